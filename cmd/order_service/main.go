@@ -1,25 +1,71 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
 	"github.com/FlyKarlik/orderService/config"
 	"github.com/FlyKarlik/orderService/internal/app/order_service"
 	"github.com/FlyKarlik/orderService/pkg/logger"
-	"github.com/FlyKarlik/orderService/pkg/validate"
 )
 
+// overrides collects repeated -set ENV=value flags.
+type overrides []string
+
+func (o *overrides) String() string {
+	return strings.Join(*o, ",")
+}
+
+func (o *overrides) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("expected ENV=value, got %q", value)
+	}
+	*o = append(*o, value)
+	return nil
+}
+
 func main() {
-	cfg, err := config.New()
+	var (
+		configPath  string
+		printConfig bool
+		sets        overrides
+	)
+	flag.StringVar(&configPath, "config", os.Getenv("ORDER_SERVICE_CONFIG_FILE"), "path to a YAML or TOML config file")
+	flag.BoolVar(&printConfig, "print-config", false, "print the effective config with secrets masked and exit")
+	flag.Var(&sets, "set", "override a setting as ENV=value, takes precedence over env and the config file (repeatable)")
+	flag.Parse()
+
+	for _, set := range sets {
+		key, value, _ := strings.Cut(set, "=")
+		if err := os.Setenv(key, value); err != nil {
+			fail(err)
+		}
+	}
+
+	cfg, err := config.New(configPath)
 	if err != nil {
-		panic(err)
+		fail(err)
 	}
 
-	if err := validate.Validate(cfg); err != nil {
-		panic(err)
+	if printConfig {
+		if err := config.Print(os.Stdout, cfg); err != nil {
+			fail(err)
+		}
+		if err := config.Validate(cfg); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	if err := config.Validate(cfg); err != nil {
+		fail(err)
 	}
 
-	logger, err := logger.New(cfg.OrderService.LogLevel)
+	logger, err := logger.New(cfg)
 	if err != nil {
-		panic(err)
+		fail(err)
 	}
 
 	orderService := order_service.New(cfg, logger)
@@ -27,3 +73,8 @@ func main() {
 		panic(err)
 	}
 }
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
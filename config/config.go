@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
@@ -8,74 +9,244 @@ import (
 )
 
 type Config struct {
-	OrderService   OrderServiceConfig   `validate:"required"`
-	GRPCServer     GRPCServerConfig     `validate:"required"`
-	GRPCApi        GRPCApiConfig        `validate:"required"`
-	GRPCClient     GRPCClientConfig     `validate:"required"`
-	Infrastructure InfrastructureConfig `validate:"required"`
+	// File is the config file the values were read from, empty when the
+	// configuration comes from the environment only.
+	File string `yaml:"-" toml:"-"`
+
+	OrderService    OrderServiceConfig    `yaml:"order_service" toml:"order_service" validate:"required"`
+	GRPCServer      GRPCServerConfig      `yaml:"grpc_server" toml:"grpc_server" validate:"required"`
+	GRPCApi         GRPCApiConfig         `yaml:"grpc_api" toml:"grpc_api" validate:"required"`
+	GRPCClient      GRPCClientConfig      `yaml:"grpc_client" toml:"grpc_client" validate:"required"`
+	HTTPGateway     HTTPGatewayConfig     `yaml:"http_gateway" toml:"http_gateway" validate:"required"`
+	HealthCheck     HealthCheckConfig     `yaml:"health_check" toml:"health_check" validate:"required"`
+	OrderRepository OrderRepositoryConfig `yaml:"order_repository" toml:"order_repository" validate:"required"`
+	Risk            RiskConfig            `yaml:"risk" toml:"risk" validate:"required"`
+	Accounts        AccountsConfig        `yaml:"accounts" toml:"accounts" validate:"required"`
+	MarketSessions  MarketSessionsConfig  `yaml:"market_sessions" toml:"market_sessions" validate:"required"`
+	Auth            AuthConfig            `yaml:"auth" toml:"auth" validate:"required"`
+	Infrastructure  InfrastructureConfig  `yaml:"infrastructure" toml:"infrastructure" validate:"required"`
 }
 
 type OrderServiceConfig struct {
-	LogLevel string `env:"ORDER_SERVICE_LOG_LEVEL" validate:"required,oneof=debug info warn error"`
+	LogLevel        string        `env:"ORDER_SERVICE_LOG_LEVEL" env-default:"info" reload:"true" yaml:"log_level" toml:"log_level" validate:"required,oneof=debug info warn error"`
+	ShutdownTimeout time.Duration `env:"ORDER_SERVICE_SHUTDOWN_TIMEOUT" env-default:"30s" yaml:"shutdown_timeout" toml:"shutdown_timeout" validate:"gte=0"`
+
+	LogLayerLevels string `env:"ORDER_SERVICE_LOG_LAYER_LEVELS" reload:"true" yaml:"log_layer_levels" toml:"log_layer_levels"`
+
+	// Sampling keeps the first LogSamplingInitial entries with the same
+	// message per second and every LogSamplingThereafter-th one after that.
+	// A zero Thereafter would drop every repeat, so it must be positive.
+	LogSamplingInitial    int `env:"ORDER_SERVICE_LOG_SAMPLING_INITIAL" yaml:"log_sampling_initial" toml:"log_sampling_initial" validate:"gte=0"`
+	LogSamplingThereafter int `env:"ORDER_SERVICE_LOG_SAMPLING_THEREAFTER" env-default:"100" yaml:"log_sampling_thereafter" toml:"log_sampling_thereafter" validate:"gt=0"`
+
+	// LogRedactKeys masks or hashes log details and span attributes by key,
+	// e.g. "user_id=hash,price=mask". The "error" key covers error messages.
+	LogRedactKeys string `env:"ORDER_SERVICE_LOG_REDACT_KEYS" yaml:"log_redact_keys" toml:"log_redact_keys"`
+	LogRedactSalt string `env:"ORDER_SERVICE_LOG_REDACT_SALT" secret:"true" yaml:"log_redact_salt" toml:"log_redact_salt"`
+
+	MarketsCacheTTL      time.Duration `env:"ORDER_SERVICE_MARKETS_CACHE_TTL" env-default:"5m" reload:"true" yaml:"markets_cache_ttl" toml:"markets_cache_ttl" validate:"gt=0"`
+	StreamPollInterval   time.Duration `env:"ORDER_SERVICE_STREAM_POLL_INTERVAL" env-default:"5s" reload:"true" yaml:"stream_poll_interval" toml:"stream_poll_interval" validate:"gt=0"`
+	ConfigReloadInterval time.Duration `env:"ORDER_SERVICE_CONFIG_RELOAD_INTERVAL" yaml:"config_reload_interval" toml:"config_reload_interval" validate:"gte=0"`
+
+	// CancelOnDisconnectGrace is how long a user whose cancel-on-disconnect
+	// streams all dropped has to reconnect before their open orders are
+	// cancelled.
+	CancelOnDisconnectGrace time.Duration `env:"ORDER_SERVICE_CANCEL_ON_DISCONNECT_GRACE" env-default:"5s" reload:"true" yaml:"cancel_on_disconnect_grace" toml:"cancel_on_disconnect_grace" validate:"gte=0"`
 }
 
+// GRPCServerConfig configures the gRPC listener. HandshakeTimeout bounds
+// the setup of a new connection, TLS handshake included; WriteTimeout
+// bounds each unary call.
 type GRPCServerConfig struct {
-	Address              string        `env:"GRPC_SERVER_ADDRESS" validate:"required"`
-	MaxRecvMsgSize       int           `env:"GRPC_SERVER_MAX_RECV_MSG_SIZE" validate:"gte=0"`
-	MaxSendMsgSize       int           `env:"GRPC_SERVER_MAX_SEND_MSG_SIZE" validate:"gte=0"`
-	EnableReflection     bool          `env:"GRPC_SERVER_ENABLE_REFLECTION" validate:"-"`
-	TLSCertFile          string        `env:"GRPC_SERVER_TLS_CERT_FILE" validate:"omitempty,file"`
-	TLSKeyFile           string        `env:"GRPC_SERVER_TLS_KEY_FILE" validate:"omitempty,file"`
-	ReadTimeout          time.Duration `env:"GRPC_SERVER_READ_TIMEOUT" validate:"gte=0"`
-	WriteTimeout         time.Duration `env:"GRPC_SERVER_WRITE_TIMEOUT" validate:"gte=0"`
-	EnablePrometheus     bool          `env:"GRPC_SERVER_ENABLE_PROMETHEUS" validate:"-"`
-	PrometheusListenAddr string        `env:"GRPC_SERVER_PROMETHEUS_LISTEN_ADDR" validate:"required_with=EnablePrometheus,omitempty"`
+	Address              string        `env:"GRPC_SERVER_ADDRESS" yaml:"address" toml:"address" validate:"required"`
+	MaxRecvMsgSize       int           `env:"GRPC_SERVER_MAX_RECV_MSG_SIZE" yaml:"max_recv_msg_size" toml:"max_recv_msg_size" validate:"gte=0"`
+	MaxSendMsgSize       int           `env:"GRPC_SERVER_MAX_SEND_MSG_SIZE" yaml:"max_send_msg_size" toml:"max_send_msg_size" validate:"gte=0"`
+	EnableReflection     bool          `env:"GRPC_SERVER_ENABLE_REFLECTION" yaml:"enable_reflection" toml:"enable_reflection" validate:"-"`
+	TLSCertFile          string        `env:"GRPC_SERVER_TLS_CERT_FILE" yaml:"tls_cert_file" toml:"tls_cert_file" validate:"omitempty,file"`
+	TLSKeyFile           string        `env:"GRPC_SERVER_TLS_KEY_FILE" yaml:"tls_key_file" toml:"tls_key_file" validate:"required_with=TLSCertFile,omitempty,file"`
+	TLSClientCAFile      string        `env:"GRPC_SERVER_TLS_CLIENT_CA_FILE" yaml:"tls_client_ca_file" toml:"tls_client_ca_file" validate:"omitempty,file"`
+	TLSReloadInterval    time.Duration `env:"GRPC_SERVER_TLS_RELOAD_INTERVAL" env-default:"1m" yaml:"tls_reload_interval" toml:"tls_reload_interval" validate:"gte=0"`
+	HandshakeTimeout     time.Duration `env:"GRPC_SERVER_HANDSHAKE_TIMEOUT" env-default:"10s" yaml:"handshake_timeout" toml:"handshake_timeout" validate:"gte=0"`
+	WriteTimeout         time.Duration `env:"GRPC_SERVER_WRITE_TIMEOUT" env-default:"10s" yaml:"write_timeout" toml:"write_timeout" validate:"gte=0"`
+	EnablePrometheus     bool          `env:"GRPC_SERVER_ENABLE_PROMETHEUS" yaml:"enable_prometheus" toml:"enable_prometheus" validate:"-"`
+	PrometheusListenAddr string        `env:"GRPC_SERVER_PROMETHEUS_LISTEN_ADDR" yaml:"prometheus_listen_addr" toml:"prometheus_listen_addr" validate:"required_with=EnablePrometheus,omitempty"`
 }
 
 type GRPCClientConfig struct {
-	ConnectTimeout    time.Duration `env:"GRPC_CLIENT_CONNECT_TIMEOUT" validate:"gte=0"`
-	MaxBackoffDelay   time.Duration `env:"GRPC_CLIENT_MAX_BACKOFF_DELAY" validate:"gte=0"`
-	BaseBackoffDelay  time.Duration `env:"GRPC_CLIENT_BASE_BACKOFF_DELAY" validate:"gte=0"`
-	BackoffMultiplier float64       `env:"GRPC_CLIENT_BACKOFF_MULTIPLIER" validate:"gte=1"`
-	BackoffJitter     float64       `env:"GRPC_CLIENT_BACKOFF_JITTER" validate:"gte=0"`
+	ConnectTimeout    time.Duration `env:"GRPC_CLIENT_CONNECT_TIMEOUT" env-default:"3s" yaml:"connect_timeout" toml:"connect_timeout" validate:"gte=0"`
+	MaxBackoffDelay   time.Duration `env:"GRPC_CLIENT_MAX_BACKOFF_DELAY" env-default:"5s" yaml:"max_backoff_delay" toml:"max_backoff_delay" validate:"gte=0"`
+	BaseBackoffDelay  time.Duration `env:"GRPC_CLIENT_BASE_BACKOFF_DELAY" env-default:"1s" yaml:"base_backoff_delay" toml:"base_backoff_delay" validate:"gte=0"`
+	BackoffMultiplier float64       `env:"GRPC_CLIENT_BACKOFF_MULTIPLIER" env-default:"1.6" yaml:"backoff_multiplier" toml:"backoff_multiplier" validate:"gte=1"`
+	BackoffJitter     float64       `env:"GRPC_CLIENT_BACKOFF_JITTER" env-default:"0.2" yaml:"backoff_jitter" toml:"backoff_jitter" validate:"gte=0"`
+	MaxRecvMsgSize    int           `env:"GRPC_CLIENT_MAX_RECV_MSG_SIZE" yaml:"max_recv_msg_size" toml:"max_recv_msg_size" validate:"gte=0"`
+	MaxSendMsgSize    int           `env:"GRPC_CLIENT_MAX_SEND_MSG_SIZE" yaml:"max_send_msg_size" toml:"max_send_msg_size" validate:"gte=0"`
+	TLSEnabled        bool          `env:"GRPC_CLIENT_TLS_ENABLED" yaml:"tls_enabled" toml:"tls_enabled" validate:"-"`
+	TLSCAFile         string        `env:"GRPC_CLIENT_TLS_CA_FILE" yaml:"tls_ca_file" toml:"tls_ca_file" validate:"omitempty,file"`
+	TLSCertFile       string        `env:"GRPC_CLIENT_TLS_CERT_FILE" yaml:"tls_cert_file" toml:"tls_cert_file" validate:"omitempty,file"`
+	TLSKeyFile        string        `env:"GRPC_CLIENT_TLS_KEY_FILE" yaml:"tls_key_file" toml:"tls_key_file" validate:"required_with=TLSCertFile,omitempty,file"`
+	TLSServerName     string        `env:"GRPC_CLIENT_TLS_SERVER_NAME" yaml:"tls_server_name" toml:"tls_server_name" validate:"-"`
+	TLSReloadInterval time.Duration `env:"GRPC_CLIENT_TLS_RELOAD_INTERVAL" env-default:"1m" yaml:"tls_reload_interval" toml:"tls_reload_interval" validate:"gte=0"`
+}
+
+type HTTPGatewayConfig struct {
+	Enabled           bool          `env:"HTTP_GATEWAY_ENABLED" yaml:"enabled" toml:"enabled" validate:"-"`
+	Address           string        `env:"HTTP_GATEWAY_ADDRESS" yaml:"address" toml:"address" validate:"required_if=Enabled true"`
+	ReadHeaderTimeout time.Duration `env:"HTTP_GATEWAY_READ_HEADER_TIMEOUT" env-default:"5s" yaml:"read_header_timeout" toml:"read_header_timeout" validate:"gte=0"`
+	MaxBodyBytes      int64         `env:"HTTP_GATEWAY_MAX_BODY_BYTES" env-default:"1048576" yaml:"max_body_bytes" toml:"max_body_bytes" validate:"gte=0"`
+	TLSCertFile       string        `env:"HTTP_GATEWAY_TLS_CERT_FILE" yaml:"tls_cert_file" toml:"tls_cert_file" validate:"omitempty,file"`
+	TLSKeyFile        string        `env:"HTTP_GATEWAY_TLS_KEY_FILE" yaml:"tls_key_file" toml:"tls_key_file" validate:"required_with=TLSCertFile,omitempty,file"`
+	TLSClientCAFile   string        `env:"HTTP_GATEWAY_TLS_CLIENT_CA_FILE" yaml:"tls_client_ca_file" toml:"tls_client_ca_file" validate:"omitempty,file"`
+	TLSReloadInterval time.Duration `env:"HTTP_GATEWAY_TLS_RELOAD_INTERVAL" env-default:"1m" yaml:"tls_reload_interval" toml:"tls_reload_interval" validate:"gte=0"`
+}
+
+type HealthCheckConfig struct {
+	Interval      time.Duration `env:"HEALTH_CHECK_INTERVAL" env-default:"5s" yaml:"interval" toml:"interval" validate:"gte=0"`
+	Timeout       time.Duration `env:"HEALTH_CHECK_TIMEOUT" env-default:"2s" yaml:"timeout" toml:"timeout" validate:"gte=0"`
+	ShutdownDelay time.Duration `env:"HEALTH_CHECK_SHUTDOWN_DELAY" yaml:"shutdown_delay" toml:"shutdown_delay" validate:"gte=0"`
+}
+
+const (
+	ExecutionModeMatching  = "matching"
+	ExecutionModeSimulated = "simulated"
+)
+
+type OrderRepositoryConfig struct {
+	Shards int `env:"ORDER_REPOSITORY_SHARDS" env-default:"32" yaml:"shards" toml:"shards" validate:"gt=0"`
+	// ExecutionMode selects what fills orders: "matching" runs a price-time
+	// priority book per market, "simulated" fills and rejects at random and
+	// refuses market orders, which have no price to fill at without a book.
+	// Simulated stays the default because orders without a side are buys,
+	// which only makes sense on a book when every client sends the side.
+	ExecutionMode string `env:"ORDER_REPOSITORY_EXECUTION_MODE" env-default:"simulated" yaml:"execution_mode" toml:"execution_mode" validate:"oneof=matching simulated"`
+
+	PersistenceEnabled bool          `env:"ORDER_REPOSITORY_PERSISTENCE_ENABLED" yaml:"persistence_enabled" toml:"persistence_enabled" validate:"-"`
+	DataDir            string        `env:"ORDER_REPOSITORY_DATA_DIR" yaml:"data_dir" toml:"data_dir" validate:"required_if=PersistenceEnabled true"`
+	WALSync            string        `env:"ORDER_REPOSITORY_WAL_SYNC" env-default:"always" yaml:"wal_sync" toml:"wal_sync" validate:"oneof=always interval none"`
+	WALSyncInterval    time.Duration `env:"ORDER_REPOSITORY_WAL_SYNC_INTERVAL" env-default:"1s" yaml:"wal_sync_interval" toml:"wal_sync_interval" validate:"gt=0"`
+	SnapshotInterval   time.Duration `env:"ORDER_REPOSITORY_SNAPSHOT_INTERVAL" env-default:"5m" yaml:"snapshot_interval" toml:"snapshot_interval" validate:"gte=0"`
+}
+
+// RiskConfig sets the limits of the pre-trade risk checks. A zero or empty
+// limit disables its check. MarketLimits overrides limits per market in the
+// "market_id:limit=value;limit=value,market_id:..." form, with the limit
+// names of the yaml keys below, e.g.
+// "0b7c...:max_order_quantity=500;price_band_percent=5". The price limits
+// compare with the mid of the book and need the matching execution mode.
+type RiskConfig struct {
+	Enabled             bool   `env:"RISK_ENABLED" env-default:"true" reload:"true" yaml:"enabled" toml:"enabled" validate:"-"`
+	MaxOrderQuantity    int64  `env:"RISK_MAX_ORDER_QUANTITY" reload:"true" yaml:"max_order_quantity" toml:"max_order_quantity" validate:"gte=0"`
+	MaxOrderNotional    string `env:"RISK_MAX_ORDER_NOTIONAL" reload:"true" yaml:"max_order_notional" toml:"max_order_notional"`
+	MaxOpenOrders       int    `env:"RISK_MAX_OPEN_ORDERS" reload:"true" yaml:"max_open_orders" toml:"max_open_orders" validate:"gte=0"`
+	PriceBandPercent    string `env:"RISK_PRICE_BAND_PERCENT" reload:"true" yaml:"price_band_percent" toml:"price_band_percent"`
+	FatFingerPriceRatio string `env:"RISK_FAT_FINGER_PRICE_RATIO" reload:"true" yaml:"fat_finger_price_ratio" toml:"fat_finger_price_ratio"`
+	MarketLimits        string `env:"RISK_MARKET_LIMITS" reload:"true" yaml:"market_limits" toml:"market_limits"`
+}
+
+// AccountsConfig turns on funds checking: orders reserve what they may pay
+// from the user's balance and are rejected when it does not cover them.
+// MarketAssets names the base and quote asset of every market in the
+// "market_id:BASE/QUOTE,market_id:BASE/QUOTE" form; orders in markets
+// missing from it are rejected while accounts are enabled. Accounts need
+// the matching execution mode.
+type AccountsConfig struct {
+	Enabled      bool   `env:"ACCOUNTS_ENABLED" yaml:"enabled" toml:"enabled" validate:"-"`
+	MarketAssets string `env:"ACCOUNTS_MARKET_ASSETS" yaml:"market_assets" toml:"market_assets"`
+}
+
+// MarketSessionsConfig sets the trading sessions of markets. Schedules
+// lists the sessions of each scheduled market in the
+// "market_id:days hh:mm-hh:mm;days hh:mm-hh:mm,market_id:..." form, where
+// days is "daily", a day ("sat") or a range of days ("mon-fri"), e.g.
+// "0b7c...:mon-fri 09:00-17:30". A session ending before it starts runs
+// past midnight. Markets without a schedule are always open; scheduled
+// markets are closed outside their sessions. Times are in Timezone.
+type MarketSessionsConfig struct {
+	Schedules string `env:"MARKET_SESSIONS_SCHEDULES" reload:"true" yaml:"schedules" toml:"schedules"`
+	Timezone  string `env:"MARKET_SESSIONS_TIMEZONE" env-default:"UTC" reload:"true" yaml:"timezone" toml:"timezone" validate:"required"`
+}
+
+// AuthConfig names the client identities trusted with admin operations.
+// Identities come from the client certificate of a mutual TLS connection,
+// on the gRPC server or the HTTP gateway, and match its common name or one
+// of its URI SANs. A certificate whose common name is a user ID identifies
+// that user.
+type AuthConfig struct {
+	AdminIdentities string `env:"AUTH_ADMIN_IDENTITIES" reload:"true" yaml:"admin_identities" toml:"admin_identities"`
 }
 
 type GRPCApiConfig struct {
-	SpotInstrumentServiceHost string `env:"GRPC_API_SPOT_INSTRUMENT_SERVICE_HOST" validate:"required"`
+	SpotInstrumentServiceHost string `env:"GRPC_API_SPOT_INSTRUMENT_SERVICE_HOST" yaml:"spot_instrument_service_host" toml:"spot_instrument_service_host" validate:"required"`
 }
 
 type InfrastructureConfig struct {
-	Prometheus    PrometheusConfig    `validate:"required"`
-	Opentelemetry OpentelemetryConfig `validate:"required"`
-	RedisConfig   RedisConfig         `validate:"required"`
+	Prometheus    PrometheusConfig    `yaml:"prometheus" toml:"prometheus" validate:"required"`
+	Opentelemetry OpentelemetryConfig `yaml:"opentelemetry" toml:"opentelemetry" validate:"required"`
+	RedisConfig   RedisConfig         `yaml:"redis" toml:"redis" validate:"required"`
 }
 
 type PrometheusConfig struct {
-	Address string `env:"PROMETHEUS_ADDRESS" validate:"required"`
+	Address string `env:"PROMETHEUS_ADDRESS" yaml:"address" toml:"address" validate:"required"`
 }
 
 type OpentelemetryConfig struct {
-	ServiceName string `env:"OPENTELEMETRY_SERVICE_NAME" validate:"required"`
-	Host        string `env:"OPENTELEMETRY_AGENT_HOST" validate:"required,hostname|ip"`
-	Port        string `env:"OPENTELEMETRY_PORT" validate:"required,numeric"`
-	LogSpans    bool   `env:"OPENTELEMETRY_LOG_SPANS" validate:"-"`
-	Enabled     bool   `env:"OPENTELEMETRY_ENABLED" validate:"-"`
+	ServiceName string  `env:"OPENTELEMETRY_SERVICE_NAME" env-default:"order-service" yaml:"service_name" toml:"service_name" validate:"required"`
+	Host        string  `env:"OPENTELEMETRY_AGENT_HOST" yaml:"host" toml:"host" validate:"required_unless=Exporter stdout,omitempty,hostname|ip"`
+	Port        string  `env:"OPENTELEMETRY_PORT" yaml:"port" toml:"port" validate:"required_unless=Exporter stdout,omitempty,numeric"`
+	LogSpans    bool    `env:"OPENTELEMETRY_LOG_SPANS" yaml:"log_spans" toml:"log_spans" validate:"-"`
+	Enabled     bool    `env:"OPENTELEMETRY_ENABLED" yaml:"enabled" toml:"enabled" validate:"-"`
+	Exporter    string  `env:"OPENTELEMETRY_EXPORTER" env-default:"otlp_http" yaml:"exporter" toml:"exporter" validate:"omitempty,oneof=otlp_grpc otlp_http stdout"`
+	Insecure    bool    `env:"OPENTELEMETRY_INSECURE" yaml:"insecure" toml:"insecure" validate:"-"`
+	Sampler     string  `env:"OPENTELEMETRY_SAMPLER" env-default:"always" yaml:"sampler" toml:"sampler" validate:"omitempty,oneof=always never ratio parentbased_always parentbased_ratio"`
+	SampleRatio float64 `env:"OPENTELEMETRY_SAMPLE_RATIO" env-default:"1" yaml:"sample_ratio" toml:"sample_ratio" validate:"gte=0,lte=1"`
 }
 
 type RedisConfig struct {
-	Host         string        `env:"REDIS_HOST" validate:"required,hostname|ip"`
-	Port         string        `env:"REDIS_PORT" validate:"required,numeric"`
-	Password     string        `env:"REDIS_PASSWORD" validate:"-"` // опционально
-	DB           int           `env:"REDIS_DB" validate:"gte=0"`
-	MinIdleConns int           `env:"REDIS_MIN_IDLE_CONNS" validate:"gte=0"`
-	PoolSize     int           `env:"REDIS_POOL_SIZE" validate:"gte=0"`
-	PoolTimeout  time.Duration `env:"REDIS_POOL_TIMEOUT" validate:"gte=0"`
+	Mode         string        `env:"REDIS_MODE" env-default:"standalone" yaml:"mode" toml:"mode" validate:"oneof=standalone sentinel cluster"`
+	Host         string        `env:"REDIS_HOST" yaml:"host" toml:"host" validate:"required_if=Mode standalone,omitempty,hostname|ip"`
+	Port         string        `env:"REDIS_PORT" env-default:"6379" yaml:"port" toml:"port" validate:"required_if=Mode standalone,omitempty,numeric"`
+	Addrs        []string      `env:"REDIS_ADDRS" env-separator:"," yaml:"addrs" toml:"addrs" validate:"required_unless=Mode standalone,dive,hostname_port"`
+	MasterName   string        `env:"REDIS_MASTER_NAME" yaml:"master_name" toml:"master_name" validate:"required_if=Mode sentinel"`
+	Username     string        `env:"REDIS_USERNAME" yaml:"username" toml:"username" validate:"-"`
+	Password     string        `env:"REDIS_PASSWORD" secret:"true" yaml:"password" toml:"password" validate:"-"` // опционально
+	DB           int           `env:"REDIS_DB" yaml:"db" toml:"db" validate:"gte=0"`
+	MinIdleConns int           `env:"REDIS_MIN_IDLE_CONNS" yaml:"min_idle_conns" toml:"min_idle_conns" validate:"gte=0"`
+	PoolSize     int           `env:"REDIS_POOL_SIZE" env-default:"10" yaml:"pool_size" toml:"pool_size" validate:"gte=0"`
+	PoolTimeout  time.Duration `env:"REDIS_POOL_TIMEOUT" env-default:"4s" yaml:"pool_timeout" toml:"pool_timeout" validate:"gte=0"`
+	DialTimeout  time.Duration `env:"REDIS_DIAL_TIMEOUT" env-default:"5s" yaml:"dial_timeout" toml:"dial_timeout" validate:"gte=0"`
+	ReadTimeout  time.Duration `env:"REDIS_READ_TIMEOUT" env-default:"3s" yaml:"read_timeout" toml:"read_timeout" validate:"gte=0"`
+	WriteTimeout time.Duration `env:"REDIS_WRITE_TIMEOUT" env-default:"3s" yaml:"write_timeout" toml:"write_timeout" validate:"gte=0"`
+
+	SentinelUsername string `env:"REDIS_SENTINEL_USERNAME" yaml:"sentinel_username" toml:"sentinel_username" validate:"-"`
+	SentinelPassword string `env:"REDIS_SENTINEL_PASSWORD" secret:"true" yaml:"sentinel_password" toml:"sentinel_password" validate:"-"`
+
+	TLSEnabled        bool          `env:"REDIS_TLS_ENABLED" yaml:"tls_enabled" toml:"tls_enabled" validate:"-"`
+	TLSCAFile         string        `env:"REDIS_TLS_CA_FILE" yaml:"tls_ca_file" toml:"tls_ca_file" validate:"omitempty,file"`
+	TLSCertFile       string        `env:"REDIS_TLS_CERT_FILE" yaml:"tls_cert_file" toml:"tls_cert_file" validate:"omitempty,file"`
+	TLSKeyFile        string        `env:"REDIS_TLS_KEY_FILE" yaml:"tls_key_file" toml:"tls_key_file" validate:"required_with=TLSCertFile,omitempty,file"`
+	TLSServerName     string        `env:"REDIS_TLS_SERVER_NAME" yaml:"tls_server_name" toml:"tls_server_name" validate:"-"`
+	TLSReloadInterval time.Duration `env:"REDIS_TLS_RELOAD_INTERVAL" env-default:"1m" yaml:"tls_reload_interval" toml:"tls_reload_interval" validate:"gte=0"`
+
+	CacheCodec            string `env:"REDIS_CACHE_CODEC" env-default:"json" yaml:"cache_codec" toml:"cache_codec" validate:"oneof=json protobuf"`
+	CacheCompression      string `env:"REDIS_CACHE_COMPRESSION" env-default:"none" yaml:"cache_compression" toml:"cache_compression" validate:"oneof=none gzip"`
+	CacheCompressMinBytes int    `env:"REDIS_CACHE_COMPRESS_MIN_BYTES" env-default:"1024" yaml:"cache_compress_min_bytes" toml:"cache_compress_min_bytes" validate:"gte=0"`
 }
 
-func New() (*Config, error) {
+// New loads the configuration. Sources are layered from lowest to highest
+// precedence: env-default tags, the YAML/TOML file at path (when set) and
+// environment variables. Flag overrides on cmd/order_service are applied as
+// environment variables before New is called.
+func New(path string) (*Config, error) {
 	cfg := &Config{}
-	if err := cleanenv.ReadEnv(cfg); err != nil {
-		return nil, err
+	if path == "" {
+		if err := cleanenv.ReadEnv(cfg); err != nil {
+			return nil, err
+		}
+		return cfg, nil
+	}
+
+	if err := cleanenv.ReadConfig(path, cfg); err != nil {
+		return nil, fmt.Errorf("read config file %s: %w", path, err)
 	}
+	cfg.File = path
 	return cfg, nil
 }
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+const maskedValue = "******"
+
+// Print writes the effective configuration as ENV=value lines in declaration
+// order. Fields tagged secret:"true" are masked when set.
+func Print(w io.Writer, cfg *Config) error {
+	return walk(reflect.ValueOf(cfg).Elem(), func(field reflect.StructField, value reflect.Value) error {
+		_, err := fmt.Fprintf(w, "%s=%s\n", field.Tag.Get("env"), render(field, value))
+		return err
+	})
+}
+
+func walk(v reflect.Value, fn func(reflect.StructField, reflect.Value) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			if err := walk(value, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if field.Tag.Get("env") == "" {
+			continue
+		}
+		if err := fn(field, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func render(field reflect.StructField, value reflect.Value) string {
+	if field.Tag.Get("secret") == "true" && !value.IsZero() {
+		return maskedValue
+	}
+	if value.Kind() == reflect.Slice {
+		items := make([]string, value.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(value.Index(i).Interface())
+		}
+		return strings.Join(items, field.Tag.Get("env-separator"))
+	}
+	return fmt.Sprint(value.Interface())
+}
+
+func secretEnvs() map[string]bool {
+	secrets := make(map[string]bool)
+	_ = walk(reflect.ValueOf(Config{}), func(field reflect.StructField, _ reflect.Value) error {
+		if field.Tag.Get("secret") == "true" {
+			secrets[field.Tag.Get("env")] = true
+		}
+		return nil
+	})
+	return secrets
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"reflect"
+)
+
+// ReloadResult describes how a newly loaded config was merged into the
+// running one.
+type ReloadResult struct {
+	// Changed lists reloadable settings that took a new value.
+	Changed []string
+	// Rejected lists settings that differ but cannot change at runtime; the
+	// running value is kept for them.
+	Rejected []string
+}
+
+// MergeReloadable returns a copy of current where only fields tagged
+// reload:"true" are taken from next. Settings are named by their env var.
+func MergeReloadable(current *Config, next *Config) (*Config, ReloadResult) {
+	merged := *current
+	var result ReloadResult
+	mergeStruct(reflect.ValueOf(&merged).Elem(), reflect.ValueOf(next).Elem(), &result)
+	return &merged, result
+}
+
+func mergeStruct(dst reflect.Value, src reflect.Value, result *ReloadResult) {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		dstField, srcField := dst.Field(i), src.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			mergeStruct(dstField, srcField, result)
+			continue
+		}
+
+		env := field.Tag.Get("env")
+		if env == "" || reflect.DeepEqual(dstField.Interface(), srcField.Interface()) {
+			continue
+		}
+
+		if field.Tag.Get("reload") != "true" {
+			result.Rejected = append(result.Rejected, env)
+			continue
+		}
+
+		dstField.Set(srcField)
+		result.Changed = append(result.Changed, env)
+	}
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/FlyKarlik/orderService/pkg/decimal"
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidationError lists every invalid field of a Config, named by the
+// environment variable that sets it.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+type FieldError struct {
+	Env     string
+	Path    string
+	Value   string
+	Problem string
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid configuration (%d problems):", len(e.Fields))
+	for _, f := range e.Fields {
+		fmt.Fprintf(&b, "\n  %s (%s): %s", f.Env, f.Path, f.Problem)
+		if f.Value != "" {
+			fmt.Fprintf(&b, ", got %q", f.Value)
+		}
+	}
+	return b.String()
+}
+
+var configValidator = newConfigValidator()
+
+func newConfigValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		if env := field.Tag.Get("env"); env != "" {
+			return env
+		}
+		return field.Name
+	})
+	v.RegisterStructValidation(validateConfig, Config{})
+	return v
+}
+
+// validateConfig checks the rules that span sections of the config.
+func validateConfig(sl validator.StructLevel) {
+	cfg := sl.Current().Interface().(Config)
+
+	// Simulated fills have no counterparty, so settling them would credit
+	// balances from nowhere.
+	if cfg.Accounts.Enabled && cfg.OrderRepository.ExecutionMode != ExecutionModeMatching {
+		sl.ReportError(cfg.Accounts.Enabled, "ACCOUNTS_ENABLED", "Accounts.Enabled", "matching_mode", "")
+	}
+
+	// Price limits compare with the mid of the book, and simulated mode has
+	// no book, so they would never be checked.
+	if cfg.Risk.Enabled && cfg.OrderRepository.ExecutionMode != ExecutionModeMatching {
+		if isPriceLimitSet(cfg.Risk.PriceBandPercent) {
+			sl.ReportError(cfg.Risk.PriceBandPercent, "RISK_PRICE_BAND_PERCENT", "Risk.PriceBandPercent", "matching_mode", "")
+		}
+		if isPriceLimitSet(cfg.Risk.FatFingerPriceRatio) {
+			sl.ReportError(cfg.Risk.FatFingerPriceRatio, "RISK_FAT_FINGER_PRICE_RATIO", "Risk.FatFingerPriceRatio", "matching_mode", "")
+		}
+		if hasMarketPriceLimits(cfg.Risk.MarketLimits) {
+			sl.ReportError(cfg.Risk.MarketLimits, "RISK_MARKET_LIMITS", "Risk.MarketLimits", "matching_mode", "")
+		}
+	}
+}
+
+// isPriceLimitSet reports whether value enables a price limit. Malformed
+// values count as set; the risk pipeline reports them when it parses them.
+func isPriceLimitSet(value string) bool {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return false
+	}
+	d, err := decimal.Parse(value)
+	return err != nil || !d.IsZero()
+}
+
+// hasMarketPriceLimits reports whether the per-market overrides in
+// RISK_MARKET_LIMITS enable a price limit for any market.
+func hasMarketPriceLimits(marketLimits string) bool {
+	for _, entry := range strings.Split(marketLimits, ",") {
+		_, overrides, _ := strings.Cut(entry, ":")
+		for _, pair := range strings.Split(overrides, ";") {
+			name, value, _ := strings.Cut(pair, "=")
+			switch strings.TrimSpace(name) {
+			case "price_band_percent", "fat_finger_price_ratio":
+				if isPriceLimitSet(value) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Validate checks cfg against its validate tags and reports all failures at
+// once rather than stopping at the first one.
+func Validate(cfg *Config) error {
+	err := configValidator.Struct(cfg)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	secrets := secretEnvs()
+	result := &ValidationError{}
+	for _, fe := range validationErrs {
+		value := fmt.Sprint(fe.Value())
+		if secrets[fe.Field()] {
+			value = maskedValue
+		}
+		result.Fields = append(result.Fields, FieldError{
+			Env:     fe.Field(),
+			Path:    strings.TrimPrefix(fe.StructNamespace(), "Config."),
+			Value:   value,
+			Problem: describe(fe),
+		})
+	}
+	return result
+}
+
+func describe(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_if":
+		field, value, _ := strings.Cut(fe.Param(), " ")
+		return fmt.Sprintf("is required when %s is %s", field, value)
+	case "required_unless":
+		field, value, _ := strings.Cut(fe.Param(), " ")
+		return fmt.Sprintf("is required unless %s is %s", field, value)
+	case "required_with":
+		return fmt.Sprintf("is required when %s is set", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s]", fe.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "gte":
+		return fmt.Sprintf("must be greater than or equal to %s", fe.Param())
+	case "lte":
+		return fmt.Sprintf("must be less than or equal to %s", fe.Param())
+	case "file":
+		return "must be an existing file"
+	case "numeric":
+		return "must be numeric"
+	case "hostname_port":
+		return "must be a host:port address"
+	case "hostname|ip":
+		return "must be a hostname or an IP address"
+	case "matching_mode":
+		return "requires ORDER_REPOSITORY_EXECUTION_MODE=matching"
+	default:
+		return fmt.Sprintf("failed %q validation", fe.Tag())
+	}
+}
@@ -2,32 +2,44 @@ package order_service
 
 import (
 	"context"
+	"errors"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/FlyKarlik/orderService/config"
 	grpc_async_handler "github.com/FlyKarlik/orderService/internal/delivery/grpc/async"
 	grpc_interceptor "github.com/FlyKarlik/orderService/internal/delivery/grpc/interceptor"
-	grpc_sync_handler "github.com/FlyKarlik/orderService/internal/delivery/grpc/sync"
 	"github.com/FlyKarlik/orderService/internal/driver"
 	"github.com/FlyKarlik/orderService/internal/repository"
+	"github.com/FlyKarlik/orderService/internal/risk"
+	"github.com/FlyKarlik/orderService/internal/session"
 	"github.com/FlyKarlik/orderService/internal/usecase"
 	"github.com/FlyKarlik/orderService/pkg/cache"
 	grpc_client "github.com/FlyKarlik/orderService/pkg/client/grpc"
+	"github.com/FlyKarlik/orderService/pkg/configwatch"
+	"github.com/FlyKarlik/orderService/pkg/healthcheck"
+	"github.com/FlyKarlik/orderService/pkg/lifecycle"
 	"github.com/FlyKarlik/orderService/pkg/logger"
 	"github.com/FlyKarlik/orderService/pkg/metric"
 	"github.com/FlyKarlik/orderService/pkg/tracer"
 	pb "github.com/FlyKarlik/proto/order_service/gen/order_service/proto"
-	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc"
 )
 
 type OrderService struct {
-	cfg        *config.Config
-	grpcServer *grpc.Server
-	logger     logger.Logger
+	cfg              *config.Config
+	grpcServer       *grpc.Server
+	grpcListener     net.Listener
+	grpcAsyncHandler *grpc_async_handler.GRPCAsyncHandler
+	httpServer       *http.Server
+	httpListener     net.Listener
+	prometheusServer *http.Server
+	logger           logger.Logger
 }
 
 func New(cfg *config.Config, logger logger.Logger) *OrderService {
@@ -37,160 +49,404 @@ func New(cfg *config.Config, logger logger.Logger) *OrderService {
 	}
 }
 
+// Start builds every component, serves until SIGINT/SIGTERM or until a
+// component fails, and then shuts everything down in reverse start order
+// within ORDER_SERVICE_SHUTDOWN_TIMEOUT.
 func (o *OrderService) Start() error {
 	const layer = "app"
 	const method = "Start"
 
 	o.logger.Info(layer, method, "starting service")
 
-	o.mustSetupTracer()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Components are added to the manager as soon as they exist, so a
+	// failure further down the setup stops what was already built.
+	manager := lifecycle.New(o.logger, o.cfg.OrderService.ShutdownTimeout)
+
+	tracerShutdown, err := o.mustSetupTracer(ctx)
+	if err != nil {
+		o.logger.Error(layer, method, "failed to set up tracing", err)
+		return err
+	}
+	manager.Add(lifecycle.Component{
+		Name: "tracer",
+		Stop: tracerShutdown,
+	})
 
 	grpcInterceptor := o.mustSetupGRPCInterceptor()
 
-	driver, clients, err := o.mustSetupDriver(o.cfg, o.logger, grpcInterceptor)
+	metrics := o.mustSetupMetrics()
+
+	driver, clients, err := o.mustSetupDriver(ctx, o.cfg, o.logger, grpcInterceptor, metrics)
 	if err != nil {
 		o.logger.Error(layer, method, "failed to init driver client", err)
-		return err
+		return errors.Join(err, manager.Abort())
+	}
+	manager.Add(lifecycle.Component{
+		Name: "grpc_clients",
+		Stop: func(context.Context) error {
+			return o.mustCloseConnectionWithGRPCClients(clients)
+		},
+	})
+
+	redisClient, err := o.mustSetupRedis(ctx)
+	if err != nil {
+		o.logger.Error(layer, method, "failed to init redis client", err)
+		return errors.Join(err, manager.Abort())
+	}
+	manager.Add(lifecycle.Component{
+		Name: "redis",
+		Stop: func(context.Context) error {
+			return redisClient.Close()
+		},
+	})
+
+	serializer, err := cache.NewSerializer(o.cfg)
+	if err != nil {
+		o.logger.Error(layer, method, "failed to init cache serializer", err)
+		return errors.Join(err, manager.Abort())
 	}
 
-	repo := o.mustSetupRepo()
-	usecase := o.mustSetupUsecase(driver, repo)
+	repoCtx, cancelRepo := context.WithCancel(context.Background())
+	repo, err := o.mustSetupRepo(repoCtx, redisClient, serializer, metrics)
+	if err != nil {
+		o.logger.Error(layer, method, "failed to set up repository", err)
+		cancelRepo()
+		return errors.Join(err, manager.Abort())
+	}
+	manager.Add(lifecycle.Component{
+		Name: "order_repository",
+		Stop: func(ctx context.Context) error {
+			cancelRepo()
+			return repo.Close(ctx)
+		},
+	})
+
+	riskPipeline, err := o.mustSetupRisk(repo, metrics)
+	if err != nil {
+		o.logger.Error(layer, method, "failed to set up risk checks", err)
+		return errors.Join(err, manager.Abort())
+	}
+	calendar, err := o.mustSetupSessions()
+	if err != nil {
+		o.logger.Error(layer, method, "failed to set up market sessions", err)
+		return errors.Join(err, manager.Abort())
+	}
+	usecase := o.mustSetupUsecase(driver, repo, riskPipeline, calendar, metrics)
 
-	go func() {
-		o.logger.Infof(
-			layer,
-			method,
-			"starting prometheus",
-			"address: %s", o.cfg.Infrastructure.Prometheus.Address)
-		if err := o.mustStartPrometheus(); err != nil {
-			o.logger.Error(layer, method, "failed to start prometheus", err)
-			os.Exit(1)
-		}
-		o.logger.Info(layer, method, "prometheus started successfully")
-	}()
-
-	go func() {
-		o.logger.Infof(
-			layer,
-			method,
-			"starting gRPC server",
-			"address: %s", o.cfg.GRPCServer.Address)
-		if err := o.mustStartGRPCServer(usecase, grpcInterceptor); err != nil {
-			o.logger.Error(layer, method, "failed to start grpc server", err)
-			os.Exit(1)
+	configWatcher := o.mustSetupConfigWatcher(usecase, riskPipeline, calendar)
+
+	healthChecker := o.mustSetupHealthChecker(redisClient, clients, repo)
+
+	if err := o.mustSetupGRPCServer(ctx, usecase, grpcInterceptor, healthChecker); err != nil {
+		o.logger.Error(layer, method, "failed to set up grpc server", err)
+		return errors.Join(err, manager.Abort())
+	}
+	o.mustSetupPrometheus()
+	if o.cfg.HTTPGateway.Enabled {
+		if err := o.mustSetupHTTPGateway(ctx, usecase); err != nil {
+			o.logger.Error(layer, method, "failed to set up http gateway", err)
+			return errors.Join(err, manager.Abort())
 		}
-		o.logger.Info(layer, method, "grpc server stopped")
-	}()
+	}
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	manager.Add(
+		lifecycle.Component{
+			Name: "log_level_signal",
+			Run:  o.mustWatchLogLevelSignal,
+		},
+		lifecycle.Component{
+			Name: "config_watcher",
+			Run:  configWatcher.Run,
+		},
+		lifecycle.Component{
+			Name: "prometheus",
+			Run:  o.mustStartPrometheus,
+			Stop: o.mustStopPrometheus,
+		},
+		lifecycle.Component{
+			Name:  "grpc_server",
+			Start: o.mustListenGRPCServer,
+			Run:   o.mustStartGRPCServer,
+			Stop:  o.mustStopGRPCServer,
+		},
+	)
+	if o.cfg.HTTPGateway.Enabled {
+		manager.Add(lifecycle.Component{
+			Name:  "http_gateway",
+			Start: o.mustListenHTTPGateway,
+			Run:   o.mustStartHTTPGateway,
+			Stop:  o.mustStopHTTPGateway,
+		})
+	}
+	manager.Add(lifecycle.Component{
+		Name: "health_checker",
+		Start: func(ctx context.Context) error {
+			healthChecker.Start(ctx)
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			return o.mustDrainHealthChecker(ctx, healthChecker)
+		},
+	})
 
 	o.logger.Info(layer, method, "waiting for shutdown signal")
-	<-quit
-	o.logger.Info(layer, method, "shutdown signal received")
-
-	err = o.mustCloseConnectionWithGRPCClients(clients)
-	if err != nil {
-		o.logger.Error(layer, method, "failed to close connection with grpc clients", err)
+	if err := manager.Run(ctx); err != nil {
+		o.logger.Error(layer, method, "service stopped with errors", err)
+		return err
 	}
-	o.mustStopGRPCServer()
-	o.logger.Info(layer, method, "service stopped gracefully")
 
+	o.logger.Info(layer, method, "service stopped gracefully")
 	return nil
 }
 
-func (o *OrderService) mustStartPrometheus() error {
-	return metric.StartPrometheus(o.cfg)
+func (o *OrderService) mustSetupTracer(ctx context.Context) (func(context.Context) error, error) {
+	const method = "mustSetupTracer"
+	const layer = "app"
+
+	o.logger.Info(layer, method, "setting up tracing",
+		"enabled", o.cfg.Infrastructure.Opentelemetry.Enabled,
+		"exporter", o.cfg.Infrastructure.Opentelemetry.Exporter,
+		"sampler", o.cfg.Infrastructure.Opentelemetry.Sampler,
+	)
+	return tracer.New(ctx, o.cfg, o.logger)
 }
 
-func (o *OrderService) mustSetupTracer() {
-	const method = "mustSetupTracer"
+func (o *OrderService) mustSetupRedis(ctx context.Context) (cache.RedisClient, error) {
+	const method = "mustSetupRedis"
 	const layer = "app"
 
-	tracer.New(context.Background(), o.cfg)
+	o.logger.Info(layer, method, "setting up redis client",
+		"mode", o.cfg.Infrastructure.RedisConfig.Mode,
+		"tls", o.cfg.Infrastructure.RedisConfig.TLSEnabled,
+	)
+	return cache.NewRedisClient(ctx, o.cfg, func(err error) {
+		o.logger.Error(layer, method, "failed to reload redis tls certificates", err)
+	})
+}
+
+func (o *OrderService) mustSetupMetrics() *metric.Registry {
+	const method = "mustSetupMetrics"
+	const layer = "app"
 
-	o.logger.Info(layer, method, "setting up tracing")
+	o.logger.Info(layer, method, "setting up metrics registry")
+	return metric.NewRegistry(prometheus.DefaultRegisterer)
 }
 
-func (o *OrderService) mustSetupRepo() repository.Repository {
+func (o *OrderService) mustSetupRepo(
+	ctx context.Context,
+	redisClient cache.RedisClient,
+	serializer *cache.Serializer,
+	metrics *metric.Registry,
+) (repository.Repository, error) {
 	const method = "mustSetupRepo"
 	const layer = "app"
 
-	redisClient := cache.NewRedisClient(o.cfg)
-
 	o.logger.Info(layer, method, "setting up repository")
-	return repository.New(o.logger, redisClient)
+	return repository.New(ctx, o.cfg, o.logger, redisClient, serializer, metrics)
 }
 
 func (o *OrderService) mustSetupDriver(
+	ctx context.Context,
 	cfg *config.Config,
 	l logger.Logger,
 	interceptor *grpc_interceptor.GRPCInterceptor,
+	metrics *metric.Registry,
 ) (driver.Driver, []grpc_client.IGRPCClient, error) {
 	const method = "mustSetuDriver"
 	const layer = "app"
 
 	o.logger.Info(layer, method, "setting up driver")
-	return driver.New(cfg, l, interceptor)
+	return driver.New(ctx, cfg, l, interceptor, metrics)
 }
 
-func (o *OrderService) mustSetupUsecase(driver driver.Driver, repo repository.Repository) usecase.Usecase {
+func (o *OrderService) mustSetupRisk(
+	repo repository.Repository,
+	metrics *metric.Registry,
+) (risk.IPipeline, error) {
+	const method = "mustSetupRisk"
+	const layer = "app"
+
+	o.logger.Info(layer, method, "setting up risk checks",
+		"enabled", o.cfg.Risk.Enabled,
+	)
+	return risk.New(o.cfg, o.logger, repo, metrics)
+}
+
+func (o *OrderService) mustSetupSessions() (session.ICalendar, error) {
+	const method = "mustSetupSessions"
+	const layer = "app"
+
+	o.logger.Info(layer, method, "setting up market sessions",
+		"timezone", o.cfg.MarketSessions.Timezone,
+	)
+	return session.New(o.cfg, o.logger)
+}
+
+func (o *OrderService) mustSetupUsecase(
+	driver driver.Driver,
+	repo repository.Repository,
+	riskPipeline risk.IPipeline,
+	calendar session.ICalendar,
+	metrics *metric.Registry,
+) usecase.Usecase {
 	const method = "mustSetuUsecase"
 	const layer = "app"
 
 	o.logger.Info(layer, method, "setting up usecase")
-	return usecase.New(o.logger, driver, repo)
+	return usecase.New(o.cfg, o.logger, driver, repo, riskPipeline, calendar, metrics)
 }
 
-func (o *OrderService) mustSetupGRPCInterceptor() *grpc_interceptor.GRPCInterceptor {
-	return grpc_interceptor.New(o.logger)
+// mustSetupConfigWatcher subscribes the components whose settings can be
+// reloaded at runtime.
+func (o *OrderService) mustSetupConfigWatcher(
+	usecase usecase.Usecase,
+	riskPipeline risk.IPipeline,
+	calendar session.ICalendar,
+) *configwatch.Watcher {
+	const method = "mustSetupConfigWatcher"
+	const layer = "app"
+
+	watcher := configwatch.New(o.logger, o.cfg)
+	// Levels changed at runtime through PUT /v1/admin/log-level on the HTTP
+	// gateway or SIGUSR1 are kept
+	// until a reload changes the configured levels themselves.
+	logLevel, logLayerLevels := o.cfg.OrderService.LogLevel, o.cfg.OrderService.LogLayerLevels
+	watcher.Subscribe("logger", func(cfg *config.Config) {
+		if cfg.OrderService.LogLevel == logLevel && cfg.OrderService.LogLayerLevels == logLayerLevels {
+			return
+		}
+		err := o.logger.Levels().Reset(cfg.OrderService.LogLevel, cfg.OrderService.LogLayerLevels)
+		if err != nil {
+			o.logger.Error(layer, method, "failed to apply log levels", err)
+			return
+		}
+		logLevel, logLayerLevels = cfg.OrderService.LogLevel, cfg.OrderService.LogLayerLevels
+	})
+	watcher.Subscribe("usecase", usecase.ApplyConfig)
+	watcher.Subscribe("risk", riskPipeline.ApplyConfig)
+	watcher.Subscribe("market_sessions", calendar.ApplyConfig)
+
+	o.logger.Info(layer, method, "setting up config watcher",
+		"file", o.cfg.File,
+		"reload_interval", o.cfg.OrderService.ConfigReloadInterval,
+	)
+	return watcher
 }
 
-func (o *OrderService) mustStartGRPCServer(usecase usecase.Usecase, interceptor *grpc_interceptor.GRPCInterceptor) error {
+func (o *OrderService) mustSetupHealthChecker(
+	redisClient cache.RedisClient,
+	clients []grpc_client.IGRPCClient,
+	repo repository.Repository,
+) *healthcheck.Checker {
+	const method = "mustSetupHealthChecker"
 	const layer = "app"
-	const method = "mustStartGRPCServer"
 
-	grpcServer := grpc.NewServer(
-		grpc.ChainUnaryInterceptor(
-			interceptor.XRequestIDInterceptor(),
-			interceptor.LoggerInterceptor(),
-			interceptor.UnaryPanicRecoveryInterceptor(),
-			grpc_prometheus.UnaryServerInterceptor,
-		),
+	const (
+		checkRedis          = "redis"
+		checkSpotInstrument = "spot_instrument_service"
+		checkOrderRepo      = "order_repository"
 	)
-	o.grpcServer = grpcServer
 
-	grpcSyncHandler := grpc_sync_handler.New(o.logger, usecase)
-	grpcAsyncHandler := grpc_async_handler.New(o.logger, usecase)
+	checker := healthcheck.New(o.logger, o.cfg.HealthCheck.Interval, o.cfg.HealthCheck.Timeout)
 
-	pb.RegisterOrderSyncServiceServer(grpcServer, grpcSyncHandler)
-	pb.RegisterOrderStreamServiceServer(grpcServer, grpcAsyncHandler)
+	checker.AddCheck(checkRedis, func(ctx context.Context) error {
+		return redisClient.Ping(ctx).Err()
+	})
+	checker.AddCheck(checkSpotInstrument, func(ctx context.Context) error {
+		for _, client := range clients {
+			if err := grpc_client.CheckConnectivity(client); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	checker.AddCheck(checkOrderRepo, repo.Ping)
 
-	grpc_prometheus.Register(grpcServer)
+	checker.AddService(pb.OrderSyncService_ServiceDesc.ServiceName, checkRedis, checkSpotInstrument, checkOrderRepo)
+	checker.AddService(pb.OrderStreamService_ServiceDesc.ServiceName, checkOrderRepo)
 
-	lis, err := net.Listen("tcp", o.cfg.GRPCServer.Address)
-	if err != nil {
-		o.logger.Error(layer, method, "failed to listen tcp", err, "address", o.cfg.GRPCServer.Address)
-		return err
+	o.logger.Info(layer, method, "setting up health checker")
+	return checker
+}
+
+func (o *OrderService) mustDrainHealthChecker(ctx context.Context, checker *healthcheck.Checker) error {
+	const method = "mustDrainHealthChecker"
+	const layer = "app"
+
+	checker.Shutdown()
+	if o.cfg.HealthCheck.ShutdownDelay <= 0 {
+		return nil
 	}
 
-	o.logger.Info(layer, method, "grpc server listening", "address", o.cfg.GRPCServer.Address)
-	err = grpcServer.Serve(lis)
-	if err != nil {
-		o.logger.Error(layer, method, "grpc server serve error", err)
+	o.logger.Infof(layer, method, "waiting for load balancers to drain", "delay: %s", o.cfg.HealthCheck.ShutdownDelay)
+
+	timer := time.NewTimer(o.cfg.HealthCheck.ShutdownDelay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (o *OrderService) mustSetupGRPCInterceptor() *grpc_interceptor.GRPCInterceptor {
+	return grpc_interceptor.New(o.logger)
+}
+
+func (o *OrderService) mustSetupPrometheus() {
+	const method = "mustSetupPrometheus"
+	const layer = "app"
+
+	o.logger.Info(layer, method, "setting up prometheus")
+	o.prometheusServer = metric.NewPrometheusServer(o.cfg)
+}
+
+// mustWatchLogLevelSignal toggles debug logging on SIGUSR1 so verbosity can
+// be raised on a running instance without the admin endpoint.
+func (o *OrderService) mustWatchLogLevelSignal(ctx context.Context) error {
+	const layer = "app"
+	const method = "mustWatchLogLevelSignal"
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-signals:
+			level := o.logger.Levels().ToggleDebug()
+			o.logger.Warn(layer, method, "log level changed by signal", nil, "level", level.String())
+		}
+	}
+}
+
+func (o *OrderService) mustStartPrometheus(ctx context.Context) error {
+	const layer = "app"
+	const method = "mustStartPrometheus"
+
+	o.logger.Info(layer, method, "prometheus listening", "address", o.prometheusServer.Addr)
+	err := o.prometheusServer.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		o.logger.Error(layer, method, "prometheus serve error", err)
 		return err
 	}
 
 	return nil
 }
 
-func (o *OrderService) mustStopGRPCServer() {
+func (o *OrderService) mustStopPrometheus(ctx context.Context) error {
 	const layer = "app"
-	const method = "mustStopGRPCServer"
+	const method = "mustStopPrometheus"
 
-	o.logger.Info(layer, method, "stopping grpc server")
-	o.grpcServer.GracefulStop()
-	o.logger.Info(layer, method, "grpc server stopped gracefully")
+	o.logger.Info(layer, method, "stopping prometheus")
+	return o.prometheusServer.Shutdown(ctx)
 }
 
 func (o *OrderService) mustCloseConnectionWithGRPCClients(clients []grpc_client.IGRPCClient) error {
@@ -199,12 +455,14 @@ func (o *OrderService) mustCloseConnectionWithGRPCClients(clients []grpc_client.
 
 	o.logger.Info(layer, method, "closing grpc client connections")
 
+	var errs []error
 	for _, client := range clients {
 		if err := client.Close(); err != nil {
 			o.logger.Error(layer, method, "failed to close grpc client", err)
+			errs = append(errs, err)
 		}
 	}
 
 	o.logger.Info(layer, method, "all grpc clients closed")
-	return nil
+	return errors.Join(errs...)
 }
@@ -0,0 +1,261 @@
+package order_service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	grpc_async_handler "github.com/FlyKarlik/orderService/internal/delivery/grpc/async"
+	grpc_interceptor "github.com/FlyKarlik/orderService/internal/delivery/grpc/interceptor"
+	grpc_sync_handler "github.com/FlyKarlik/orderService/internal/delivery/grpc/sync"
+	http_gateway "github.com/FlyKarlik/orderService/internal/delivery/http/gateway"
+	"github.com/FlyKarlik/orderService/internal/usecase"
+	"github.com/FlyKarlik/orderService/pkg/healthcheck"
+	"github.com/FlyKarlik/orderService/pkg/tlsconfig"
+	pb "github.com/FlyKarlik/proto/order_service/gen/order_service/proto"
+	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+func (o *OrderService) mustSetupGRPCServer(
+	ctx context.Context,
+	usecase usecase.Usecase,
+	interceptor *grpc_interceptor.GRPCInterceptor,
+	healthChecker *healthcheck.Checker,
+) error {
+	const layer = "app"
+	const method = "mustSetupGRPCServer"
+
+	serverOpts, err := o.grpcServerOptions(ctx, interceptor)
+	if err != nil {
+		o.logger.Error(layer, method, "failed to build grpc server options", err)
+		return err
+	}
+
+	grpcServer := grpc.NewServer(serverOpts...)
+	o.grpcServer = grpcServer
+
+	grpcSyncHandler := grpc_sync_handler.New(o.logger, usecase)
+	o.grpcAsyncHandler = grpc_async_handler.New(o.logger, usecase)
+
+	pb.RegisterOrderSyncServiceServer(grpcServer, grpcSyncHandler)
+	pb.RegisterOrderStreamServiceServer(grpcServer, o.grpcAsyncHandler)
+
+	healthgrpc.RegisterHealthServer(grpcServer, healthChecker.Server())
+
+	grpc_prometheus.Register(grpcServer)
+
+	if o.cfg.GRPCServer.EnableReflection {
+		reflection.Register(grpcServer)
+	}
+
+	return nil
+}
+
+// grpcServerOptions builds the server options. TLS certificates are
+// reloaded until ctx is done.
+func (o *OrderService) grpcServerOptions(ctx context.Context, interceptor *grpc_interceptor.GRPCInterceptor) ([]grpc.ServerOption, error) {
+	const layer = "app"
+	const method = "grpcServerOptions"
+
+	opts := []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler(
+			otelgrpc.WithFilter(filters.Not(filters.HealthCheck())),
+		)),
+		grpc.ChainUnaryInterceptor(
+			interceptor.XRequestIDInterceptor(),
+			interceptor.PeerIdentityInterceptor(),
+			interceptor.LoggerInterceptor(),
+			interceptor.UnaryPanicRecoveryInterceptor(),
+			interceptor.TimeoutInterceptor(o.cfg.GRPCServer.WriteTimeout),
+			grpc_prometheus.UnaryServerInterceptor,
+		),
+		grpc.ChainStreamInterceptor(
+			interceptor.XRequestIDStreamInterceptor(),
+			interceptor.PeerIdentityStreamInterceptor(),
+			interceptor.LoggerStreamInterceptor(),
+			interceptor.StreamPanicRecoveryInterceptor(),
+			grpc_prometheus.StreamServerInterceptor,
+		),
+	}
+
+	if o.cfg.GRPCServer.MaxRecvMsgSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(o.cfg.GRPCServer.MaxRecvMsgSize))
+	}
+	if o.cfg.GRPCServer.MaxSendMsgSize > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(o.cfg.GRPCServer.MaxSendMsgSize))
+	}
+	if o.cfg.GRPCServer.HandshakeTimeout > 0 {
+		opts = append(opts, grpc.ConnectionTimeout(o.cfg.GRPCServer.HandshakeTimeout))
+	}
+
+	if o.cfg.GRPCServer.TLSCertFile != "" {
+		reloader, err := tlsconfig.NewCertReloader(
+			o.cfg.GRPCServer.TLSCertFile,
+			o.cfg.GRPCServer.TLSKeyFile,
+			o.cfg.GRPCServer.TLSClientCAFile,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		reloader.Watch(ctx, o.cfg.GRPCServer.TLSReloadInterval, func(err error) {
+			o.logger.Error(layer, method, "failed to reload tls certificates", err)
+		})
+
+		opts = append(opts, grpc.Creds(credentials.NewTLS(reloader.ServerConfig())))
+	}
+
+	return opts, nil
+}
+
+func (o *OrderService) mustListenGRPCServer(ctx context.Context) error {
+	const layer = "app"
+	const method = "mustListenGRPCServer"
+
+	lis, err := net.Listen("tcp", o.cfg.GRPCServer.Address)
+	if err != nil {
+		o.logger.Error(layer, method, "failed to listen tcp", err, "address", o.cfg.GRPCServer.Address)
+		return err
+	}
+	o.grpcListener = lis
+
+	return nil
+}
+
+func (o *OrderService) mustStartGRPCServer(ctx context.Context) error {
+	const layer = "app"
+	const method = "mustStartGRPCServer"
+
+	o.logger.Info(layer, method, "grpc server listening",
+		"address", o.cfg.GRPCServer.Address,
+		"tls", o.cfg.GRPCServer.TLSCertFile != "",
+		"mtls", o.cfg.GRPCServer.TLSClientCAFile != "",
+	)
+	err := o.grpcServer.Serve(o.grpcListener)
+	if err != nil {
+		o.logger.Error(layer, method, "grpc server serve error", err)
+		return err
+	}
+
+	return nil
+}
+
+// mustStopGRPCServer closes open streams and waits for in-flight calls to
+// finish. When ctx expires first the remaining connections are closed hard.
+func (o *OrderService) mustStopGRPCServer(ctx context.Context) error {
+	const layer = "app"
+	const method = "mustStopGRPCServer"
+
+	o.logger.Info(layer, method, "stopping grpc server")
+	o.grpcAsyncHandler.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		o.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		o.logger.Info(layer, method, "grpc server stopped gracefully")
+		return nil
+	case <-ctx.Done():
+		o.grpcServer.Stop()
+		o.logger.Warn(layer, method, "grpc server stopped forcibly", ctx.Err())
+		return ctx.Err()
+	}
+}
+
+func (o *OrderService) mustSetupHTTPGateway(ctx context.Context, usecase usecase.Usecase) error {
+	const layer = "app"
+	const method = "mustSetupHTTPGateway"
+
+	handler := http_gateway.New(o.cfg, o.logger, usecase)
+
+	o.httpServer = &http.Server{
+		Addr:              o.cfg.HTTPGateway.Address,
+		Handler:           handler.Routes(),
+		ReadHeaderTimeout: o.cfg.HTTPGateway.ReadHeaderTimeout,
+	}
+	o.httpServer.RegisterOnShutdown(handler.Shutdown)
+
+	if o.cfg.HTTPGateway.TLSCertFile != "" {
+		reloader, err := tlsconfig.NewCertReloader(
+			o.cfg.HTTPGateway.TLSCertFile,
+			o.cfg.HTTPGateway.TLSKeyFile,
+			o.cfg.HTTPGateway.TLSClientCAFile,
+		)
+		if err != nil {
+			o.logger.Error(layer, method, "failed to load http gateway tls certificates", err)
+			return err
+		}
+
+		reloader.Watch(ctx, o.cfg.HTTPGateway.TLSReloadInterval, func(err error) {
+			o.logger.Error(layer, method, "failed to reload tls certificates", err)
+		})
+
+		o.httpServer.TLSConfig = reloader.ServerConfig()
+	}
+
+	o.logger.Info(layer, method, "setting up http gateway")
+	return nil
+}
+
+func (o *OrderService) mustListenHTTPGateway(ctx context.Context) error {
+	const layer = "app"
+	const method = "mustListenHTTPGateway"
+
+	lis, err := net.Listen("tcp", o.cfg.HTTPGateway.Address)
+	if err != nil {
+		o.logger.Error(layer, method, "failed to listen tcp", err, "address", o.cfg.HTTPGateway.Address)
+		return err
+	}
+	o.httpListener = lis
+
+	return nil
+}
+
+func (o *OrderService) mustStartHTTPGateway(ctx context.Context) error {
+	const layer = "app"
+	const method = "mustStartHTTPGateway"
+
+	o.logger.Info(layer, method, "http gateway listening",
+		"address", o.cfg.HTTPGateway.Address,
+		"tls", o.httpServer.TLSConfig != nil,
+		"mtls", o.cfg.HTTPGateway.TLSClientCAFile != "",
+	)
+
+	var err error
+	if o.httpServer.TLSConfig != nil {
+		err = o.httpServer.ServeTLS(o.httpListener, "", "")
+	} else {
+		err = o.httpServer.Serve(o.httpListener)
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		o.logger.Error(layer, method, "http gateway serve error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (o *OrderService) mustStopHTTPGateway(ctx context.Context) error {
+	const layer = "app"
+	const method = "mustStopHTTPGateway"
+
+	o.logger.Info(layer, method, "stopping http gateway")
+	if err := o.httpServer.Shutdown(ctx); err != nil {
+		o.logger.Error(layer, method, "failed to stop http gateway", err)
+		_ = o.httpServer.Close()
+		return err
+	}
+	o.logger.Info(layer, method, "http gateway stopped gracefully")
+	return nil
+}
@@ -1,6 +1,8 @@
 package grpc_async_handler
 
 import (
+	"sync"
+
 	"github.com/FlyKarlik/orderService/internal/usecase"
 	"github.com/FlyKarlik/orderService/pkg/logger"
 	pb "github.com/FlyKarlik/proto/order_service/gen/order_service/proto"
@@ -13,6 +15,9 @@ type GRPCAsyncHandler struct {
 	tracer  trace.Tracer
 	usecase usecase.Usecase
 	pb.UnimplementedOrderStreamServiceServer
+
+	done     chan struct{}
+	doneOnce sync.Once
 }
 
 func New(logger logger.Logger, usecase usecase.Usecase) *GRPCAsyncHandler {
@@ -20,5 +25,14 @@ func New(logger logger.Logger, usecase usecase.Usecase) *GRPCAsyncHandler {
 		logger:  logger,
 		usecase: usecase,
 		tracer:  otel.Tracer("order-service/grpc-async-handler"),
+		done:    make(chan struct{}),
 	}
 }
+
+// Shutdown ends every open stream so that GracefulStop does not wait for
+// clients to disconnect on their own.
+func (g *GRPCAsyncHandler) Shutdown() {
+	g.doneOnce.Do(func() {
+		close(g.done)
+	})
+}
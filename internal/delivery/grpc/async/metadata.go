@@ -0,0 +1,51 @@
+package grpc_async_handler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/mapper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// The order_service proto has no session options, so stream clients opt into
+// cancel-on-disconnect with request metadata.
+const metadataKeyCancelOnDisconnect = "x-cancel-on-disconnect"
+
+// applyStreamOrderUpdatesMetadata copies the cancel-on-disconnect flag from
+// the incoming metadata into req.
+func applyStreamOrderUpdatesMetadata(ctx context.Context, req *domain.StreamOrderUpdatesRequest) error {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	if values := md.Get(metadataKeyCancelOnDisconnect); len(values) > 0 && values[0] != "" {
+		enabled, err := strconv.ParseBool(values[0])
+		if err != nil {
+			return fmt.Errorf("invalid %s metadata: %w", metadataKeyCancelOnDisconnect, err)
+		}
+		req.CancelOnDisconnect = enabled
+	}
+
+	return nil
+}
+
+// setOrderUpdateMetadata passes the exact status and the fill details of an
+// update, which OrderUpdate cannot carry. gRPC has no per-message metadata,
+// so the first update is sent as header metadata and every update is joined
+// into the trailer: each key of the trailer holds one value per update sent,
+// in order. It must be called before the update is sent.
+func setOrderUpdateMetadata(stream grpc.ServerStream, update domain.StreamOrderUpdatesResponse, first bool) error {
+	md := mapper.ToOrderFillMetadata(update.OrderStatus, update.FilledQuantity, update.RemainingQuantity, update.AvgFillPrice)
+	if first {
+		if err := stream.SetHeader(md); err != nil {
+			return err
+		}
+	}
+	stream.SetTrailer(md)
+	return nil
+}
@@ -31,22 +31,28 @@ func (g *GRPCAsyncHandler) StreamOrderUpdates(
 		attribute.String("order.user_id", req.GetUserId()),
 	)
 
-	g.logger.Info(layer, method, "stream started",
+	g.logger.WithContext(ctx).Info(layer, method, "stream started",
 		"x_request_id", xRequestID,
 		"order_id", req.GetOrderId(),
 		"user_id", req.GetUserId(),
 	)
 
 	domainReq := mapper.FromProtoStreamOrderUpdatesRequest(req)
+	if err := applyStreamOrderUpdatesMetadata(ctx, &domainReq); err != nil {
+		g.logger.WithContext(ctx).Error(layer, method, "invalid stream order updates metadata", err)
+		span.RecordError(err)
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
 	if err := validate.Validate(domainReq); err != nil {
-		g.logger.Error(layer, method, "invalid stream order updates request", err)
+		g.logger.WithContext(ctx).Error(layer, method, "invalid stream order updates request", err)
 		span.RecordError(err)
 		return status.Error(codes.InvalidArgument, err.Error())
 	}
 
 	ch, cancel, err := g.usecase.SubscribeToOrderStatus(ctx, domainReq)
 	if err != nil {
-		g.logger.Error(layer, method, "failed to subscribe to order status", err)
+		g.logger.WithContext(ctx).Error(layer, method, "failed to subscribe to order status", err)
 		span.RecordError(err)
 		return status.Error(wrapp.GetStatusCodeFromError(err), err.Error())
 	}
@@ -67,30 +73,44 @@ func (g *GRPCAsyncHandler) streamOrderUpdates(
 	ctx, span := g.tracer.Start(ctx, "GRPCAsyncHandler.streamOrderUpdates")
 	defer span.End()
 
+	first := true
+
 	for {
 		select {
 		case <-ctx.Done():
-			g.logger.Info(layer, method, "stream context cancelled",
+			g.logger.WithContext(ctx).Info(layer, method, "stream context cancelled",
 				"x_request_id", xRequestID,
 			)
 			return nil
 
+		case <-g.done:
+			g.logger.WithContext(ctx).Info(layer, method, "stream closed by server shutdown",
+				"x_request_id", xRequestID,
+			)
+			return status.Error(codes.Unavailable, "server is shutting down")
+
 		case update, ok := <-ch:
 			if !ok {
-				g.logger.Info(layer, method, "stream channel closed",
+				g.logger.WithContext(ctx).Info(layer, method, "stream channel closed",
 					"x_request_id", xRequestID,
 				)
 				return nil
 			}
 
-			g.logger.Info(layer, method, "sending order status update",
+			g.logger.WithContext(ctx).Info(layer, method, "sending order status update",
 				"x_request_id", xRequestID,
 				"order_id", update.OrderID.String(),
 				"status", update.OrderStatus.String(),
 			)
 
+			if err := setOrderUpdateMetadata(stream, update, first); err != nil {
+				g.logger.WithContext(ctx).Warn(layer, method, "failed to send fill details metadata", err,
+					"x_request_id", xRequestID,
+				)
+			}
+			first = false
 			if err := stream.Send(mapper.ToProtoStreamOrderUpdatesResponse(update)); err != nil {
-				g.logger.Error(layer, method, "failed to send order update", err,
+				g.logger.WithContext(ctx).Error(layer, method, "failed to send order update", err,
 					"x_request_id", xRequestID,
 				)
 				span.RecordError(err)
@@ -18,20 +18,55 @@ func (i *GRPCInterceptor) LoggerInterceptor() grpc.UnaryServerInterceptor {
 
 		reqID := ctx.Value(shared_context.ContextKeyEnumXRequestID)
 		duration := time.Since(start)
+		identity, _ := shared_context.PeerIdentityFromContext(ctx)
 
 		if err != nil {
-			i.logger.Error(layer, info.FullMethod,
+			i.logger.WithContext(ctx).Error(layer, info.FullMethod,
 				"request failed",
 				err,
 				"request_id", reqID,
+				"peer_common_name", identity.CommonName,
 				"duration", duration)
 		} else {
-			i.logger.Info(layer, info.FullMethod,
+			i.logger.WithContext(ctx).Info(layer, info.FullMethod,
 				"request completed",
 				"request_id", reqID,
+				"peer_common_name", identity.CommonName,
 				"duration", duration)
 		}
 
 		return resp, err
 	}
 }
+
+func (i *GRPCInterceptor) LoggerStreamInterceptor() grpc.StreamServerInterceptor {
+	const layer = "grpc_interceptor"
+	return func(srv interface{}, ss grpc.ServerStream,
+		info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+		start := time.Now()
+		err := handler(srv, ss)
+
+		ctx := ss.Context()
+		reqID := ctx.Value(shared_context.ContextKeyEnumXRequestID)
+		duration := time.Since(start)
+		identity, _ := shared_context.PeerIdentityFromContext(ctx)
+
+		if err != nil {
+			i.logger.WithContext(ctx).Error(layer, info.FullMethod,
+				"stream failed",
+				err,
+				"request_id", reqID,
+				"peer_common_name", identity.CommonName,
+				"duration", duration)
+		} else {
+			i.logger.WithContext(ctx).Info(layer, info.FullMethod,
+				"stream completed",
+				"request_id", reqID,
+				"peer_common_name", identity.CommonName,
+				"duration", duration)
+		}
+
+		return err
+	}
+}
@@ -18,7 +18,7 @@ func (i *GRPCInterceptor) UnaryPanicRecoveryInterceptor() grpc.UnaryServerInterc
 		defer func() {
 			if r := recover(); r != nil {
 				stack := debug.Stack()
-				i.logger.Error(layer, info.FullMethod,
+				i.logger.WithContext(ctx).Error(layer, info.FullMethod,
 					fmt.Sprintf("panic recovered: %v", r),
 					nil,
 					"stack", string(stack),
@@ -29,3 +29,23 @@ func (i *GRPCInterceptor) UnaryPanicRecoveryInterceptor() grpc.UnaryServerInterc
 		return handler(ctx, req)
 	}
 }
+
+func (i *GRPCInterceptor) StreamPanicRecoveryInterceptor() grpc.StreamServerInterceptor {
+	const layer = "grpc_interceptor"
+	return func(srv interface{}, ss grpc.ServerStream,
+		info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+
+		defer func() {
+			if r := recover(); r != nil {
+				stack := debug.Stack()
+				i.logger.WithContext(ss.Context()).Error(layer, info.FullMethod,
+					fmt.Sprintf("panic recovered: %v", r),
+					nil,
+					"stack", string(stack),
+				)
+				err = status.Errorf(codes.Internal, "internal server error")
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,52 @@
+package grpc_interceptor
+
+import (
+	"context"
+
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+func (i *GRPCInterceptor) PeerIdentityInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{},
+		info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		return handler(withPeerIdentity(ctx), req)
+	}
+}
+
+func (i *GRPCInterceptor) PeerIdentityStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream,
+		info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+		return handler(srv, &wrappedServerStream{
+			ServerStream: ss,
+			ctx:          withPeerIdentity(ss.Context()),
+		})
+	}
+}
+
+func withPeerIdentity(ctx context.Context) context.Context {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.AuthInfo == nil {
+		return ctx
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return ctx
+	}
+
+	return shared_context.WithPeerIdentity(ctx, shared_context.NewPeerIdentity(tlsInfo.State.PeerCertificates[0]))
+}
+
+type wrappedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (w *wrappedServerStream) Context() context.Context {
+	return w.ctx
+}
@@ -13,22 +13,37 @@ func (i *GRPCInterceptor) XRequestIDInterceptor() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{},
 		info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 
-		md, ok := metadata.FromIncomingContext(ctx)
-		var requestID string
-		if ok {
-			ids := md.Get(shared_context.ContextKeyEnumXRequestID.String())
-			if len(ids) > 0 {
-				requestID = ids[0]
-			}
-		}
-		if requestID == "" {
-			requestID = uuid.New().String()
-		}
+		return handler(withXRequestID(ctx), req)
+	}
+}
+
+func (i *GRPCInterceptor) XRequestIDStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream,
+		info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 
-		ctx = context.WithValue(ctx, shared_context.ContextKeyEnumXRequestID, requestID)
+		return handler(srv, &wrappedServerStream{
+			ServerStream: ss,
+			ctx:          withXRequestID(ss.Context()),
+		})
+	}
+}
 
-		return handler(ctx, req)
+// withXRequestID stores the x-request-id of the incoming metadata in ctx,
+// generating one when the caller sent none.
+func withXRequestID(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	var requestID string
+	if ok {
+		ids := md.Get(shared_context.ContextKeyEnumXRequestID.String())
+		if len(ids) > 0 {
+			requestID = ids[0]
+		}
 	}
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+
+	return context.WithValue(ctx, shared_context.ContextKeyEnumXRequestID, requestID)
 }
 
 func (i *GRPCInterceptor) XRequestIDUnaryClientInterceptor() grpc.UnaryClientInterceptor {
@@ -0,0 +1,60 @@
+package grpc_sync_handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/mapper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// The order_service proto has no side, time in force or fill fields yet, so
+// gRPC clients pass the former as request metadata and receive the latter as
+// response header metadata (see mapper.ToOrderFillMetadata).
+const (
+	metadataKeyOrderSide   = "x-order-side"
+	metadataKeyTimeInForce = "x-time-in-force"
+	metadataKeyExpiresAt   = "x-expires-at"
+)
+
+// applyCreateOrderMetadata copies the side, the time in force and the RFC
+// 3339 expiry from the incoming metadata into req.
+func applyCreateOrderMetadata(ctx context.Context, req *domain.CreateOrderRequest) error {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	if values := md.Get(metadataKeyOrderSide); len(values) > 0 {
+		req.Side = mapper.FromStringOrderSide(values[0])
+	}
+
+	if values := md.Get(metadataKeyTimeInForce); len(values) > 0 {
+		req.TimeInForce = mapper.FromStringTimeInForce(values[0])
+	}
+
+	if values := md.Get(metadataKeyExpiresAt); len(values) > 0 && values[0] != "" {
+		expiresAt, err := time.Parse(time.RFC3339, values[0])
+		if err != nil {
+			return fmt.Errorf("invalid %s metadata: %w", metadataKeyExpiresAt, err)
+		}
+		req.ExpiresAt = &expiresAt
+	}
+
+	return nil
+}
+
+// setOrderFillHeader sends the exact status and the fill details of an
+// order as header metadata.
+func setOrderFillHeader(
+	ctx context.Context,
+	status *domain.OrderStatusEnum,
+	filledQuantity *int64,
+	remainingQuantity *int64,
+	avgFillPrice *string,
+) error {
+	return grpc.SetHeader(ctx, mapper.ToOrderFillMetadata(status, filledQuantity, remainingQuantity, avgFillPrice))
+}
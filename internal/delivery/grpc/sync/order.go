@@ -38,9 +38,14 @@ func (g *GRPCSyncHandler) CreateOrder(
 	)
 
 	domainReq := mapper.FromProtoCreateOrderRequest(req)
+	if err := applyCreateOrderMetadata(ctx, &domainReq); err != nil {
+		g.logger.WithContext(ctx).Error(layer, method, "invalid create order metadata", err)
+		span.RecordError(err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 
 	if err := validate.Validate(domainReq); err != nil {
-		g.logger.Error(layer, method, "invalid create order request", err)
+		g.logger.WithContext(ctx).Error(layer, method, "invalid create order request", err)
 		span.RecordError(err)
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
@@ -48,12 +53,16 @@ func (g *GRPCSyncHandler) CreateOrder(
 	resp, err := g.usecase.CreateOrder(ctx, domainReq)
 	if err != nil {
 		code := wrapp.GetStatusCodeFromError(err)
-		g.logger.Error(layer, method, "failed to create order", err)
+		g.logger.WithContext(ctx).Error(layer, method, "failed to create order", err)
 		span.RecordError(err)
 		span.SetAttributes(attribute.String("grpc.code", code.String()))
 		return nil, status.Error(code, err.Error())
 	}
 
+	if err := setOrderFillHeader(ctx, resp.OrderStatus, resp.FilledQuantity, resp.RemainingQuantity, resp.AvgFillPrice); err != nil {
+		g.logger.WithContext(ctx).Warn(layer, method, "failed to send fill details header", err)
+	}
+
 	return mapper.ToProtoCreateOrderResponse(resp), nil
 }
 
@@ -81,7 +90,7 @@ func (g *GRPCSyncHandler) GetOrderStatus(
 	domainReq := mapper.FromProtoGetOrderStatusRequest(req)
 
 	if err := validate.Validate(domainReq); err != nil {
-		g.logger.Error(layer, method, "invalid get order status request", err)
+		g.logger.WithContext(ctx).Error(layer, method, "invalid get order status request", err)
 		span.RecordError(err)
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
@@ -89,11 +98,15 @@ func (g *GRPCSyncHandler) GetOrderStatus(
 	resp, err := g.usecase.GetOrderStatus(ctx, domainReq)
 	if err != nil {
 		code := wrapp.GetStatusCodeFromError(err)
-		g.logger.Error(layer, method, "failed to get order status", err)
+		g.logger.WithContext(ctx).Error(layer, method, "failed to get order status", err)
 		span.RecordError(err)
 		span.SetAttributes(attribute.String("grpc.code", code.String()))
 		return nil, status.Error(code, err.Error())
 	}
 
+	if err := setOrderFillHeader(ctx, resp.Status, resp.FilledQuantity, resp.RemainingQuantity, resp.AvgFillPrice); err != nil {
+		g.logger.WithContext(ctx).Warn(layer, method, "failed to send fill details header", err)
+	}
+
 	return mapper.ToProtoGetOrderStatusResponse(resp), nil
 }
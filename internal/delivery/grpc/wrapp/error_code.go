@@ -19,6 +19,34 @@ func GetStatusCodeFromError(err error) codes.Code {
 			return codes.InvalidArgument
 		case errs.CodeInvalidOrderID:
 			return codes.InvalidArgument
+		case errs.CodeInvalidTimeInForce:
+			return codes.InvalidArgument
+		case errs.CodeInvalidPrice:
+			return codes.InvalidArgument
+		case errs.CodeOrderBookUnavailable, errs.CodeMarketOrderUnavailable:
+			return codes.FailedPrecondition
+		case errs.CodeOrderNotOpen:
+			return codes.FailedPrecondition
+		case errs.CodeInvalidReplace:
+			return codes.InvalidArgument
+		case errs.CodePermissionDenied:
+			return codes.PermissionDenied
+		case errs.CodeUnauthenticated:
+			return codes.Unauthenticated
+		case errs.CodeRiskMaxQuantity, errs.CodeRiskMaxNotional:
+			return codes.InvalidArgument
+		case errs.CodeRiskMaxOpenOrders:
+			return codes.ResourceExhausted
+		case errs.CodeRiskPriceBand, errs.CodeRiskFatFinger:
+			return codes.FailedPrecondition
+		case errs.CodeInsufficientBalance, errs.CodeMarketAssetsUnknown, errs.CodeAccountsDisabled:
+			return codes.FailedPrecondition
+		case errs.CodeInvalidAdjustment:
+			return codes.InvalidArgument
+		case errs.CodeMarketNotOpen, errs.CodeSelfTrade:
+			return codes.FailedPrecondition
+		case errs.CodeInvalidMarketState, errs.CodeInvalidLogLevel:
+			return codes.InvalidArgument
 		default:
 			return codes.Internal
 		}
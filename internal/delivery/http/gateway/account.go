@@ -0,0 +1,101 @@
+package http_gateway
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"github.com/FlyKarlik/orderService/pkg/proto_mapper"
+	"github.com/FlyKarlik/orderService/pkg/validate"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *HTTPGatewayHandler) GetBalances(w http.ResponseWriter, r *http.Request) {
+	const layer = "http_gateway"
+	const method = "GetBalances"
+
+	ctx, span := h.tracer.Start(r.Context(), "HTTPGatewayHandler.GetBalances")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+	userID := r.PathValue("user_id")
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("http.route", "GET /v1/accounts/{user_id}/balances"),
+		attribute.String("account.user_id", userID),
+	)
+
+	domainReq := domain.GetBalancesRequest{
+		UserID: proto_mapper.FromIDProto(&userID),
+	}
+
+	if err := validate.Validate(domainReq); err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "invalid get balances request", err)
+		span.RecordError(err)
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	resp, err := h.usecase.GetBalances(ctx, domainReq)
+	if err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to get balances", err)
+		span.RecordError(err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fromDomainGetBalancesResponse(userID, resp))
+}
+
+func (h *HTTPGatewayHandler) AdjustBalance(w http.ResponseWriter, r *http.Request) {
+	const layer = "http_gateway"
+	const method = "AdjustBalance"
+
+	ctx, span := h.tracer.Start(r.Context(), "HTTPGatewayHandler.AdjustBalance")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+	userID := r.PathValue("user_id")
+
+	if h.cfg.HTTPGateway.MaxBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.cfg.HTTPGateway.MaxBodyBytes)
+	}
+
+	var body adjustBalanceBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to decode adjust balance body", err)
+		span.RecordError(err)
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("http.route", "POST /v1/admin/accounts/{user_id}/adjust"),
+		attribute.String("account.user_id", userID),
+		attribute.String("account.asset", body.Asset),
+	)
+
+	domainReq := toDomainAdjustBalanceRequest(userID, body)
+
+	if err := validate.Validate(domainReq); err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "invalid adjust balance request", err)
+		span.RecordError(err)
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	resp, err := h.usecase.AdjustBalance(ctx, domainReq)
+	if err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to adjust balance", err)
+		span.RecordError(err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fromDomainBalance(resp.Balance))
+}
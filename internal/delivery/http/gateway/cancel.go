@@ -0,0 +1,125 @@
+package http_gateway
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"github.com/FlyKarlik/orderService/pkg/proto_mapper"
+	"github.com/FlyKarlik/orderService/pkg/validate"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// decodeCancelAllOrdersBody reads a mass cancel body and its optional
+// market ID.
+func (h *HTTPGatewayHandler) decodeCancelAllOrdersBody(w http.ResponseWriter, r *http.Request) (cancelAllOrdersBody, error) {
+	if h.cfg.HTTPGateway.MaxBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.cfg.HTTPGateway.MaxBodyBytes)
+	}
+
+	var body cancelAllOrdersBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return cancelAllOrdersBody{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if _, err := parseOptionalID(body.MarketID); err != nil {
+		return cancelAllOrdersBody{}, status.Error(codes.InvalidArgument, "market_id must be a UUID")
+	}
+	return body, nil
+}
+
+func (h *HTTPGatewayHandler) CancelAllOrders(w http.ResponseWriter, r *http.Request) {
+	const layer = "http_gateway"
+	const method = "CancelAllOrders"
+
+	ctx, span := h.tracer.Start(r.Context(), "HTTPGatewayHandler.CancelAllOrders")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+
+	body, err := h.decodeCancelAllOrdersBody(w, r)
+	if err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to decode cancel all orders body", err)
+		span.RecordError(err)
+		writeError(w, err)
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("http.route", "POST /v1/orders/cancel-all"),
+		attribute.String("order.user_id", body.UserID),
+		attribute.String("order.market_id", body.MarketID),
+	)
+
+	marketID, _ := parseOptionalID(body.MarketID)
+	domainReq := domain.CancelAllOrdersRequest{
+		UserID:   proto_mapper.FromIDProto(&body.UserID),
+		MarketID: marketID,
+	}
+
+	if err := validate.Validate(domainReq); err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "invalid cancel all orders request", err)
+		span.RecordError(err)
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	resp, err := h.usecase.CancelAllOrders(ctx, domainReq)
+	if err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to cancel orders", err)
+		span.RecordError(err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fromDomainCancelAllOrdersResponse(resp))
+}
+
+func (h *HTTPGatewayHandler) AdminCancelAllOrders(w http.ResponseWriter, r *http.Request) {
+	const layer = "http_gateway"
+	const method = "AdminCancelAllOrders"
+
+	ctx, span := h.tracer.Start(r.Context(), "HTTPGatewayHandler.AdminCancelAllOrders")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+
+	body, err := h.decodeCancelAllOrdersBody(w, r)
+	if err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to decode cancel all orders body", err)
+		span.RecordError(err)
+		writeError(w, err)
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("http.route", "POST /v1/admin/orders/cancel-all"),
+		attribute.String("order.market_id", body.MarketID),
+	)
+
+	marketID, _ := parseOptionalID(body.MarketID)
+	domainReq := domain.AdminCancelAllOrdersRequest{
+		MarketID: marketID,
+	}
+
+	if err := validate.Validate(domainReq); err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "invalid admin cancel all orders request", err)
+		span.RecordError(err)
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	resp, err := h.usecase.AdminCancelAllOrders(ctx, domainReq)
+	if err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to cancel orders", err)
+		span.RecordError(err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fromDomainCancelAllOrdersResponse(resp))
+}
@@ -0,0 +1,470 @@
+package http_gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/FlyKarlik/orderService/internal/delivery/grpc/wrapp"
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/mapper"
+	"github.com/FlyKarlik/orderService/pkg/proto_mapper"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/status"
+)
+
+type createOrderBody struct {
+	UserID    string `json:"user_id"`
+	MarketID  string `json:"market_id"`
+	OrderType string `json:"order_type"`
+	// Side is BUY (default) or SELL.
+	Side      string   `json:"side,omitempty"`
+	Price     string   `json:"price"`
+	Quantity  int64    `json:"quantity"`
+	UserRoles []string `json:"user_roles"`
+	// TimeInForce is one of GTC (default), IOC, FOK and GTD. ExpiresAt is
+	// the RFC 3339 deadline of a GTD order.
+	TimeInForce string     `json:"time_in_force,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+type createOrderResponseBody struct {
+	OrderID           string  `json:"order_id"`
+	Status            string  `json:"status"`
+	FilledQuantity    *int64  `json:"filled_quantity,omitempty"`
+	RemainingQuantity *int64  `json:"remaining_quantity,omitempty"`
+	AvgFillPrice      *string `json:"avg_fill_price,omitempty"`
+}
+
+type orderStatusResponseBody struct {
+	Status            string  `json:"status"`
+	FilledQuantity    *int64  `json:"filled_quantity,omitempty"`
+	RemainingQuantity *int64  `json:"remaining_quantity,omitempty"`
+	AvgFillPrice      *string `json:"avg_fill_price,omitempty"`
+}
+
+type executionBody struct {
+	ExecutionID string     `json:"execution_id"`
+	Price       *string    `json:"price,omitempty"`
+	Quantity    *int64     `json:"quantity,omitempty"`
+	ExecutedAt  *time.Time `json:"executed_at,omitempty"`
+}
+
+type orderUpdateBody struct {
+	OrderID           string          `json:"order_id"`
+	Status            string          `json:"status"`
+	FilledQuantity    *int64          `json:"filled_quantity,omitempty"`
+	RemainingQuantity *int64          `json:"remaining_quantity,omitempty"`
+	AvgFillPrice      *string         `json:"avg_fill_price,omitempty"`
+	Executions        []executionBody `json:"executions,omitempty"`
+	UpdatedAt         *time.Time      `json:"updated_at,omitempty"`
+}
+
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func toDomainUserRoles(roles []string) domain.UserRolesEnum {
+	userRoles := make(domain.UserRolesEnum, 0, len(roles))
+	for _, role := range roles {
+		userRoles = append(userRoles, domain.UserRoleEnum(role))
+	}
+	return userRoles
+}
+
+func toDomainCreateOrderRequest(body createOrderBody) domain.CreateOrderRequest {
+	return domain.CreateOrderRequest{
+		UserID:      proto_mapper.FromIDProto(&body.UserID),
+		MarketID:    proto_mapper.FromIDProto(&body.MarketID),
+		OrderType:   (*domain.OrderTypeEnum)(proto_mapper.FromStringProto(body.OrderType)),
+		Price:       proto_mapper.FromStringProto(body.Price),
+		Quantity:    proto_mapper.FromInt64Proto(body.Quantity),
+		UserRoles:   toDomainUserRoles(body.UserRoles),
+		Side:        mapper.FromStringOrderSide(body.Side),
+		TimeInForce: mapper.FromStringTimeInForce(body.TimeInForce),
+		ExpiresAt:   body.ExpiresAt,
+	}
+}
+
+func fromDomainOrderStatus(status *domain.OrderStatusEnum) string {
+	if status == nil {
+		return domain.OrderStatusEnumUnspecified.String()
+	}
+	return status.String()
+}
+
+func fromDomainCreateOrderResponse(resp domain.CreateOrderResponse) createOrderResponseBody {
+	return createOrderResponseBody{
+		OrderID:           proto_mapper.ToIDProto(resp.OrderID),
+		Status:            fromDomainOrderStatus(resp.OrderStatus),
+		FilledQuantity:    resp.FilledQuantity,
+		RemainingQuantity: resp.RemainingQuantity,
+		AvgFillPrice:      resp.AvgFillPrice,
+	}
+}
+
+func fromDomainGetOrderStatusResponse(resp domain.GetOrderStatusResponse) orderStatusResponseBody {
+	return orderStatusResponseBody{
+		Status:            fromDomainOrderStatus(resp.Status),
+		FilledQuantity:    resp.FilledQuantity,
+		RemainingQuantity: resp.RemainingQuantity,
+		AvgFillPrice:      resp.AvgFillPrice,
+	}
+}
+
+func fromDomainExecutions(executions []domain.Execution) []executionBody {
+	if len(executions) == 0 {
+		return nil
+	}
+
+	bodies := make([]executionBody, 0, len(executions))
+	for _, execution := range executions {
+		bodies = append(bodies, executionBody{
+			ExecutionID: proto_mapper.ToIDProto(execution.ID),
+			Price:       execution.Price,
+			Quantity:    execution.Quantity,
+			ExecutedAt:  execution.ExecutedAt,
+		})
+	}
+	return bodies
+}
+
+func fromDomainStreamOrderUpdatesResponse(resp domain.StreamOrderUpdatesResponse) orderUpdateBody {
+	return orderUpdateBody{
+		OrderID:           proto_mapper.ToIDProto(resp.OrderID),
+		Status:            fromDomainOrderStatus(resp.OrderStatus),
+		FilledQuantity:    resp.FilledQuantity,
+		RemainingQuantity: resp.RemainingQuantity,
+		AvgFillPrice:      resp.AvgFillPrice,
+		Executions:        fromDomainExecutions(resp.Executions),
+		UpdatedAt:         resp.UpdatedAt,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// writeError maps err through the same table the gRPC handlers use, so REST
+// clients observe the status the gRPC API would have returned.
+func writeError(w http.ResponseWriter, err error) {
+	code := wrapp.GetStatusCodeFromError(err)
+	msg := err.Error()
+	if s, ok := status.FromError(err); ok {
+		msg = s.Message()
+	}
+
+	writeJSON(w, wrapp.GetHTTPStatusFromCode(code), errorBody{
+		Code:    code.String(),
+		Message: msg,
+	})
+}
+
+type orderBookLevelBody struct {
+	Side     string  `json:"side,omitempty"`
+	Price    *string `json:"price"`
+	Quantity *int64  `json:"quantity"`
+	Orders   *int64  `json:"orders"`
+}
+
+type orderBookBody struct {
+	MarketID string               `json:"market_id"`
+	Sequence *uint64              `json:"sequence"`
+	Bids     []orderBookLevelBody `json:"bids"`
+	Asks     []orderBookLevelBody `json:"asks"`
+}
+
+type orderBookUpdateBody struct {
+	MarketID string               `json:"market_id"`
+	Sequence *uint64              `json:"sequence"`
+	Levels   []orderBookLevelBody `json:"levels"`
+}
+
+// queryUserRoles accepts user_roles both repeated and comma separated.
+func queryUserRoles(values []string) domain.UserRolesEnum {
+	var roles []string
+	for _, value := range values {
+		for _, role := range strings.Split(value, ",") {
+			if role = strings.TrimSpace(role); role != "" {
+				roles = append(roles, role)
+			}
+		}
+	}
+	return toDomainUserRoles(roles)
+}
+
+func fromDomainOrderBookLevels(levels []domain.OrderBookLevel, withSide bool) []orderBookLevelBody {
+	bodies := make([]orderBookLevelBody, 0, len(levels))
+	for _, level := range levels {
+		body := orderBookLevelBody{
+			Price:    level.Price,
+			Quantity: level.Quantity,
+			Orders:   level.Orders,
+		}
+		if withSide && level.Side != nil {
+			body.Side = level.Side.String()
+		}
+		bodies = append(bodies, body)
+	}
+	return bodies
+}
+
+func fromDomainGetOrderBookResponse(resp domain.GetOrderBookResponse) orderBookBody {
+	return orderBookBody{
+		MarketID: proto_mapper.ToIDProto(resp.MarketID),
+		Sequence: resp.Sequence,
+		Bids:     fromDomainOrderBookLevels(resp.Bids, false),
+		Asks:     fromDomainOrderBookLevels(resp.Asks, false),
+	}
+}
+
+func fromDomainStreamOrderBookResponse(resp domain.StreamOrderBookResponse) orderBookUpdateBody {
+	return orderBookUpdateBody{
+		MarketID: proto_mapper.ToIDProto(resp.MarketID),
+		Sequence: resp.Sequence,
+		Levels:   fromDomainOrderBookLevels(resp.Levels, true),
+	}
+}
+
+// replaceOrderBody amends an open order. Omitted fields keep their value;
+// Quantity is the new total, including what has already been filled.
+type replaceOrderBody struct {
+	UserID    string   `json:"user_id"`
+	UserRoles []string `json:"user_roles"`
+	Price     *string  `json:"price,omitempty"`
+	Quantity  *int64   `json:"quantity,omitempty"`
+}
+
+type replaceOrderResponseBody struct {
+	OrderID           string  `json:"order_id"`
+	Status            string  `json:"status"`
+	Price             *string `json:"price,omitempty"`
+	Quantity          *int64  `json:"quantity,omitempty"`
+	RemainingQuantity *int64  `json:"remaining_quantity,omitempty"`
+	KeptPriority      *bool   `json:"kept_priority,omitempty"`
+}
+
+type orderAmendmentBody struct {
+	Action           string     `json:"action"`
+	CancelReason     string     `json:"cancel_reason,omitempty"`
+	PreviousPrice    *string    `json:"previous_price,omitempty"`
+	Price            *string    `json:"price,omitempty"`
+	PreviousQuantity *int64     `json:"previous_quantity,omitempty"`
+	Quantity         *int64     `json:"quantity,omitempty"`
+	KeptPriority     *bool      `json:"kept_priority,omitempty"`
+	AmendedAt        *time.Time `json:"amended_at,omitempty"`
+}
+
+type orderHistoryBody struct {
+	OrderID    string               `json:"order_id"`
+	Amendments []orderAmendmentBody `json:"amendments"`
+}
+
+func toDomainReplaceOrderRequest(orderID string, body replaceOrderBody) domain.ReplaceOrderRequest {
+	return domain.ReplaceOrderRequest{
+		OrderID:   proto_mapper.FromIDProto(&orderID),
+		UserID:    proto_mapper.FromIDProto(&body.UserID),
+		UserRoles: toDomainUserRoles(body.UserRoles),
+		Price:     body.Price,
+		Quantity:  body.Quantity,
+	}
+}
+
+func fromDomainReplaceOrderResponse(resp domain.ReplaceOrderResponse) replaceOrderResponseBody {
+	return replaceOrderResponseBody{
+		OrderID:           proto_mapper.ToIDProto(resp.OrderID),
+		Status:            fromDomainOrderStatus(resp.OrderStatus),
+		Price:             resp.Price,
+		Quantity:          resp.Quantity,
+		RemainingQuantity: resp.RemainingQuantity,
+		KeptPriority:      resp.KeptPriority,
+	}
+}
+
+func fromDomainGetOrderHistoryResponse(orderID string, resp domain.GetOrderHistoryResponse) orderHistoryBody {
+	bodies := make([]orderAmendmentBody, 0, len(resp.Amendments))
+	for _, amendment := range resp.Amendments {
+		body := orderAmendmentBody{
+			PreviousPrice:    amendment.PreviousPrice,
+			Price:            amendment.Price,
+			PreviousQuantity: amendment.PreviousQuantity,
+			Quantity:         amendment.Quantity,
+			KeptPriority:     amendment.KeptPriority,
+			AmendedAt:        amendment.AmendedAt,
+		}
+		if amendment.Action != nil {
+			body.Action = amendment.Action.String()
+		}
+		if amendment.CancelReason != nil {
+			body.CancelReason = amendment.CancelReason.String()
+		}
+		bodies = append(bodies, body)
+	}
+	return orderHistoryBody{
+		OrderID:    orderID,
+		Amendments: bodies,
+	}
+}
+
+// cancelAllOrdersBody scopes a mass cancel. MarketID is optional; UserID
+// is used by the user variant only.
+type cancelAllOrdersBody struct {
+	UserID   string `json:"user_id,omitempty"`
+	MarketID string `json:"market_id,omitempty"`
+}
+
+type cancelAllOrdersResponseBody struct {
+	Cancelled []string `json:"cancelled"`
+	Failed    []string `json:"failed"`
+}
+
+// parseOptionalID parses an optional ID. Unlike FromIDProto it rejects a
+// malformed value instead of treating it as absent, which would widen the
+// scope of a mass cancel.
+func parseOptionalID(id string) (*uuid.UUID, error) {
+	if id == "" {
+		return nil, nil
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+func fromDomainIDs(ids []uuid.UUID) []string {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, id.String())
+	}
+	return out
+}
+
+func fromDomainCancelAllOrdersResponse(resp domain.CancelAllOrdersResponse) cancelAllOrdersResponseBody {
+	return cancelAllOrdersResponseBody{
+		Cancelled: fromDomainIDs(resp.Cancelled),
+		Failed:    fromDomainIDs(resp.Failed),
+	}
+}
+
+type balanceBody struct {
+	Asset     string `json:"asset"`
+	Available string `json:"available"`
+	Reserved  string `json:"reserved"`
+}
+
+type balancesBody struct {
+	UserID   string        `json:"user_id"`
+	Balances []balanceBody `json:"balances"`
+}
+
+// adjustBalanceBody credits Amount to an account, or debits it when the
+// amount is negative. The caller is authorized by its client certificate.
+type adjustBalanceBody struct {
+	Asset  string `json:"asset"`
+	Amount string `json:"amount"`
+}
+
+func fromDomainBalance(balance domain.Balance) balanceBody {
+	return balanceBody{
+		Asset:     *balance.Asset,
+		Available: *balance.Available,
+		Reserved:  *balance.Reserved,
+	}
+}
+
+func fromDomainGetBalancesResponse(userID string, resp domain.GetBalancesResponse) balancesBody {
+	bodies := make([]balanceBody, 0, len(resp.Balances))
+	for _, balance := range resp.Balances {
+		bodies = append(bodies, fromDomainBalance(balance))
+	}
+	return balancesBody{
+		UserID:   userID,
+		Balances: bodies,
+	}
+}
+
+func toDomainAdjustBalanceRequest(userID string, body adjustBalanceBody) domain.AdjustBalanceRequest {
+	return domain.AdjustBalanceRequest{
+		UserID: proto_mapper.FromIDProto(&userID),
+		Asset:  &body.Asset,
+		Amount: &body.Amount,
+	}
+}
+
+type marketStateBody struct {
+	MarketID  string     `json:"market_id"`
+	State     string     `json:"state"`
+	Source    string     `json:"source"`
+	Reason    *string    `json:"reason,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// setMarketStateBody sets the state of a market. An empty State clears a
+// previous override so the market follows its schedule again. UserRoles
+// only select the markets the caller can see; the change itself is
+// authorized by the client certificate.
+type setMarketStateBody struct {
+	UserRoles           []string `json:"user_roles"`
+	State               string   `json:"state,omitempty"`
+	Reason              *string  `json:"reason,omitempty"`
+	CancelRestingOrders bool     `json:"cancel_resting_orders,omitempty"`
+}
+
+type setMarketStateResponseBody struct {
+	marketStateBody
+	Cancelled []string `json:"cancelled"`
+	Failed    []string `json:"failed"`
+}
+
+func fromDomainMarketStatus(status domain.MarketStatus) marketStateBody {
+	return marketStateBody{
+		MarketID:  proto_mapper.ToIDProto(status.MarketID),
+		State:     status.State.String(),
+		Source:    status.Source.String(),
+		Reason:    status.Reason,
+		UpdatedAt: status.UpdatedAt,
+	}
+}
+
+func toDomainSetMarketStateRequest(marketID string, body setMarketStateBody) domain.SetMarketStateRequest {
+	req := domain.SetMarketStateRequest{
+		UserRoles:           toDomainUserRoles(body.UserRoles),
+		MarketID:            proto_mapper.FromIDProto(&marketID),
+		Reason:              body.Reason,
+		CancelRestingOrders: body.CancelRestingOrders,
+	}
+	if body.State != "" {
+		state := domain.MarketStateEnum(strings.ToUpper(body.State))
+		req.State = &state
+	}
+	return req
+}
+
+func fromDomainSetMarketStateResponse(resp domain.SetMarketStateResponse) setMarketStateResponseBody {
+	return setMarketStateResponseBody{
+		marketStateBody: fromDomainMarketStatus(resp.Status),
+		Cancelled:       fromDomainIDs(resp.Cancelled),
+		Failed:          fromDomainIDs(resp.Failed),
+	}
+}
+
+// setLogLevelBody sets the override of Layer, or the service wide level when
+// Layer is empty. An empty Level removes the override of Layer.
+type setLogLevelBody struct {
+	Layer string `json:"layer"`
+	Level string `json:"level"`
+}
+
+type logLevelsBody struct {
+	Level  string            `json:"level"`
+	Layers map[string]string `json:"layers"`
+}
+
+func fromDomainLogLevels(levels domain.LogLevels) logLevelsBody {
+	return logLevelsBody{Level: levels.Level, Layers: levels.Layers}
+}
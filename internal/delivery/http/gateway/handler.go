@@ -0,0 +1,70 @@
+package http_gateway
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/FlyKarlik/orderService/config"
+	"github.com/FlyKarlik/orderService/internal/usecase"
+	"github.com/FlyKarlik/orderService/pkg/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type HTTPGatewayHandler struct {
+	cfg     *config.Config
+	logger  logger.Logger
+	usecase usecase.Usecase
+	tracer  trace.Tracer
+
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+func New(cfg *config.Config, logger logger.Logger, usecase usecase.Usecase) *HTTPGatewayHandler {
+	return &HTTPGatewayHandler{
+		cfg:     cfg,
+		logger:  logger,
+		usecase: usecase,
+		tracer:  otel.Tracer("order-service/http-gateway"),
+		done:    make(chan struct{}),
+	}
+}
+
+// Shutdown ends every open event stream so that http.Server.Shutdown does not
+// wait on long-lived connections.
+func (h *HTTPGatewayHandler) Shutdown() {
+	h.doneOnce.Do(func() {
+		close(h.done)
+	})
+}
+
+func (h *HTTPGatewayHandler) Routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /v1/orders", h.CreateOrder)
+	mux.HandleFunc("GET /v1/orders/{id}/status", h.GetOrderStatus)
+	mux.HandleFunc("GET /v1/orders/{id}/updates", h.StreamOrderUpdates)
+	mux.HandleFunc("POST /v1/orders/{id}/replace", h.ReplaceOrder)
+	mux.HandleFunc("GET /v1/orders/{id}/history", h.GetOrderHistory)
+	mux.HandleFunc("POST /v1/orders/cancel-all", h.CancelAllOrders)
+	mux.HandleFunc("POST /v1/admin/orders/cancel-all", h.AdminCancelAllOrders)
+	mux.HandleFunc("GET /v1/accounts/{user_id}/balances", h.GetBalances)
+	mux.HandleFunc("POST /v1/admin/accounts/{user_id}/adjust", h.AdjustBalance)
+	mux.HandleFunc("GET /v1/markets/{id}/book", h.GetOrderBook)
+	mux.HandleFunc("GET /v1/markets/{id}/book/stream", h.StreamOrderBook)
+	mux.HandleFunc("GET /v1/markets/{id}/state", h.GetMarketState)
+	mux.HandleFunc("PUT /v1/admin/markets/{id}/state", h.SetMarketState)
+	mux.HandleFunc("GET /v1/admin/log-level", h.GetLogLevels)
+	mux.HandleFunc("PUT /v1/admin/log-level", h.SetLogLevel)
+
+	return h.TraceContextMiddleware(
+		h.XRequestIDMiddleware(
+			h.PeerIdentityMiddleware(
+				h.LoggerMiddleware(
+					h.PanicRecoveryMiddleware(mux),
+				),
+			),
+		),
+	)
+}
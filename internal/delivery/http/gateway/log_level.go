@@ -0,0 +1,75 @@
+package http_gateway
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *HTTPGatewayHandler) GetLogLevels(w http.ResponseWriter, r *http.Request) {
+	const layer = "http_gateway"
+	const method = "GetLogLevels"
+
+	ctx, span := h.tracer.Start(r.Context(), "HTTPGatewayHandler.GetLogLevels")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("x-request-id", shared_context.XRequestIDFromContext(ctx)),
+		attribute.String("http.route", "GET /v1/admin/log-level"),
+	)
+
+	resp, err := h.usecase.GetLogLevels(ctx)
+	if err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to get log levels", err)
+		span.RecordError(err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fromDomainLogLevels(resp))
+}
+
+func (h *HTTPGatewayHandler) SetLogLevel(w http.ResponseWriter, r *http.Request) {
+	const layer = "http_gateway"
+	const method = "SetLogLevel"
+
+	ctx, span := h.tracer.Start(r.Context(), "HTTPGatewayHandler.SetLogLevel")
+	defer span.End()
+
+	if h.cfg.HTTPGateway.MaxBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.cfg.HTTPGateway.MaxBodyBytes)
+	}
+
+	var body setLogLevelBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to decode set log level body", err)
+		span.RecordError(err)
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("x-request-id", shared_context.XRequestIDFromContext(ctx)),
+		attribute.String("http.route", "PUT /v1/admin/log-level"),
+		attribute.String("log.layer", body.Layer),
+		attribute.String("log.level", body.Level),
+	)
+
+	resp, err := h.usecase.SetLogLevel(ctx, domain.SetLogLevelRequest{
+		Layer: body.Layer,
+		Level: body.Level,
+	})
+	if err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to set log level", err)
+		span.RecordError(err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fromDomainLogLevels(resp))
+}
@@ -0,0 +1,102 @@
+package http_gateway
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"github.com/FlyKarlik/orderService/pkg/proto_mapper"
+	"github.com/FlyKarlik/orderService/pkg/validate"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *HTTPGatewayHandler) GetMarketState(w http.ResponseWriter, r *http.Request) {
+	const layer = "http_gateway"
+	const method = "GetMarketState"
+
+	ctx, span := h.tracer.Start(r.Context(), "HTTPGatewayHandler.GetMarketState")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+	marketID := r.PathValue("id")
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("http.route", "GET /v1/markets/{id}/state"),
+		attribute.String("market.id", marketID),
+	)
+
+	domainReq := domain.GetMarketStateRequest{
+		MarketID:  proto_mapper.FromIDProto(&marketID),
+		UserRoles: queryUserRoles(r.URL.Query()["user_roles"]),
+	}
+
+	if err := validate.Validate(domainReq); err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "invalid get market state request", err)
+		span.RecordError(err)
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	resp, err := h.usecase.GetMarketState(ctx, domainReq)
+	if err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to get market state", err)
+		span.RecordError(err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fromDomainMarketStatus(resp.Status))
+}
+
+func (h *HTTPGatewayHandler) SetMarketState(w http.ResponseWriter, r *http.Request) {
+	const layer = "http_gateway"
+	const method = "SetMarketState"
+
+	ctx, span := h.tracer.Start(r.Context(), "HTTPGatewayHandler.SetMarketState")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+	marketID := r.PathValue("id")
+
+	if h.cfg.HTTPGateway.MaxBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.cfg.HTTPGateway.MaxBodyBytes)
+	}
+
+	var body setMarketStateBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to decode set market state body", err)
+		span.RecordError(err)
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("http.route", "PUT /v1/admin/markets/{id}/state"),
+		attribute.String("market.id", marketID),
+		attribute.String("market.state", body.State),
+	)
+
+	domainReq := toDomainSetMarketStateRequest(marketID, body)
+
+	if err := validate.Validate(domainReq); err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "invalid set market state request", err)
+		span.RecordError(err)
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	resp, err := h.usecase.SetMarketState(ctx, domainReq)
+	if err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to set market state", err)
+		span.RecordError(err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fromDomainSetMarketStateResponse(resp))
+}
@@ -0,0 +1,121 @@
+package http_gateway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const headerXRequestID = "X-Request-Id"
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// TraceContextMiddleware extracts W3C trace context from the request headers
+// so handler spans join the caller's trace.
+func (h *HTTPGatewayHandler) TraceContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (h *HTTPGatewayHandler) XRequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(headerXRequestID)
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+
+		w.Header().Set(headerXRequestID, requestID)
+		ctx := context.WithValue(r.Context(), shared_context.ContextKeyEnumXRequestID, requestID)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// PeerIdentityMiddleware stores the client certificate of a mutual TLS
+// connection in the request context, where admin operations look for a
+// trusted identity.
+func (h *HTTPGatewayHandler) PeerIdentityMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		identity := shared_context.NewPeerIdentity(r.TLS.PeerCertificates[0])
+		ctx := shared_context.WithPeerIdentity(r.Context(), identity)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (h *HTTPGatewayHandler) LoggerMiddleware(next http.Handler) http.Handler {
+	const layer = "http_middleware"
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		reqID := shared_context.XRequestIDFromContext(r.Context())
+		duration := time.Since(start)
+		route := r.Pattern
+		if route == "" {
+			route = r.Method + " " + r.URL.Path
+		}
+
+		if rec.status >= http.StatusInternalServerError {
+			h.logger.Error(layer, route,
+				"request failed",
+				nil,
+				"request_id", reqID,
+				"status", rec.status,
+				"duration", duration)
+		} else {
+			h.logger.Info(layer, route,
+				"request completed",
+				"request_id", reqID,
+				"status", rec.status,
+				"duration", duration)
+		}
+	})
+}
+
+func (h *HTTPGatewayHandler) PanicRecoveryMiddleware(next http.Handler) http.Handler {
+	const layer = "http_middleware"
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				stack := debug.Stack()
+				h.logger.Error(layer, r.Method+" "+r.URL.Path,
+					fmt.Sprintf("panic recovered: %v", rec),
+					nil,
+					"stack", string(stack),
+				)
+				writeError(w, status.Error(codes.Internal, "internal server error"))
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,106 @@
+package http_gateway
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"github.com/FlyKarlik/orderService/pkg/proto_mapper"
+	"github.com/FlyKarlik/orderService/pkg/validate"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *HTTPGatewayHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	const layer = "http_gateway"
+	const method = "CreateOrder"
+
+	ctx, span := h.tracer.Start(r.Context(), "HTTPGatewayHandler.CreateOrder")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+
+	if h.cfg.HTTPGateway.MaxBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.cfg.HTTPGateway.MaxBodyBytes)
+	}
+
+	var body createOrderBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to decode create order body", err)
+		span.RecordError(err)
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("http.route", "POST /v1/orders"),
+		attribute.String("order.user_id", body.UserID),
+		attribute.String("order.market_id", body.MarketID),
+		attribute.String("order.type", body.OrderType),
+		attribute.String("order.price", body.Price),
+		attribute.Int64("order.quantity", body.Quantity),
+	)
+
+	domainReq := toDomainCreateOrderRequest(body)
+
+	if err := validate.Validate(domainReq); err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "invalid create order request", err)
+		span.RecordError(err)
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	resp, err := h.usecase.CreateOrder(ctx, domainReq)
+	if err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to create order", err)
+		span.RecordError(err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fromDomainCreateOrderResponse(resp))
+}
+
+func (h *HTTPGatewayHandler) GetOrderStatus(w http.ResponseWriter, r *http.Request) {
+	const layer = "http_gateway"
+	const method = "GetOrderStatus"
+
+	ctx, span := h.tracer.Start(r.Context(), "HTTPGatewayHandler.GetOrderStatus")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+	orderID := r.PathValue("id")
+	userID := r.URL.Query().Get("user_id")
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("http.route", "GET /v1/orders/{id}/status"),
+		attribute.String("order.id", orderID),
+		attribute.String("order.user_id", userID),
+	)
+
+	domainReq := domain.GetOrderStatusRequest{
+		OrderID: proto_mapper.FromIDProto(&orderID),
+		UserID:  proto_mapper.FromIDProto(&userID),
+	}
+
+	if err := validate.Validate(domainReq); err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "invalid get order status request", err)
+		span.RecordError(err)
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	resp, err := h.usecase.GetOrderStatus(ctx, domainReq)
+	if err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to get order status", err)
+		span.RecordError(err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fromDomainGetOrderStatusResponse(resp))
+}
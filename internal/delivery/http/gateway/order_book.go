@@ -0,0 +1,162 @@
+package http_gateway
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"github.com/FlyKarlik/orderService/pkg/proto_mapper"
+	"github.com/FlyKarlik/orderService/pkg/validate"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *HTTPGatewayHandler) GetOrderBook(w http.ResponseWriter, r *http.Request) {
+	const layer = "http_gateway"
+	const method = "GetOrderBook"
+
+	ctx, span := h.tracer.Start(r.Context(), "HTTPGatewayHandler.GetOrderBook")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+	marketID := r.PathValue("id")
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("http.route", "GET /v1/markets/{id}/book"),
+		attribute.String("market.id", marketID),
+	)
+
+	domainReq := domain.GetOrderBookRequest{
+		MarketID:  proto_mapper.FromIDProto(&marketID),
+		UserRoles: queryUserRoles(r.URL.Query()["user_roles"]),
+	}
+
+	if raw := r.URL.Query().Get("depth"); raw != "" {
+		depth, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			h.logger.WithContext(ctx).Error(layer, method, "invalid depth", err)
+			span.RecordError(err)
+			writeError(w, status.Error(codes.InvalidArgument, "depth must be an integer"))
+			return
+		}
+		domainReq.Depth = &depth
+	}
+
+	if err := validate.Validate(domainReq); err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "invalid get order book request", err)
+		span.RecordError(err)
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	resp, err := h.usecase.GetOrderBook(ctx, domainReq)
+	if err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to get order book", err)
+		span.RecordError(err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fromDomainGetOrderBookResponse(resp))
+}
+
+// StreamOrderBook sends the depth of a market as Server-Sent Events: a
+// "book_snapshot" event with every level, then a "book_update" event per
+// change. Another "book_snapshot" follows if the stream had to
+// resynchronise.
+func (h *HTTPGatewayHandler) StreamOrderBook(w http.ResponseWriter, r *http.Request) {
+	const layer = "http_gateway"
+	const method = "StreamOrderBook"
+
+	ctx, span := h.tracer.Start(r.Context(), "HTTPGatewayHandler.StreamOrderBook")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+	marketID := r.PathValue("id")
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("http.route", "GET /v1/markets/{id}/book/stream"),
+		attribute.String("market.id", marketID),
+	)
+
+	domainReq := domain.StreamOrderBookRequest{
+		MarketID:  proto_mapper.FromIDProto(&marketID),
+		UserRoles: queryUserRoles(r.URL.Query()["user_roles"]),
+	}
+
+	if err := validate.Validate(domainReq); err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "invalid stream order book request", err)
+		span.RecordError(err)
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	ch, cancel, err := h.usecase.SubscribeToOrderBook(ctx, domainReq)
+	if err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to subscribe to order book", err)
+		span.RecordError(err)
+		writeError(w, err)
+		return
+	}
+	defer cancel()
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "response writer does not support flushing", err,
+			"x_request_id", xRequestID,
+		)
+		span.RecordError(err)
+		return
+	}
+
+	h.logger.WithContext(ctx).Info(layer, method, "stream started",
+		"x_request_id", xRequestID,
+		"market_id", marketID,
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			h.logger.WithContext(ctx).Info(layer, method, "stream context cancelled",
+				"x_request_id", xRequestID,
+			)
+			return
+
+		case <-h.done:
+			h.logger.WithContext(ctx).Info(layer, method, "stream closed by server shutdown",
+				"x_request_id", xRequestID,
+			)
+			return
+
+		case msg, ok := <-ch:
+			if !ok {
+				h.logger.WithContext(ctx).Info(layer, method, "stream channel closed",
+					"x_request_id", xRequestID,
+				)
+				return
+			}
+
+			if msg.Snapshot != nil {
+				err = writeEvent(w, rc, "book_snapshot", fromDomainGetOrderBookResponse(*msg.Snapshot))
+			} else {
+				err = writeEvent(w, rc, "book_update", fromDomainStreamOrderBookResponse(msg))
+			}
+			if err != nil {
+				h.logger.WithContext(ctx).Error(layer, method, "failed to send order book event", err,
+					"x_request_id", xRequestID,
+				)
+				span.RecordError(err)
+				return
+			}
+		}
+	}
+}
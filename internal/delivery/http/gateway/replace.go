@@ -0,0 +1,104 @@
+package http_gateway
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"github.com/FlyKarlik/orderService/pkg/proto_mapper"
+	"github.com/FlyKarlik/orderService/pkg/validate"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *HTTPGatewayHandler) ReplaceOrder(w http.ResponseWriter, r *http.Request) {
+	const layer = "http_gateway"
+	const method = "ReplaceOrder"
+
+	ctx, span := h.tracer.Start(r.Context(), "HTTPGatewayHandler.ReplaceOrder")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+	orderID := r.PathValue("id")
+
+	if h.cfg.HTTPGateway.MaxBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.cfg.HTTPGateway.MaxBodyBytes)
+	}
+
+	var body replaceOrderBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to decode replace order body", err)
+		span.RecordError(err)
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("http.route", "POST /v1/orders/{id}/replace"),
+		attribute.String("order.id", orderID),
+		attribute.String("order.user_id", body.UserID),
+	)
+
+	domainReq := toDomainReplaceOrderRequest(orderID, body)
+
+	if err := validate.Validate(domainReq); err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "invalid replace order request", err)
+		span.RecordError(err)
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	resp, err := h.usecase.ReplaceOrder(ctx, domainReq)
+	if err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to replace order", err)
+		span.RecordError(err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fromDomainReplaceOrderResponse(resp))
+}
+
+func (h *HTTPGatewayHandler) GetOrderHistory(w http.ResponseWriter, r *http.Request) {
+	const layer = "http_gateway"
+	const method = "GetOrderHistory"
+
+	ctx, span := h.tracer.Start(r.Context(), "HTTPGatewayHandler.GetOrderHistory")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+	orderID := r.PathValue("id")
+	userID := r.URL.Query().Get("user_id")
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("http.route", "GET /v1/orders/{id}/history"),
+		attribute.String("order.id", orderID),
+		attribute.String("order.user_id", userID),
+	)
+
+	domainReq := domain.GetOrderHistoryRequest{
+		OrderID: proto_mapper.FromIDProto(&orderID),
+		UserID:  proto_mapper.FromIDProto(&userID),
+	}
+
+	if err := validate.Validate(domainReq); err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "invalid get order history request", err)
+		span.RecordError(err)
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	resp, err := h.usecase.GetOrderHistory(ctx, domainReq)
+	if err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to get order history", err)
+		span.RecordError(err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fromDomainGetOrderHistoryResponse(orderID, resp))
+}
@@ -0,0 +1,136 @@
+package http_gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"github.com/FlyKarlik/orderService/pkg/proto_mapper"
+	"github.com/FlyKarlik/orderService/pkg/validate"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StreamOrderUpdates exposes OrderStreamService.StreamOrderUpdates as a
+// Server-Sent Events stream: every update is sent as an "order_update" event
+// carrying the JSON encoded update. cancel_on_disconnect=true opts the
+// stream into cancel-on-disconnect.
+func (h *HTTPGatewayHandler) StreamOrderUpdates(w http.ResponseWriter, r *http.Request) {
+	const layer = "http_gateway"
+	const method = "StreamOrderUpdates"
+
+	ctx, span := h.tracer.Start(r.Context(), "HTTPGatewayHandler.StreamOrderUpdates")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+	orderID := r.PathValue("id")
+	userID := r.URL.Query().Get("user_id")
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("http.route", "GET /v1/orders/{id}/updates"),
+		attribute.String("order.id", orderID),
+		attribute.String("order.user_id", userID),
+	)
+
+	domainReq := domain.StreamOrderUpdatesRequest{
+		OrderID: proto_mapper.FromIDProto(&orderID),
+		UserID:  proto_mapper.FromIDProto(&userID),
+	}
+
+	if raw := r.URL.Query().Get("cancel_on_disconnect"); raw != "" {
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			h.logger.WithContext(ctx).Error(layer, method, "invalid cancel_on_disconnect", err)
+			span.RecordError(err)
+			writeError(w, status.Error(codes.InvalidArgument, "cancel_on_disconnect must be a boolean"))
+			return
+		}
+		domainReq.CancelOnDisconnect = enabled
+	}
+
+	if err := validate.Validate(domainReq); err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "invalid stream order updates request", err)
+		span.RecordError(err)
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	ch, cancel, err := h.usecase.SubscribeToOrderStatus(ctx, domainReq)
+	if err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "failed to subscribe to order status", err)
+		span.RecordError(err)
+		writeError(w, err)
+		return
+	}
+	defer cancel()
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		h.logger.WithContext(ctx).Error(layer, method, "response writer does not support flushing", err,
+			"x_request_id", xRequestID,
+		)
+		span.RecordError(err)
+		return
+	}
+
+	h.logger.WithContext(ctx).Info(layer, method, "stream started",
+		"x_request_id", xRequestID,
+		"order_id", orderID,
+		"user_id", userID,
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			h.logger.WithContext(ctx).Info(layer, method, "stream context cancelled",
+				"x_request_id", xRequestID,
+			)
+			return
+
+		case <-h.done:
+			h.logger.WithContext(ctx).Info(layer, method, "stream closed by server shutdown",
+				"x_request_id", xRequestID,
+			)
+			return
+
+		case update, ok := <-ch:
+			if !ok {
+				h.logger.WithContext(ctx).Info(layer, method, "stream channel closed",
+					"x_request_id", xRequestID,
+				)
+				return
+			}
+
+			if err := writeEvent(w, rc, "order_update", fromDomainStreamOrderUpdatesResponse(update)); err != nil {
+				h.logger.WithContext(ctx).Error(layer, method, "failed to send order update", err,
+					"x_request_id", xRequestID,
+				)
+				span.RecordError(err)
+				return
+			}
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, rc *http.ResponseController, event string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+
+	return rc.Flush()
+}
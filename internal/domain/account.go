@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Balance is what a user holds of one asset. Reserved is held by the user's
+// open orders; only Available can be spent by new orders or withdrawn.
+type Balance struct {
+	UserID    *uuid.UUID
+	Asset     *string
+	Available *string
+	Reserved  *string
+}
+
+type GetBalancesRequest struct {
+	UserID *uuid.UUID `validate:"required"`
+}
+
+type GetBalancesResponse struct {
+	Balances []Balance
+}
+
+// AdjustBalanceRequest credits Amount to the available balance of an
+// account, or debits it when Amount is negative. It is how funds enter and
+// leave the service.
+type AdjustBalanceRequest struct {
+	UserID *uuid.UUID `validate:"required"`
+	Asset  *string    `validate:"required"`
+	Amount *string    `validate:"required"`
+}
+
+type AdjustBalanceResponse struct {
+	Balance Balance
+}
+
+// NormalizeAsset returns the canonical, upper case form of an asset name.
+func NormalizeAsset(asset string) string {
+	return strings.ToUpper(strings.TrimSpace(asset))
+}
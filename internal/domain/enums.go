@@ -12,6 +12,26 @@ func (o OrderTypeEnum) String() string {
 	return string(o)
 }
 
+// OrderSideEnum is the direction of an order.
+type OrderSideEnum string
+
+const (
+	OrderSideEnumBuy  OrderSideEnum = "BUY"
+	OrderSideEnumSell OrderSideEnum = "SELL"
+)
+
+func (o OrderSideEnum) String() string {
+	return string(o)
+}
+
+// Opposite returns the side an order of side o trades against.
+func (o OrderSideEnum) Opposite() OrderSideEnum {
+	if o == OrderSideEnumBuy {
+		return OrderSideEnumSell
+	}
+	return OrderSideEnumBuy
+}
+
 type OrderStatusEnum string
 
 const (
@@ -20,12 +40,122 @@ const (
 	OrderStatusEnumPending     OrderStatusEnum = "PENDING"
 	OrderStatusEnumFilled      OrderStatusEnum = "FILLED"
 	OrderStatusEnumRejected    OrderStatusEnum = "REJECTED"
+	OrderStatusEnumExpired     OrderStatusEnum = "EXPIRED"
+	// OrderStatusEnumPartiallyFilled is an open order with at least one fill.
+	OrderStatusEnumPartiallyFilled OrderStatusEnum = "PARTIALLY_FILLED"
+	// OrderStatusEnumCancelled is an order withdrawn before it was filled.
+	OrderStatusEnumCancelled OrderStatusEnum = "CANCELLED"
 )
 
 func (o OrderStatusEnum) String() string {
 	return string(o)
 }
 
+// IsFinal reports whether no further transitions are possible.
+func (o OrderStatusEnum) IsFinal() bool {
+	switch o {
+	case OrderStatusEnumFilled, OrderStatusEnumRejected, OrderStatusEnumExpired, OrderStatusEnumCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// CancelReasonEnum records why an order was cancelled.
+type CancelReasonEnum string
+
+const (
+	CancelReasonEnumUser  CancelReasonEnum = "USER"
+	CancelReasonEnumAdmin CancelReasonEnum = "ADMIN"
+	// CancelReasonEnumDisconnect is a cancel-on-disconnect session that
+	// was not resumed within the grace period.
+	CancelReasonEnumDisconnect CancelReasonEnum = "DISCONNECT"
+	// CancelReasonEnumHalt is an admin halt or close of the order's market
+	// that asked for resting orders to be cancelled.
+	CancelReasonEnumHalt CancelReasonEnum = "HALT"
+)
+
+func (c CancelReasonEnum) String() string {
+	return string(c)
+}
+
+// OrderHistoryActionEnum is the kind of an order history entry.
+type OrderHistoryActionEnum string
+
+const (
+	OrderHistoryActionEnumReplace OrderHistoryActionEnum = "REPLACE"
+	OrderHistoryActionEnumCancel  OrderHistoryActionEnum = "CANCEL"
+)
+
+func (o OrderHistoryActionEnum) String() string {
+	return string(o)
+}
+
+// MarketStateEnum is the trading state of a market. Orders are only
+// accepted while it is OPEN.
+//
+//	OPEN     continuous trading.
+//	HALTED   trading stopped for the time being, usually by an operator.
+//	CLOSED   outside the market's trading sessions, or disabled upstream.
+//	AUCTION  an auction phase; continuous trading is suspended.
+type MarketStateEnum string
+
+const (
+	MarketStateEnumOpen    MarketStateEnum = "OPEN"
+	MarketStateEnumHalted  MarketStateEnum = "HALTED"
+	MarketStateEnumClosed  MarketStateEnum = "CLOSED"
+	MarketStateEnumAuction MarketStateEnum = "AUCTION"
+)
+
+func (m MarketStateEnum) String() string {
+	return string(m)
+}
+
+// MarketStateSourceEnum tells where the state of a market came from, in
+// order of precedence.
+//
+//	DRIVER    SpotInstrumentService reports the market disabled or deleted.
+//	ADMIN     set by an operator; overrides the schedule until cleared.
+//	SCHEDULE  the configured trading sessions of the market.
+//	DEFAULT   no schedule is configured: the market is always open.
+type MarketStateSourceEnum string
+
+const (
+	MarketStateSourceEnumDriver   MarketStateSourceEnum = "DRIVER"
+	MarketStateSourceEnumAdmin    MarketStateSourceEnum = "ADMIN"
+	MarketStateSourceEnumSchedule MarketStateSourceEnum = "SCHEDULE"
+	MarketStateSourceEnumDefault  MarketStateSourceEnum = "DEFAULT"
+)
+
+func (m MarketStateSourceEnum) String() string {
+	return string(m)
+}
+
+// TimeInForceEnum controls how long an order stays open.
+//
+//	GTC good till cancelled: open until it is filled or rejected.
+//	IOC immediate or cancel: executed on arrival, anything left expires.
+//	FOK fill or kill: filled completely on arrival or expired as a whole.
+//	GTD good till date: like GTC, but expires at ExpiresAt.
+type TimeInForceEnum string
+
+const (
+	TimeInForceEnumGTC TimeInForceEnum = "GTC"
+	TimeInForceEnumIOC TimeInForceEnum = "IOC"
+	TimeInForceEnumFOK TimeInForceEnum = "FOK"
+	TimeInForceEnumGTD TimeInForceEnum = "GTD"
+)
+
+func (t TimeInForceEnum) String() string {
+	return string(t)
+}
+
+// IsImmediate reports whether the order must be resolved on arrival and
+// never rests.
+func (t TimeInForceEnum) IsImmediate() bool {
+	return t == TimeInForceEnumIOC || t == TimeInForceEnumFOK
+}
+
 type UserRoleEnum string
 
 const (
@@ -41,6 +171,16 @@ func (u UserRoleEnum) String() string {
 
 type UserRolesEnum []UserRoleEnum
 
+// Has reports whether role is one of u.
+func (u UserRolesEnum) Has(role UserRoleEnum) bool {
+	for _, r := range u {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 func (u UserRolesEnum) Strings() []string {
 	res := make([]string, 0, len(u))
 	for _, role := range u {
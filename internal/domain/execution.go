@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Execution is a single fill of an order.
+type Execution struct {
+	ID         *uuid.UUID
+	OrderID    *uuid.UUID
+	Price      *string
+	Quantity   *int64
+	ExecutedAt *time.Time
+}
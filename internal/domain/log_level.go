@@ -0,0 +1,14 @@
+package domain
+
+// LogLevels are the service wide log level and the per-layer overrides.
+type LogLevels struct {
+	Level  string
+	Layers map[string]string
+}
+
+// SetLogLevelRequest sets the override of Layer, or the service wide level
+// when Layer is empty. An empty Level removes the override of Layer.
+type SetLogLevelRequest struct {
+	Layer string
+	Level string
+}
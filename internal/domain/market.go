@@ -21,3 +21,72 @@ type ViewMarketsRequest struct {
 type ViewMarketsResponse struct {
 	Markets []Market
 }
+
+// Contains reports whether the response lists marketID.
+func (r ViewMarketsResponse) Contains(marketID uuid.UUID) bool {
+	_, ok := r.Find(marketID)
+	return ok
+}
+
+// Find returns the market with marketID.
+func (r ViewMarketsResponse) Find(marketID uuid.UUID) (Market, bool) {
+	for _, m := range r.Markets {
+		if m.ID != nil && *m.ID == marketID {
+			return m, true
+		}
+	}
+	return Market{}, false
+}
+
+// IsListed reports whether SpotInstrumentService offers the market for
+// trading, that is it is neither disabled nor deleted.
+func (m Market) IsListed() bool {
+	return (m.Enabled == nil || *m.Enabled) && m.DeletedAt == nil
+}
+
+// MarketStateOverride is a market state set by an operator.
+type MarketStateOverride struct {
+	MarketID  *uuid.UUID
+	State     *MarketStateEnum
+	Reason    *string
+	UpdatedAt *time.Time
+}
+
+// MarketStatus is the effective state of a market and where it came from.
+type MarketStatus struct {
+	MarketID  *uuid.UUID
+	State     *MarketStateEnum
+	Source    *MarketStateSourceEnum
+	Reason    *string
+	UpdatedAt *time.Time
+}
+
+type GetMarketStateRequest struct {
+	MarketID  *uuid.UUID    `validate:"required"`
+	UserRoles UserRolesEnum `validate:"required,gt=0"`
+}
+
+type GetMarketStateResponse struct {
+	Status MarketStatus
+}
+
+// SetMarketStateRequest sets the state of a market, or clears a previous
+// override when State is nil so the market follows its schedule again.
+// CancelRestingOrders cancels every open order of the market; it is only
+// allowed with a state that stops trading. The caller must have an admin
+// identity; UserRoles only select the markets it can see.
+type SetMarketStateRequest struct {
+	UserRoles           UserRolesEnum    `validate:"required,gt=0"`
+	MarketID            *uuid.UUID       `validate:"required"`
+	State               *MarketStateEnum `validate:"omitempty,oneof=OPEN HALTED CLOSED AUCTION"`
+	Reason              *string
+	CancelRestingOrders bool
+}
+
+type SetMarketStateResponse struct {
+	Status MarketStatus
+	// Cancelled and Failed are the resting orders the change cancelled and
+	// failed to cancel.
+	Cancelled []uuid.UUID
+	Failed    []uuid.UUID
+}
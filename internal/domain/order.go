@@ -7,15 +7,24 @@ import (
 )
 
 type Order struct {
-	ID        *uuid.UUID
-	UserID    *uuid.UUID
-	MarketID  *uuid.UUID
-	OrderType *OrderTypeEnum
-	Price     *string
-	Quantity  *int64
-	Status    *OrderStatusEnum
-	CreatedAt *time.Time
-	UpdatedAt *time.Time
+	ID          *uuid.UUID
+	UserID      *uuid.UUID
+	MarketID    *uuid.UUID
+	OrderType   *OrderTypeEnum
+	Side        *OrderSideEnum
+	Price       *string
+	Quantity    *int64
+	Status      *OrderStatusEnum
+	TimeInForce *TimeInForceEnum
+	ExpiresAt   *time.Time
+	// FilledQuantity and RemainingQuantity always add up to Quantity.
+	// AvgFillPrice is the volume weighted price of all fills and is nil
+	// until the first fill.
+	FilledQuantity    *int64
+	RemainingQuantity *int64
+	AvgFillPrice      *string
+	CreatedAt         *time.Time
+	UpdatedAt         *time.Time
 }
 
 type CreateOrderRequest struct {
@@ -25,11 +34,21 @@ type CreateOrderRequest struct {
 	Price     *string        `validate:"required"`
 	Quantity  *int64         `validate:"required,gt=0"`
 	UserRoles UserRolesEnum  `validate:"required,gt=0"`
+	// Side defaults to BUY for clients that predate order sides.
+	Side *OrderSideEnum `validate:"omitempty,oneof=BUY SELL"`
+	// TimeInForce defaults to GTC. ExpiresAt is required for GTD and must
+	// be empty otherwise.
+	TimeInForce *TimeInForceEnum `validate:"omitempty,oneof=GTC IOC FOK GTD"`
+	ExpiresAt   *time.Time
 }
 
 type CreateOrderResponse struct {
 	OrderID     *uuid.UUID
 	OrderStatus *OrderStatusEnum
+	// The fill fields report what the order filled on arrival.
+	FilledQuantity    *int64
+	RemainingQuantity *int64
+	AvgFillPrice      *string
 }
 
 type GetOrderStatusRequest struct {
@@ -38,16 +57,112 @@ type GetOrderStatusRequest struct {
 }
 
 type GetOrderStatusResponse struct {
-	Status *OrderStatusEnum
+	Status            *OrderStatusEnum
+	FilledQuantity    *int64
+	RemainingQuantity *int64
+	AvgFillPrice      *string
 }
 
 type StreamOrderUpdatesRequest struct {
 	OrderID *uuid.UUID `validate:"required"`
 	UserID  *uuid.UUID `validate:"required"`
+	// CancelOnDisconnect opts the stream into cancel-on-disconnect: if it
+	// drops and the user has no other such stream open, their open orders
+	// are cancelled after a grace period unless they reconnect.
+	CancelOnDisconnect bool
 }
 
 type StreamOrderUpdatesResponse struct {
-	OrderID     *uuid.UUID
-	OrderStatus *OrderStatusEnum
-	UpdatedAt   *time.Time
+	OrderID           *uuid.UUID
+	OrderStatus       *OrderStatusEnum
+	FilledQuantity    *int64
+	RemainingQuantity *int64
+	AvgFillPrice      *string
+	// Executions are the fills since the previous update, oldest first.
+	Executions []Execution
+	UpdatedAt  *time.Time
+}
+
+// ReplaceOrderRequest amends a non-terminal order in place. Nil fields keep
+// their current value. Quantity is the new total quantity, including what
+// has already been filled.
+type ReplaceOrderRequest struct {
+	OrderID   *uuid.UUID    `validate:"required"`
+	UserID    *uuid.UUID    `validate:"required"`
+	UserRoles UserRolesEnum `validate:"required,gt=0"`
+	Price     *string
+	Quantity  *int64 `validate:"omitempty,gt=0"`
+}
+
+type ReplaceOrderResponse struct {
+	OrderID           *uuid.UUID
+	OrderStatus       *OrderStatusEnum
+	Price             *string
+	Quantity          *int64
+	RemainingQuantity *int64
+	// KeptPriority reports whether the order kept its place in the queue.
+	KeptPriority *bool
+}
+
+// OrderAmendment is one entry of an order's history: a replace that changed
+// its price or quantity, or its cancellation. A cancellation leaves price
+// and quantity unchanged and carries CancelReason.
+type OrderAmendment struct {
+	OrderID          *uuid.UUID
+	Action           *OrderHistoryActionEnum
+	PreviousPrice    *string
+	Price            *string
+	PreviousQuantity *int64
+	Quantity         *int64
+	KeptPriority     *bool
+	CancelReason     *CancelReasonEnum
+	AmendedAt        *time.Time
+}
+
+type GetOrderHistoryRequest struct {
+	OrderID *uuid.UUID `validate:"required"`
+	UserID  *uuid.UUID `validate:"required"`
+}
+
+type GetOrderHistoryResponse struct {
+	Amendments []OrderAmendment
+}
+
+// OrderFilter selects the orders of a bulk operation. A nil field matches
+// every order.
+type OrderFilter struct {
+	UserID   *uuid.UUID
+	MarketID *uuid.UUID
+}
+
+func (f OrderFilter) Matches(order Order) bool {
+	if f.UserID != nil && (order.UserID == nil || *order.UserID != *f.UserID) {
+		return false
+	}
+	if f.MarketID != nil && (order.MarketID == nil || *order.MarketID != *f.MarketID) {
+		return false
+	}
+	return true
+}
+
+// CancelAllOrdersRequest cancels the open orders of the caller, optionally
+// only those of one market.
+type CancelAllOrdersRequest struct {
+	UserID   *uuid.UUID `validate:"required"`
+	MarketID *uuid.UUID
+}
+
+// AdminCancelAllOrdersRequest cancels the open orders of every user in one
+// market, or in every market when MarketID is nil. The caller must have an
+// admin identity.
+type AdminCancelAllOrdersRequest struct {
+	MarketID *uuid.UUID
+}
+
+// CancelAllOrdersResponse lists the orders that were cancelled and those
+// that could not be. Orders that closed on their own while the cancel ran
+// are in neither list.
+type CancelAllOrdersResponse struct {
+	Cancelled []uuid.UUID
+	Failed    []uuid.UUID
 }
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+)
+
+// OrderBookLevel is the aggregated state of one price level. In updates a
+// Quantity of zero means the level was removed.
+type OrderBookLevel struct {
+	Side     *OrderSideEnum
+	Price    *string
+	Quantity *int64
+	Orders   *int64
+}
+
+type GetOrderBookRequest struct {
+	MarketID  *uuid.UUID    `validate:"required"`
+	UserRoles UserRolesEnum `validate:"required,gt=0"`
+	// Depth is the number of levels per side; nil returns the default.
+	Depth *int64 `validate:"omitempty,gt=0,lte=1000"`
+}
+
+type GetOrderBookResponse struct {
+	MarketID *uuid.UUID
+	Sequence *uint64
+	Bids     []OrderBookLevel
+	Asks     []OrderBookLevel
+}
+
+type StreamOrderBookRequest struct {
+	MarketID  *uuid.UUID    `validate:"required"`
+	UserRoles UserRolesEnum `validate:"required,gt=0"`
+}
+
+// StreamOrderBookResponse is either a full snapshot or an incremental
+// update. The first message of a stream is a snapshot; another snapshot
+// follows whenever the stream had to resynchronise, so clients replace
+// their book on every snapshot and apply updates in Sequence order.
+type StreamOrderBookResponse struct {
+	MarketID *uuid.UUID
+	Sequence *uint64
+	Snapshot *GetOrderBookResponse
+	Levels   []OrderBookLevel
+}
@@ -9,6 +9,7 @@ import (
 	spot_instrument_driver "github.com/FlyKarlik/orderService/internal/driver/spot_instrument"
 	grpc_client "github.com/FlyKarlik/orderService/pkg/client/grpc"
 	"github.com/FlyKarlik/orderService/pkg/logger"
+	"github.com/FlyKarlik/orderService/pkg/metric"
 	pb "github.com/FlyKarlik/proto/spot_instrument_service/gen/spot_instrument_service/proto"
 )
 
@@ -25,12 +26,14 @@ type driverImpl struct {
 }
 
 func New(
+	ctx context.Context,
 	cfg *config.Config,
 	logger logger.Logger,
 	interceptor *grpc_interceptor.GRPCInterceptor,
+	metrics *metric.Registry,
 ) (*driverImpl, []grpc_client.IGRPCClient, error) {
 	grpcConns := make([]grpc_client.IGRPCClient, 0)
-	spotInstrumentConn, err := spot_instrument_driver.SetupSpotInstrumentClient(cfg, interceptor)
+	spotInstrumentConn, err := spot_instrument_driver.SetupSpotInstrumentClient(ctx, cfg, logger, interceptor)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -40,6 +43,7 @@ func New(
 		IMarketDriver: spot_instrument_driver.NewMarketDriver(
 			logger,
 			pb.NewSpotInstrumentServiceClient(spotInstrumentConn),
+			metrics,
 		),
 	}, grpcConns, nil
 }
@@ -2,22 +2,26 @@ package spot_instrument_driver
 
 import (
 	"context"
+	"time"
 
 	"github.com/FlyKarlik/orderService/internal/domain"
 	"github.com/FlyKarlik/orderService/internal/mapper"
 	"github.com/FlyKarlik/orderService/pkg/logger"
+	"github.com/FlyKarlik/orderService/pkg/metric"
 	pb "github.com/FlyKarlik/proto/spot_instrument_service/gen/spot_instrument_service/proto"
 )
 
 type marketDriver struct {
-	logger logger.Logger
-	client pb.SpotInstrumentServiceClient
+	logger  logger.Logger
+	client  pb.SpotInstrumentServiceClient
+	metrics *metric.Registry
 }
 
-func NewMarketDriver(l logger.Logger, client pb.SpotInstrumentServiceClient) *marketDriver {
+func NewMarketDriver(l logger.Logger, client pb.SpotInstrumentServiceClient, metrics *metric.Registry) *marketDriver {
 	return &marketDriver{
-		logger: l,
-		client: client,
+		logger:  l,
+		client:  client,
+		metrics: metrics,
 	}
 }
 
@@ -25,7 +29,9 @@ func (s *marketDriver) ViewMarkets(
 	ctx context.Context,
 	req domain.ViewMarketsRequest,
 ) (domain.ViewMarketsResponse, error) {
+	start := time.Now()
 	resp, err := s.client.ViewMarkets(ctx, mapper.ToProtoViewMarketsRequest(req))
+	s.metrics.UpstreamRequest("spot_instrument_service", "ViewMarkets", time.Since(start), err)
 	if err != nil {
 		return domain.ViewMarketsResponse{}, err
 	}
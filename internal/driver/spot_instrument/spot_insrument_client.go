@@ -1,19 +1,33 @@
 package spot_instrument_driver
 
 import (
+	"context"
+
 	"github.com/FlyKarlik/orderService/config"
 	grpc_interceptor "github.com/FlyKarlik/orderService/internal/delivery/grpc/interceptor"
 	grpc_client "github.com/FlyKarlik/orderService/pkg/client/grpc"
+	"github.com/FlyKarlik/orderService/pkg/logger"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 )
 
 func SetupSpotInstrumentClient(
+	ctx context.Context,
 	cfg *config.Config,
+	l logger.Logger,
 	interceptor *grpc_interceptor.GRPCInterceptor,
 ) (grpc_client.IGRPCClient, error) {
+	const layer = "driver"
+	const method = "SetupSpotInstrumentClient"
+
 	conn, err := grpc_client.New(
+		ctx,
 		cfg.GRPCApi.SpotInstrumentServiceHost,
 		cfg,
+		func(err error) {
+			l.Error(layer, method, "failed to reload tls certificates", err)
+		},
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
 		grpc.WithUnaryInterceptor(interceptor.XRequestIDUnaryClientInterceptor()),
 	)
 	if err != nil {
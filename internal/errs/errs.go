@@ -25,6 +25,27 @@ const (
 	CodeMarketNotFound
 	CodeInvalidUserID
 	CodeInvalidOrderID
+	CodeInvalidTimeInForce
+	CodeInvalidPrice
+	CodeOrderBookUnavailable
+	CodeOrderNotOpen
+	CodeInvalidReplace
+	CodePermissionDenied
+	CodeRiskMaxQuantity
+	CodeRiskMaxNotional
+	CodeRiskMaxOpenOrders
+	CodeRiskPriceBand
+	CodeRiskFatFinger
+	CodeInsufficientBalance
+	CodeMarketAssetsUnknown
+	CodeAccountsDisabled
+	CodeInvalidAdjustment
+	CodeMarketNotOpen
+	CodeInvalidMarketState
+	CodeUnauthenticated
+	CodeInvalidLogLevel
+	CodeMarketOrderUnavailable
+	CodeSelfTrade
 )
 
 var (
@@ -32,4 +53,37 @@ var (
 	ErrMarketNotFound = New(CodeMarketNotFound, "market not found")
 	ErrInvalidUserID  = New(CodeInvalidUserID, "invalid user id")
 	ErrInvalidOrderID = New(CodeInvalidOrderID, "invalid order id")
+
+	ErrOrderBookUnavailable   = New(CodeOrderBookUnavailable, "order books are only available in matching execution mode")
+	ErrMarketOrderUnavailable = New(CodeMarketOrderUnavailable, "market orders are only available in matching execution mode")
+
+	ErrOrderNotOpen        = New(CodeOrderNotOpen, "order is no longer open")
+	ErrNothingToReplace    = New(CodeInvalidReplace, "replace must change the price or the quantity")
+	ErrInvalidReplaceSize  = New(CodeInvalidReplace, "quantity must be greater than the filled quantity")
+	ErrInvalidReplacePrice = New(CodeInvalidReplace, "price can only be amended on limit orders")
+
+	ErrUnauthenticated  = New(CodeUnauthenticated, "operation requires a client certificate")
+	ErrPermissionDenied = New(CodePermissionDenied, "client identity is not allowed to perform this operation")
+
+	ErrRiskMaxQuantity   = New(CodeRiskMaxQuantity, "order quantity exceeds the maximum for this market")
+	ErrRiskMaxNotional   = New(CodeRiskMaxNotional, "order notional exceeds the maximum for this market")
+	ErrRiskMaxOpenOrders = New(CodeRiskMaxOpenOrders, "too many open orders in this market")
+	ErrRiskPriceBand     = New(CodeRiskPriceBand, "price is outside the price band of this market")
+	ErrRiskFatFinger     = New(CodeRiskFatFinger, "price deviates too far from the reference price")
+
+	ErrInsufficientBalance = New(CodeInsufficientBalance, "insufficient available balance")
+	ErrMarketAssetsUnknown = New(CodeMarketAssetsUnknown, "market has no configured base and quote assets")
+	ErrAccountsDisabled    = New(CodeAccountsDisabled, "accounts are disabled")
+	ErrInvalidAmount       = New(CodeInvalidAdjustment, "amount must be a non-zero decimal")
+	ErrInvalidAsset        = New(CodeInvalidAdjustment, "asset must not be empty")
+
+	ErrSelfTrade = New(CodeSelfTrade, "order would trade against a resting order of the same user")
+
+	ErrMarketNotOpen      = New(CodeMarketNotOpen, "market is not open for trading")
+	ErrCancelWhileTrading = New(CodeInvalidMarketState, "resting orders can only be cancelled with a state that stops trading")
+
+	ErrInvalidLogLevel = New(CodeInvalidLogLevel, "log level must be debug, info, warn or error and log layer one the service logs under")
+
+	ErrInvalidPrice       = New(CodeInvalidPrice, "price must be a positive decimal")
+	ErrInvalidTimeInForce = New(CodeInvalidTimeInForce, "expires_at is required for GTD orders, must be in the future and is not allowed for other time in force values")
 )
@@ -35,3 +35,27 @@ func FromProtoMarkets(pb []*spotPb.Market) []domain.Market {
 	}
 	return markets
 }
+
+func ToProtoViewMarketsResponse(resp domain.ViewMarketsResponse) *spotPb.ViewMarketsResponse {
+	return &spotPb.ViewMarketsResponse{
+		Markets: ToProtoMarkets(resp.Markets),
+	}
+}
+
+func ToProtoMarket(market domain.Market) *spotPb.Market {
+	return &spotPb.Market{
+		Id:           proto_mapper.ToIDProto(market.ID),
+		Name:         proto_mapper.ToStringProto(market.Name),
+		Enabled:      proto_mapper.ToBoolProto(market.Enabled),
+		DeletedAt:    proto_mapper.ToTimestampProto(market.DeletedAt),
+		AllowedRoles: ToProtoUserRoles(market.AllowedRoles, spotPb.UserRole(0)),
+	}
+}
+
+func ToProtoMarkets(markets []domain.Market) []*spotPb.Market {
+	pb := make([]*spotPb.Market, 0, len(markets))
+	for _, market := range markets {
+		pb = append(pb, ToProtoMarket(market))
+	}
+	return pb
+}
@@ -31,6 +31,9 @@ func FromProtoStreamOrderUpdatesRequest(pb *pb.StreamOrderUpdatesRequest) domain
 	}
 }
 
+// ToProtoStreamOrderUpdatesResponse maps the status part of an update. The
+// OrderUpdate message has no fill fields; the stream handler sends them and
+// the exact status as metadata (see ToOrderFillMetadata).
 func ToProtoStreamOrderUpdatesResponse(domain domain.StreamOrderUpdatesResponse) *pb.OrderUpdate {
 	return &pb.OrderUpdate{
 		OrderId:   proto_mapper.ToIDProto(domain.OrderID),
@@ -81,10 +84,22 @@ func MapEnumToOrderStatus(enum *domain.OrderStatusEnum) pb.OrderStatus {
 		return pb.OrderStatus_CREATED
 	case domain.OrderStatusEnumPending:
 		return pb.OrderStatus_PENDING
+	// A partially filled order is still working, which is what PENDING
+	// means to clients of the current proto. The exact status is sent as
+	// metadata, see ToOrderFillMetadata.
+	case domain.OrderStatusEnumPartiallyFilled:
+		return pb.OrderStatus_PENDING
 	case domain.OrderStatusEnumFilled:
 		return pb.OrderStatus_FILLED
 	case domain.OrderStatusEnumRejected:
 		return pb.OrderStatus_REJECTED
+	// The order_service proto has no EXPIRED status yet; expired orders are
+	// reported to gRPC clients as REJECTED, the closest terminal state.
+	case domain.OrderStatusEnumExpired:
+		return pb.OrderStatus_REJECTED
+	// Neither is there a CANCELLED status; see EXPIRED above.
+	case domain.OrderStatusEnumCancelled:
+		return pb.OrderStatus_REJECTED
 	default:
 		return pb.OrderStatus_ORDER_STATUS_UNSPECIFIED
 	}
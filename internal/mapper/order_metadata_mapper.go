@@ -0,0 +1,50 @@
+package mapper
+
+import (
+	"strconv"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"google.golang.org/grpc/metadata"
+)
+
+// The order_service proto folds PARTIALLY_FILLED into PENDING and EXPIRED and
+// CANCELLED into REJECTED, and has no fill fields, so gRPC handlers send the
+// exact status and the fill details as metadata under these keys.
+const (
+	MetadataKeyOrderStatus       = "x-order-status"
+	MetadataKeyFilledQuantity    = "x-filled-quantity"
+	MetadataKeyRemainingQuantity = "x-remaining-quantity"
+	MetadataKeyAvgFillPrice      = "x-avg-fill-price"
+)
+
+// ToOrderFillMetadata returns the exact status and the fill details of an
+// order. Every key is set, with an empty value for what is unknown, such as
+// the average price before the first fill, so that the values of several
+// updates joined into one trailer stay aligned.
+func ToOrderFillMetadata(
+	status *domain.OrderStatusEnum,
+	filledQuantity *int64,
+	remainingQuantity *int64,
+	avgFillPrice *string,
+) metadata.MD {
+	md := metadata.MD{}
+	md.Set(MetadataKeyOrderStatus, fromStringPtr((*string)(status)))
+	md.Set(MetadataKeyFilledQuantity, fromInt64Ptr(filledQuantity))
+	md.Set(MetadataKeyRemainingQuantity, fromInt64Ptr(remainingQuantity))
+	md.Set(MetadataKeyAvgFillPrice, fromStringPtr(avgFillPrice))
+	return md
+}
+
+func fromStringPtr(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func fromInt64Ptr(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatInt(*value, 10)
+}
@@ -0,0 +1,29 @@
+package mapper
+
+import (
+	"strings"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+)
+
+// FromStringOrderSide maps a client supplied order side. An empty value maps
+// to nil, which the repository treats as BUY.
+func FromStringOrderSide(value string) *domain.OrderSideEnum {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	side := domain.OrderSideEnum(strings.ToUpper(value))
+	return &side
+}
+
+// FromStringTimeInForce maps a client supplied time in force. An empty value
+// maps to nil, which the usecase treats as GTC.
+func FromStringTimeInForce(value string) *domain.TimeInForceEnum {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	timeInForce := domain.TimeInForceEnum(strings.ToUpper(value))
+	return &timeInForce
+}
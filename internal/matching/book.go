@@ -0,0 +1,332 @@
+// Package matching implements in-process price-time priority order books.
+//
+// A Book only decides what trades: it keeps resting limit orders and plans
+// matches for incoming ones. Storage and status transitions belong to the
+// caller, which first plans a match with Match, makes the result durable and
+// then applies it with Commit. The caller holds the book lock across all
+// three steps, so a plan is never applied to a book that changed under it.
+//
+// Orders never trade with orders of the same user: an incoming order that
+// would fill against one is planned with no fills and SelfTrade set, and
+// the caller rejects it.
+package matching
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/pkg/decimal"
+	"github.com/google/uuid"
+)
+
+// Order is the part of an order the book needs. Quantity is the quantity
+// still open.
+type Order struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Side        domain.OrderSideEnum
+	Type        domain.OrderTypeEnum
+	TimeInForce domain.TimeInForceEnum
+	Price       decimal.Decimal
+	Quantity    int64
+}
+
+// Fill is one trade between the incoming order and a resting maker. It
+// always executes at the maker's price.
+type Fill struct {
+	MakerID  uuid.UUID
+	Price    decimal.Decimal
+	Quantity int64
+}
+
+// Result is the planned outcome of matching an incoming order.
+type Result struct {
+	Fills []Fill
+	// Remaining is the quantity left after the fills.
+	Remaining int64
+	// Rests reports whether the remainder is added to the book. It is false
+	// for market, IOC and FOK orders, whose remainder expires.
+	Rests bool
+	// SelfTrade reports that the order would have filled against a resting
+	// order of the same user. The result then has no fills and must not be
+	// committed.
+	SelfTrade bool
+}
+
+// Filled returns the total filled quantity.
+func (r Result) Filled() int64 {
+	var filled int64
+	for _, fill := range r.Fills {
+		filled += fill.Quantity
+	}
+	return filled
+}
+
+type resting struct {
+	order Order
+}
+
+type priceLevel struct {
+	price  decimal.Decimal
+	orders []*resting
+}
+
+func (l *priceLevel) quantity() int64 {
+	var total int64
+	for _, r := range l.orders {
+		total += r.order.Quantity
+	}
+	return total
+}
+
+// bookSide keeps price levels sorted best first: highest bid, lowest ask.
+type bookSide struct {
+	side   domain.OrderSideEnum
+	levels []*priceLevel
+}
+
+// better reports whether price a has priority over price b on this side.
+func (s *bookSide) better(a, b decimal.Decimal) bool {
+	if s.side == domain.OrderSideEnumBuy {
+		return a.Cmp(b) > 0
+	}
+	return a.Cmp(b) < 0
+}
+
+// level returns the index of price and whether a level exists there.
+func (s *bookSide) level(price decimal.Decimal) (int, bool) {
+	i := sort.Search(len(s.levels), func(i int) bool {
+		return !s.better(s.levels[i].price, price)
+	})
+	return i, i < len(s.levels) && s.levels[i].price.Equal(price)
+}
+
+func (s *bookSide) add(r *resting) {
+	i, ok := s.level(r.order.Price)
+	if !ok {
+		s.levels = append(s.levels, nil)
+		copy(s.levels[i+1:], s.levels[i:])
+		s.levels[i] = &priceLevel{price: r.order.Price}
+	}
+	s.levels[i].orders = append(s.levels[i].orders, r)
+}
+
+func (s *bookSide) remove(id uuid.UUID, price decimal.Decimal) bool {
+	i, ok := s.level(price)
+	if !ok {
+		return false
+	}
+	level := s.levels[i]
+	for j, r := range level.orders {
+		if r.order.ID == id {
+			level.orders = append(level.orders[:j], level.orders[j+1:]...)
+			if len(level.orders) == 0 {
+				s.levels = append(s.levels[:i], s.levels[i+1:]...)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Book is the order book of one market.
+type Book struct {
+	mu       sync.Mutex
+	marketID uuid.UUID
+	bids     bookSide
+	asks     bookSide
+	index    map[uuid.UUID]*resting
+
+	// seq counts published depth updates; touched collects the levels
+	// changed by the mutation in progress.
+	seq         uint64
+	touched     map[levelKey]decimal.Decimal
+	subscribers map[*subscriber]struct{}
+}
+
+func NewBook(marketID uuid.UUID) *Book {
+	return &Book{
+		marketID: marketID,
+		bids:     bookSide{side: domain.OrderSideEnumBuy},
+		asks:     bookSide{side: domain.OrderSideEnumSell},
+		index:    make(map[uuid.UUID]*resting),
+	}
+}
+
+func (b *Book) Lock()   { b.mu.Lock() }
+func (b *Book) Unlock() { b.mu.Unlock() }
+
+func (b *Book) MarketID() uuid.UUID {
+	return b.marketID
+}
+
+func (b *Book) sideOf(side domain.OrderSideEnum) *bookSide {
+	if side == domain.OrderSideEnumBuy {
+		return &b.bids
+	}
+	return &b.asks
+}
+
+// crosses reports whether an incoming order accepts the price of a resting
+// level. Market orders accept any price.
+func crosses(o Order, price decimal.Decimal) bool {
+	if o.Type == domain.OrderTypeEnumMarket {
+		return true
+	}
+	if o.Side == domain.OrderSideEnumBuy {
+		return price.Cmp(o.Price) <= 0
+	}
+	return price.Cmp(o.Price) >= 0
+}
+
+// Match plans the execution of an incoming order without changing the book.
+// The caller must hold the book lock.
+func (b *Book) Match(o Order) Result {
+	contra := b.sideOf(o.Side.Opposite())
+
+	if o.TimeInForce == domain.TimeInForceEnumFOK && b.available(contra, o) < o.Quantity {
+		return Result{Remaining: o.Quantity}
+	}
+
+	result := Result{Remaining: o.Quantity}
+	for _, level := range contra.levels {
+		if result.Remaining == 0 || !crosses(o, level.price) {
+			break
+		}
+		for _, r := range level.orders {
+			if result.Remaining == 0 {
+				break
+			}
+			if r.order.UserID == o.UserID {
+				return Result{Remaining: o.Quantity, SelfTrade: true}
+			}
+			quantity := min(result.Remaining, r.order.Quantity)
+			result.Fills = append(result.Fills, Fill{
+				MakerID:  r.order.ID,
+				Price:    level.price,
+				Quantity: quantity,
+			})
+			result.Remaining -= quantity
+		}
+	}
+
+	result.Rests = result.Remaining > 0 &&
+		o.Type == domain.OrderTypeEnumLimit &&
+		!o.TimeInForce.IsImmediate()
+	return result
+}
+
+// available returns how much of o could fill right now, up to o.Quantity.
+func (b *Book) available(contra *bookSide, o Order) int64 {
+	var total int64
+	for _, level := range contra.levels {
+		if total >= o.Quantity || !crosses(o, level.price) {
+			break
+		}
+		total += level.quantity()
+	}
+	return total
+}
+
+// Commit applies a result planned by Match for o: makers are reduced or
+// removed and the remainder of o rests when the result says so. The caller
+// must hold the book lock and must not have changed the book since Match.
+func (b *Book) Commit(o Order, result Result) {
+	b.commit(o, result)
+	b.publish()
+}
+
+// Replace applies a result planned by Match for the amended form of a
+// resting order. The old entry leaves the book and the remainder, if it
+// rests, joins the back of its price level. The caller must hold the book
+// lock and must not have changed the book since Match.
+func (b *Book) Replace(o Order, result Result) {
+	b.remove(o.ID)
+	b.commit(o, result)
+	b.publish()
+}
+
+// Reduce lowers the open quantity of a resting order without changing its
+// place in the queue. The caller must hold the book lock.
+func (b *Book) Reduce(id uuid.UUID, quantity int64) bool {
+	r, ok := b.index[id]
+	if !ok || quantity <= 0 || quantity > r.order.Quantity {
+		return false
+	}
+	r.order.Quantity = quantity
+	b.touch(r.order.Side, r.order.Price)
+	b.publish()
+	return true
+}
+
+func (b *Book) commit(o Order, result Result) {
+	for _, fill := range result.Fills {
+		r, ok := b.index[fill.MakerID]
+		if !ok {
+			continue
+		}
+		r.order.Quantity -= fill.Quantity
+		b.touch(r.order.Side, r.order.Price)
+		if r.order.Quantity <= 0 {
+			b.sideOf(r.order.Side).remove(r.order.ID, r.order.Price)
+			delete(b.index, r.order.ID)
+		}
+	}
+
+	if result.Rests {
+		o.Quantity = result.Remaining
+		b.rest(o)
+	}
+}
+
+// Rest adds a limit order to the book behind every order already resting at
+// its price. It is used to rebuild books on startup. The caller must hold
+// the book lock.
+func (b *Book) Rest(o Order) {
+	b.rest(o)
+	b.publish()
+}
+
+func (b *Book) rest(o Order) {
+	if _, ok := b.index[o.ID]; ok || o.Quantity <= 0 {
+		return
+	}
+	r := &resting{order: o}
+	b.index[o.ID] = r
+	b.sideOf(o.Side).add(r)
+	b.touch(o.Side, o.Price)
+}
+
+// Remove takes a resting order off the book and reports whether it was
+// there. The caller must hold the book lock.
+func (b *Book) Remove(id uuid.UUID) bool {
+	removed := b.remove(id)
+	b.publish()
+	return removed
+}
+
+// RemoveAll takes the resting orders ids off the book and publishes the
+// change as a single update. The caller must hold the book lock.
+func (b *Book) RemoveAll(ids []uuid.UUID) {
+	for _, id := range ids {
+		b.remove(id)
+	}
+	b.publish()
+}
+
+func (b *Book) remove(id uuid.UUID) bool {
+	r, ok := b.index[id]
+	if !ok {
+		return false
+	}
+	delete(b.index, id)
+	b.touch(r.order.Side, r.order.Price)
+	return b.sideOf(r.order.Side).remove(id, r.order.Price)
+}
+
+// Len returns the number of resting orders. The caller must hold the book
+// lock.
+func (b *Book) Len() int {
+	return len(b.index)
+}
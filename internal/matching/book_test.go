@@ -0,0 +1,325 @@
+package matching
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/pkg/decimal"
+	"github.com/google/uuid"
+)
+
+func mustPrice(t *testing.T, price string) decimal.Decimal {
+	t.Helper()
+	d, err := decimal.Parse(price)
+	if err != nil {
+		t.Fatalf("decimal.Parse(%q) error = %v", price, err)
+	}
+	return d
+}
+
+func limitOrder(t *testing.T, side domain.OrderSideEnum, price string, quantity int64) Order {
+	t.Helper()
+	return Order{
+		ID:          uuid.New(),
+		UserID:      uuid.New(),
+		Side:        side,
+		Type:        domain.OrderTypeEnumLimit,
+		TimeInForce: domain.TimeInForceEnumGTC,
+		Price:       mustPrice(t, price),
+		Quantity:    quantity,
+	}
+}
+
+// submit plans and commits o the way the repository does.
+func submit(b *Book, o Order) Result {
+	b.Lock()
+	defer b.Unlock()
+	result := b.Match(o)
+	b.Commit(o, result)
+	return result
+}
+
+type fillWant struct {
+	maker    uuid.UUID
+	price    string
+	quantity int64
+}
+
+func checkFills(t *testing.T, result Result, want []fillWant) {
+	t.Helper()
+	got := make([]fillWant, 0, len(result.Fills))
+	for _, fill := range result.Fills {
+		got = append(got, fillWant{maker: fill.MakerID, price: fill.Price.String(), quantity: fill.Quantity})
+	}
+	if want == nil {
+		want = []fillWant{}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("fills = %+v, want %+v", got, want)
+	}
+}
+
+// levels returns the quantity of every level on side by price.
+func levels(b *Book, side domain.OrderSideEnum) map[string]int64 {
+	b.Lock()
+	defer b.Unlock()
+	out := make(map[string]int64)
+	for _, level := range b.sideOf(side).depth(0) {
+		out[level.Price.String()] = level.Quantity
+	}
+	return out
+}
+
+func queue(b *Book, side domain.OrderSideEnum, price decimal.Decimal) []uuid.UUID {
+	b.Lock()
+	defer b.Unlock()
+	s := b.sideOf(side)
+	i, ok := s.level(price)
+	if !ok {
+		return nil
+	}
+	var ids []uuid.UUID
+	for _, r := range s.levels[i].orders {
+		ids = append(ids, r.order.ID)
+	}
+	return ids
+}
+
+func TestMatchPriceTimePriority(t *testing.T) {
+	b := NewBook(uuid.New())
+	worse := limitOrder(t, domain.OrderSideEnumSell, "101", 5)
+	first := limitOrder(t, domain.OrderSideEnumSell, "100", 5)
+	second := limitOrder(t, domain.OrderSideEnumSell, "100", 5)
+	for _, o := range []Order{worse, first, second} {
+		submit(b, o)
+	}
+
+	// A buy at 100 never reaches 101 and takes the orders at 100 in
+	// arrival order.
+	result := submit(b, limitOrder(t, domain.OrderSideEnumBuy, "100", 7))
+	checkFills(t, result, []fillWant{
+		{maker: first.ID, price: "100", quantity: 5},
+		{maker: second.ID, price: "100", quantity: 2},
+	})
+
+	// A higher buy trades at the makers' prices, best first.
+	result = submit(b, limitOrder(t, domain.OrderSideEnumBuy, "105", 6))
+	checkFills(t, result, []fillWant{
+		{maker: second.ID, price: "100", quantity: 3},
+		{maker: worse.ID, price: "101", quantity: 3},
+	})
+	if got := levels(b, domain.OrderSideEnumSell); !reflect.DeepEqual(got, map[string]int64{"101": 2}) {
+		t.Fatalf("asks = %v, want only 2 at 101", got)
+	}
+}
+
+func TestMatchTimeInForce(t *testing.T) {
+	cases := []struct {
+		name        string
+		orderType   domain.OrderTypeEnum
+		timeInForce domain.TimeInForceEnum
+		price       string
+		quantity    int64
+		wantFilled  int64
+		wantRests   bool
+		wantBids    map[string]int64
+		wantAsks    map[string]int64
+	}{
+		{
+			name:        "partial fill rests the remainder",
+			orderType:   domain.OrderTypeEnumLimit,
+			timeInForce: domain.TimeInForceEnumGTC,
+			price:       "101",
+			quantity:    12,
+			wantFilled:  8,
+			wantRests:   true,
+			wantBids:    map[string]int64{"101": 4},
+			wantAsks:    map[string]int64{"102": 5},
+		},
+		{
+			name:        "partial fill of a maker",
+			orderType:   domain.OrderTypeEnumLimit,
+			timeInForce: domain.TimeInForceEnumGTC,
+			price:       "100",
+			quantity:    2,
+			wantFilled:  2,
+			wantBids:    map[string]int64{},
+			wantAsks:    map[string]int64{"100": 1, "101": 5, "102": 5},
+		},
+		{
+			name:        "IOC cancels the remainder",
+			orderType:   domain.OrderTypeEnumLimit,
+			timeInForce: domain.TimeInForceEnumIOC,
+			price:       "101",
+			quantity:    12,
+			wantFilled:  8,
+			wantBids:    map[string]int64{},
+			wantAsks:    map[string]int64{"102": 5},
+		},
+		{
+			name:        "FOK that cannot fill whole does not trade",
+			orderType:   domain.OrderTypeEnumLimit,
+			timeInForce: domain.TimeInForceEnumFOK,
+			price:       "101",
+			quantity:    9,
+			wantBids:    map[string]int64{},
+			wantAsks:    map[string]int64{"100": 3, "101": 5, "102": 5},
+		},
+		{
+			name:        "FOK that can fill whole trades",
+			orderType:   domain.OrderTypeEnumLimit,
+			timeInForce: domain.TimeInForceEnumFOK,
+			price:       "101",
+			quantity:    8,
+			wantFilled:  8,
+			wantBids:    map[string]int64{},
+			wantAsks:    map[string]int64{"102": 5},
+		},
+		{
+			name:        "market order sweeps and never rests",
+			orderType:   domain.OrderTypeEnumMarket,
+			timeInForce: domain.TimeInForceEnumGTC,
+			price:       "0",
+			quantity:    20,
+			wantFilled:  13,
+			wantBids:    map[string]int64{},
+			wantAsks:    map[string]int64{},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := NewBook(uuid.New())
+			submit(b, limitOrder(t, domain.OrderSideEnumSell, "100", 3))
+			submit(b, limitOrder(t, domain.OrderSideEnumSell, "101", 5))
+			submit(b, limitOrder(t, domain.OrderSideEnumSell, "102", 5))
+
+			incoming := limitOrder(t, domain.OrderSideEnumBuy, tc.price, tc.quantity)
+			incoming.Type = tc.orderType
+			incoming.TimeInForce = tc.timeInForce
+			result := submit(b, incoming)
+
+			if result.Filled() != tc.wantFilled || result.Remaining != tc.quantity-tc.wantFilled {
+				t.Fatalf("filled %d remaining %d, want %d and %d", result.Filled(), result.Remaining, tc.wantFilled, tc.quantity-tc.wantFilled)
+			}
+			if result.Rests != tc.wantRests {
+				t.Fatalf("Rests = %v, want %v", result.Rests, tc.wantRests)
+			}
+			if got := levels(b, domain.OrderSideEnumBuy); !reflect.DeepEqual(got, tc.wantBids) {
+				t.Fatalf("bids = %v, want %v", got, tc.wantBids)
+			}
+			if got := levels(b, domain.OrderSideEnumSell); !reflect.DeepEqual(got, tc.wantAsks) {
+				t.Fatalf("asks = %v, want %v", got, tc.wantAsks)
+			}
+		})
+	}
+}
+
+func TestReplaceLosesAndReduceKeepsPriority(t *testing.T) {
+	b := NewBook(uuid.New())
+	first := limitOrder(t, domain.OrderSideEnumBuy, "100", 5)
+	second := limitOrder(t, domain.OrderSideEnumBuy, "100", 5)
+	submit(b, first)
+	submit(b, second)
+	price := first.Price
+
+	// Reducing keeps the place in the queue.
+	b.Lock()
+	if !b.Reduce(first.ID, 2) {
+		t.Fatal("Reduce() = false, want true")
+	}
+	if b.Reduce(first.ID, 3) {
+		t.Fatal("Reduce() above the open quantity = true, want false")
+	}
+	b.Unlock()
+	if got := queue(b, domain.OrderSideEnumBuy, price); !reflect.DeepEqual(got, []uuid.UUID{first.ID, second.ID}) {
+		t.Fatalf("queue after Reduce() = %v, want first ahead of second", got)
+	}
+
+	// Replacing, here with a larger quantity, sends it to the back.
+	amended := first
+	amended.Quantity = 8
+	b.Lock()
+	b.Replace(amended, b.Match(amended))
+	b.Unlock()
+	if got := queue(b, domain.OrderSideEnumBuy, price); !reflect.DeepEqual(got, []uuid.UUID{second.ID, first.ID}) {
+		t.Fatalf("queue after Replace() = %v, want second ahead of first", got)
+	}
+	if got := levels(b, domain.OrderSideEnumBuy); !reflect.DeepEqual(got, map[string]int64{"100": 13}) {
+		t.Fatalf("bids = %v, want 13 at 100", got)
+	}
+
+	// A replace that crosses trades and rests only its remainder.
+	ask := limitOrder(t, domain.OrderSideEnumSell, "101", 3)
+	submit(b, ask)
+	amended = second
+	amended.Price = mustPrice(t, "101")
+	b.Lock()
+	result := b.Match(amended)
+	b.Replace(amended, result)
+	b.Unlock()
+	checkFills(t, result, []fillWant{{maker: ask.ID, price: "101", quantity: 3}})
+	if got := levels(b, domain.OrderSideEnumBuy); !reflect.DeepEqual(got, map[string]int64{"100": 8, "101": 2}) {
+		t.Fatalf("bids = %v, want 8 at 100 and 2 at 101", got)
+	}
+}
+
+func TestMatchDoesNotChangeBookUntilCommit(t *testing.T) {
+	b := NewBook(uuid.New())
+	maker := limitOrder(t, domain.OrderSideEnumSell, "100", 5)
+	submit(b, maker)
+
+	// The store failed to persist the first plan, so it is dropped and the
+	// same order is planned again.
+	taker := limitOrder(t, domain.OrderSideEnumBuy, "100", 8)
+	b.Lock()
+	first := b.Match(taker)
+	b.Unlock()
+	if got := levels(b, domain.OrderSideEnumSell); !reflect.DeepEqual(got, map[string]int64{"100": 5}) {
+		t.Fatalf("asks after Match() = %v, want the book unchanged", got)
+	}
+	if got := levels(b, domain.OrderSideEnumBuy); len(got) != 0 {
+		t.Fatalf("bids after Match() = %v, want none", got)
+	}
+
+	second := submit(b, taker)
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("second plan = %+v, want the first %+v", second, first)
+	}
+	if got := levels(b, domain.OrderSideEnumBuy); !reflect.DeepEqual(got, map[string]int64{"100": 3}) {
+		t.Fatalf("bids = %v, want 3 at 100", got)
+	}
+	b.Lock()
+	defer b.Unlock()
+	if b.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1", b.Len())
+	}
+}
+
+func TestMatchPreventsSelfTrade(t *testing.T) {
+	b := NewBook(uuid.New())
+	other := limitOrder(t, domain.OrderSideEnumSell, "100", 5)
+	own := limitOrder(t, domain.OrderSideEnumSell, "101", 5)
+	submit(b, other)
+	submit(b, own)
+
+	// The order only reaches the other user's ask and trades.
+	taker := limitOrder(t, domain.OrderSideEnumBuy, "101", 3)
+	taker.UserID = own.UserID
+	b.Lock()
+	result := b.Match(taker)
+	b.Unlock()
+	if result.SelfTrade {
+		t.Fatal("SelfTrade = true for an order that fills before the own ask")
+	}
+	checkFills(t, result, []fillWant{{maker: other.ID, price: "100", quantity: 3}})
+
+	// Reaching its own ask plans nothing.
+	taker.Quantity = 8
+	b.Lock()
+	result = b.Match(taker)
+	b.Unlock()
+	if !result.SelfTrade || len(result.Fills) != 0 || result.Rests || result.Remaining != 8 {
+		t.Fatalf("Match() = %+v, want a self trade with no fills", result)
+	}
+}
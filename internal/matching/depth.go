@@ -0,0 +1,142 @@
+package matching
+
+import (
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/pkg/decimal"
+)
+
+// subscriberBuffer is how many updates a depth subscriber may fall behind
+// before it is dropped.
+const subscriberBuffer = 256
+
+// Level is the aggregated state of one price level.
+type Level struct {
+	Side     domain.OrderSideEnum
+	Price    decimal.Decimal
+	Quantity int64
+	Orders   int
+}
+
+// Snapshot is the aggregated depth of a book at Sequence.
+type Snapshot struct {
+	Sequence uint64
+	Bids     []Level
+	Asks     []Level
+}
+
+// Update lists the levels that changed in one book mutation. A level with
+// zero quantity was removed. Sequence increases by one per update, so a gap
+// means updates were missed.
+type Update struct {
+	Sequence uint64
+	Levels   []Level
+}
+
+type levelKey struct {
+	side  domain.OrderSideEnum
+	price string
+}
+
+type subscriber struct {
+	ch chan Update
+}
+
+func (s *bookSide) depth(n int) []Level {
+	if n <= 0 || n > len(s.levels) {
+		n = len(s.levels)
+	}
+	levels := make([]Level, 0, n)
+	for _, level := range s.levels[:n] {
+		levels = append(levels, Level{
+			Side:     s.side,
+			Price:    level.price,
+			Quantity: level.quantity(),
+			Orders:   len(level.orders),
+		})
+	}
+	return levels
+}
+
+func (s *bookSide) levelState(price decimal.Decimal) Level {
+	state := Level{Side: s.side, Price: price}
+	if i, ok := s.level(price); ok {
+		state.Quantity = s.levels[i].quantity()
+		state.Orders = len(s.levels[i].orders)
+	}
+	return state
+}
+
+// touch marks a level as changed by the mutation in progress.
+func (b *Book) touch(side domain.OrderSideEnum, price decimal.Decimal) {
+	if b.touched == nil {
+		b.touched = make(map[levelKey]decimal.Decimal)
+	}
+	b.touched[levelKey{side: side, price: price.String()}] = price
+}
+
+// publish sends the levels touched since the last publish to subscribers.
+// A subscriber whose buffer is full is dropped and its channel closed; it
+// resynchronises from a new snapshot.
+func (b *Book) publish() {
+	if len(b.touched) == 0 {
+		return
+	}
+
+	b.seq++
+	update := Update{Sequence: b.seq, Levels: make([]Level, 0, len(b.touched))}
+	for key, price := range b.touched {
+		update.Levels = append(update.Levels, b.sideOf(key.side).levelState(price))
+	}
+	clear(b.touched)
+
+	for sub := range b.subscribers {
+		select {
+		case sub.ch <- update:
+		default:
+			delete(b.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Depth returns the top n levels per side; n <= 0 returns every level. It
+// takes the book lock itself.
+func (b *Book) Depth(n int) Snapshot {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.snapshot(n)
+}
+
+func (b *Book) snapshot(n int) Snapshot {
+	return Snapshot{
+		Sequence: b.seq,
+		Bids:     b.bids.depth(n),
+		Asks:     b.asks.depth(n),
+	}
+}
+
+// Subscribe returns the full depth and a channel of every later update,
+// starting at Sequence+1. The channel is closed when the subscriber is
+// dropped for falling behind or when stop is called. It takes the book lock
+// itself.
+func (b *Book) Subscribe() (Snapshot, <-chan Update, func()) {
+	b.Lock()
+	defer b.Unlock()
+
+	sub := &subscriber{ch: make(chan Update, subscriberBuffer)}
+	if b.subscribers == nil {
+		b.subscribers = make(map[*subscriber]struct{})
+	}
+	b.subscribers[sub] = struct{}{}
+
+	stop := func() {
+		b.Lock()
+		defer b.Unlock()
+		if _, ok := b.subscribers[sub]; ok {
+			delete(b.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+	return b.snapshot(0), sub.ch, stop
+}
@@ -0,0 +1,49 @@
+package matching
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Engine holds one book per market. Books are created on first use and live
+// as long as the engine.
+type Engine struct {
+	mu    sync.RWMutex
+	books map[uuid.UUID]*Book
+}
+
+func NewEngine() *Engine {
+	return &Engine{books: make(map[uuid.UUID]*Book)}
+}
+
+// Book returns the book of marketID, creating it when needed.
+func (e *Engine) Book(marketID uuid.UUID) *Book {
+	e.mu.RLock()
+	book, ok := e.books[marketID]
+	e.mu.RUnlock()
+	if ok {
+		return book
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if book, ok := e.books[marketID]; ok {
+		return book
+	}
+	book = NewBook(marketID)
+	e.books[marketID] = book
+	return book
+}
+
+// Books returns the books created so far.
+func (e *Engine) Books() []*Book {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	books := make([]*Book, 0, len(e.books))
+	for _, book := range e.books {
+		books = append(books, book)
+	}
+	return books
+}
@@ -2,30 +2,48 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"time"
 
 	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/mapper"
 	"github.com/FlyKarlik/orderService/pkg/cache"
 	"github.com/FlyKarlik/orderService/pkg/logger"
+	"github.com/FlyKarlik/orderService/pkg/metric"
+	spotPb "github.com/FlyKarlik/proto/spot_instrument_service/gen/spot_instrument_service/proto"
 	"github.com/go-redis/redis/v8"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
+const marketsCacheName = "markets"
+
+// marketsSchemaVersion is stored in every cached entry. Bump it whenever
+// domain.ViewMarketsResponse or domain.Market change shape so entries written
+// by older releases are refetched instead of decoding into zero values.
+const marketsSchemaVersion uint16 = 1
+
 type redisMarketsCache struct {
-	logger logger.Logger
-	client cache.RedisClient
-	tracer trace.Tracer
+	logger     logger.Logger
+	client     cache.RedisClient
+	serializer *cache.Serializer
+	tracer     trace.Tracer
+	metrics    *metric.Registry
 }
 
-func NewMarketsCache(logger logger.Logger, client cache.RedisClient) *redisMarketsCache {
+func NewMarketsCache(
+	logger logger.Logger,
+	client cache.RedisClient,
+	serializer *cache.Serializer,
+	metrics *metric.Registry,
+) *redisMarketsCache {
 	return &redisMarketsCache{
-		logger: logger,
-		client: client,
-		tracer: otel.Tracer("order-service/cache"),
+		logger:     logger,
+		client:     client,
+		serializer: serializer,
+		tracer:     otel.Tracer("order-service/cache"),
+		metrics:    metrics,
 	}
 }
 
@@ -34,21 +52,21 @@ func (c *redisMarketsCache) Set(ctx context.Context, key string, value domain.Vi
 	ctx, span := c.tracer.Start(ctx, method)
 	defer span.End()
 
-	data, err := json.Marshal(value)
+	data, err := c.marshal(value)
 	if err != nil {
-		c.logger.Error("cache", method, "failed to marshal ViewMarketsResponse", err, "key", key)
+		c.logger.WithContext(ctx).Error("cache", method, "failed to marshal ViewMarketsResponse", err, "key", key)
 		span.RecordError(err)
 		return err
 	}
 
 	err = c.client.Set(ctx, key, data, ttl).Err()
 	if err != nil {
-		c.logger.Error("cache", method, "failed to set cache in Redis", err, "key", key)
+		c.logger.WithContext(ctx).Error("cache", method, "failed to set cache in Redis", err, "key", key)
 		span.RecordError(err)
 		return err
 	}
 
-	c.logger.Debug("cache", method, "cached ViewMarketsResponse successfully", "key", key, "ttl", ttl)
+	c.logger.WithContext(ctx).Debug("cache", method, "cached ViewMarketsResponse successfully", "key", key, "ttl", ttl)
 	span.SetAttributes(attribute.String("cache.key", key), attribute.String("cache.status", "set"))
 	return nil
 }
@@ -61,24 +79,34 @@ func (c *redisMarketsCache) Get(ctx context.Context, key string) (domain.ViewMar
 	val, err := c.client.Get(ctx, key).Result()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
-			c.logger.Debug("cache", method, "cache miss", "key", key)
+			c.logger.WithContext(ctx).Debug("cache", method, "cache miss", "key", key)
 			span.SetAttributes(attribute.String("cache.key", key), attribute.String("cache.status", "miss"))
+			c.metrics.CacheRequest(marketsCacheName, metric.CacheResultMiss)
 			return domain.ViewMarketsResponse{}, nil
 		}
-		c.logger.Error("cache", method, "failed to get from Redis", err, "key", key)
+		c.logger.WithContext(ctx).Error("cache", method, "failed to get from Redis", err, "key", key)
 		span.RecordError(err)
+		c.metrics.CacheRequest(marketsCacheName, metric.CacheResultError)
 		return domain.ViewMarketsResponse{}, err
 	}
 
-	var result domain.ViewMarketsResponse
-	if err := json.Unmarshal([]byte(val), &result); err != nil {
-		c.logger.Error("cache", method, "failed to unmarshal cached data", err, "key", key)
+	result, err := c.unmarshal([]byte(val))
+	if errors.Is(err, cache.ErrVersionMismatch) {
+		c.logger.WithContext(ctx).Warn("cache", method, "cached entry has another version, treating as miss", err, "key", key)
+		span.SetAttributes(attribute.String("cache.key", key), attribute.String("cache.status", "version_mismatch"))
+		c.metrics.CacheRequest(marketsCacheName, metric.CacheResultVersionMismatch)
+		return domain.ViewMarketsResponse{}, nil
+	}
+	if err != nil {
+		c.logger.WithContext(ctx).Error("cache", method, "failed to unmarshal cached data", err, "key", key)
 		span.RecordError(err)
+		c.metrics.CacheRequest(marketsCacheName, metric.CacheResultError)
 		return domain.ViewMarketsResponse{}, err
 	}
 
-	c.logger.Debug("cache", method, "cache hit", "key", key)
+	c.logger.WithContext(ctx).Debug("cache", method, "cache hit", "key", key)
 	span.SetAttributes(attribute.String("cache.key", key), attribute.String("cache.status", "hit"))
+	c.metrics.CacheRequest(marketsCacheName, metric.CacheResultHit)
 	return result, nil
 }
 
@@ -89,12 +117,37 @@ func (c *redisMarketsCache) Delete(ctx context.Context, key string) error {
 
 	err := c.client.Del(ctx, key).Err()
 	if err != nil {
-		c.logger.Error("cache", method, "failed to delete key from Redis", err, "key", key)
+		c.logger.WithContext(ctx).Error("cache", method, "failed to delete key from Redis", err, "key", key)
 		span.RecordError(err)
 		return err
 	}
 
-	c.logger.Debug("cache", method, "deleted key from cache", "key", key)
+	c.logger.WithContext(ctx).Debug("cache", method, "deleted key from cache", "key", key)
 	span.SetAttributes(attribute.String("cache.key", key), attribute.String("cache.status", "deleted"))
 	return nil
 }
+
+// marshal encodes value with the configured codec. The protobuf codec stores
+// the SpotInstrumentService message rather than the domain struct.
+func (c *redisMarketsCache) marshal(value domain.ViewMarketsResponse) ([]byte, error) {
+	if c.serializer.Codec().Name() == cache.CodecProtobuf {
+		return c.serializer.Marshal(marketsSchemaVersion, mapper.ToProtoViewMarketsResponse(value))
+	}
+	return c.serializer.Marshal(marketsSchemaVersion, value)
+}
+
+func (c *redisMarketsCache) unmarshal(data []byte) (domain.ViewMarketsResponse, error) {
+	if c.serializer.Codec().Name() == cache.CodecProtobuf {
+		var pb spotPb.ViewMarketsResponse
+		if err := c.serializer.Unmarshal(data, marketsSchemaVersion, &pb); err != nil {
+			return domain.ViewMarketsResponse{}, err
+		}
+		return mapper.FromProtoViewMarketsResponse(&pb), nil
+	}
+
+	var result domain.ViewMarketsResponse
+	if err := c.serializer.Unmarshal(data, marketsSchemaVersion, &result); err != nil {
+		return domain.ViewMarketsResponse{}, err
+	}
+	return result, nil
+}
@@ -0,0 +1,457 @@
+package in_memory_repo
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/FlyKarlik/orderService/config"
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/errs"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"github.com/FlyKarlik/orderService/pkg/decimal"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type accountKey struct {
+	userID uuid.UUID
+	asset  string
+}
+
+type balance struct {
+	available decimal.Decimal
+	reserved  decimal.Decimal
+}
+
+type marketAssets struct {
+	base  string
+	quote string
+}
+
+// reservation is what an open order holds of its user's balance. Amount is
+// in the asset the order pays with: the quote asset for buys and the base
+// asset for sells.
+type reservation struct {
+	userID uuid.UUID
+	side   domain.OrderSideEnum
+	base   string
+	quote  string
+	amount decimal.Decimal
+}
+
+func (r reservation) pays() accountKey {
+	if r.side == domain.OrderSideEnumSell {
+		return accountKey{userID: r.userID, asset: r.base}
+	}
+	return accountKey{userID: r.userID, asset: r.quote}
+}
+
+func (r reservation) receives() accountKey {
+	if r.side == domain.OrderSideEnumSell {
+		return accountKey{userID: r.userID, asset: r.quote}
+	}
+	return accountKey{userID: r.userID, asset: r.base}
+}
+
+// accountLedger keeps the balances of every user. Balance changes are booked
+// by the same mutation that changes the orders and are written to the same
+// log record, so orders and balances never disagree.
+//
+// Balances are split into stripes by user so that mutations of different
+// users do not wait on each other. The reservations of open orders live in
+// the shard of their order, under the shard lock the mutation already holds.
+// Orders created while accounts were disabled hold no reservation and
+// settle nothing.
+type accountLedger struct {
+	// enabled and markets are set by configure before the repository
+	// serves requests and only read afterwards.
+	enabled bool
+	markets map[uuid.UUID]marketAssets
+	stripes []*accountStripe
+}
+
+// accountStripe holds the balances of the users hashed to it.
+type accountStripe struct {
+	mu       sync.Mutex
+	balances map[accountKey]balance
+}
+
+// newAccountLedger returns an empty ledger with accounts disabled.
+func newAccountLedger(stripeCount int) *accountLedger {
+	stripes := make([]*accountStripe, stripeCount)
+	for i := range stripes {
+		stripes[i] = &accountStripe{balances: make(map[accountKey]balance)}
+	}
+	return &accountLedger{
+		markets: make(map[uuid.UUID]marketAssets),
+		stripes: stripes,
+	}
+}
+
+func (l *accountLedger) stripeFor(userID uuid.UUID) *accountStripe {
+	return l.stripes[binary.BigEndian.Uint64(userID[8:])%uint64(len(l.stripes))]
+}
+
+// lockUsers locks the stripes of userIDs in index order, so concurrent
+// mutations never wait on each other in a cycle, and returns the unlock
+// function. Callers that hold shard locks take them first.
+func (l *accountLedger) lockUsers(userIDs ...uuid.UUID) func() {
+	seen := make(map[*accountStripe]bool, len(userIDs))
+	for _, userID := range userIDs {
+		seen[l.stripeFor(userID)] = true
+	}
+
+	var locked []*accountStripe
+	for _, stripe := range l.stripes {
+		if seen[stripe] {
+			stripe.mu.Lock()
+			locked = append(locked, stripe)
+		}
+	}
+	return func() {
+		for _, stripe := range locked {
+			stripe.mu.Unlock()
+		}
+	}
+}
+
+// lockAll locks every stripe in index order and returns the unlock
+// function.
+func (l *accountLedger) lockAll() func() {
+	for _, stripe := range l.stripes {
+		stripe.mu.Lock()
+	}
+	return func() {
+		for _, stripe := range l.stripes {
+			stripe.mu.Unlock()
+		}
+	}
+}
+
+func (l *accountLedger) configure(cfg config.AccountsConfig) error {
+	markets, err := parseMarketAssets(cfg.MarketAssets)
+	if err != nil {
+		return fmt.Errorf("accounts: %w", err)
+	}
+
+	l.enabled = cfg.Enabled
+	l.markets = markets
+	return nil
+}
+
+// parseMarketAssets parses "market_id:BASE/QUOTE,market_id:BASE/QUOTE".
+func parseMarketAssets(s string) (map[uuid.UUID]marketAssets, error) {
+	markets := make(map[uuid.UUID]marketAssets)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		id, pair, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("market assets %q: want market_id:BASE/QUOTE", entry)
+		}
+		marketID, err := uuid.Parse(strings.TrimSpace(id))
+		if err != nil {
+			return nil, fmt.Errorf("market assets %q: %w", entry, err)
+		}
+		base, quote, ok := strings.Cut(pair, "/")
+		base, quote = domain.NormalizeAsset(base), domain.NormalizeAsset(quote)
+		if !ok || base == "" || quote == "" || base == quote {
+			return nil, fmt.Errorf("market assets %q: want two different assets as BASE/QUOTE", entry)
+		}
+		markets[marketID] = marketAssets{base: base, quote: quote}
+	}
+	return markets, nil
+}
+
+// ledgerTx collects the balance changes of one mutation as deltas. Nothing
+// reaches the ledger until commitBalances, so a mutation that fails leaves
+// balances untouched. Callers hold the shards of every order involved for
+// the life of the transaction; the stripes of the users involved are only
+// needed to check and commit it.
+type ledgerTx struct {
+	ledger   *accountLedger
+	shardFor func(uuid.UUID) *orderShard
+	// deltas are the changes to the balances, applied by commitBalances.
+	deltas       map[accountKey]balance
+	reservations map[uuid.UUID]*reservation
+}
+
+func (r *orderInMemoryRepo) beginLedgerTx() *ledgerTx {
+	return &ledgerTx{
+		ledger:       r.accounts,
+		shardFor:     r.shardFor,
+		deltas:       make(map[accountKey]balance),
+		reservations: make(map[uuid.UUID]*reservation),
+	}
+}
+
+// balance returns the balance of key as the transaction leaves it.
+func (tx *ledgerTx) balance(key accountKey) balance {
+	b := tx.ledger.stripeFor(key.userID).balances[key]
+	delta := tx.deltas[key]
+	return balance{
+		available: b.available.Add(delta.available),
+		reserved:  b.reserved.Add(delta.reserved),
+	}
+}
+
+func (tx *ledgerTx) change(key accountKey, available, reserved decimal.Decimal) {
+	delta := tx.deltas[key]
+	delta.available = delta.available.Add(available)
+	delta.reserved = delta.reserved.Add(reserved)
+	tx.deltas[key] = delta
+}
+
+func (tx *ledgerTx) reservation(orderID uuid.UUID) (reservation, bool) {
+	if res, ok := tx.reservations[orderID]; ok {
+		if res == nil {
+			return reservation{}, false
+		}
+		return *res, true
+	}
+	res, ok := tx.shardFor(orderID).reservations[orderID]
+	return res, ok
+}
+
+// book settles the change of an order from previous, nil for a new order,
+// to order. The reservation moves to what the order still needs, the fills
+// in executions are paid from it and their proceeds credited.
+func (tx *ledgerTx) book(previous *domain.Order, order domain.Order, executions []domain.Execution) error {
+	res, ok := tx.reservation(*order.ID)
+	if !ok {
+		if previous != nil || !tx.ledger.enabled {
+			return nil
+		}
+		assets, ok := tx.ledger.markets[*order.MarketID]
+		if !ok {
+			return errs.ErrMarketAssetsUnknown
+		}
+		res = reservation{
+			userID: *order.UserID,
+			side:   *order.Side,
+			base:   assets.base,
+			quote:  assets.quote,
+		}
+	}
+
+	var paid, received decimal.Decimal
+	for _, execution := range executions {
+		price, err := decimal.Parse(*execution.Price)
+		if err != nil {
+			return fmt.Errorf("execution price: %w", err)
+		}
+		cost := price.MulInt(*execution.Quantity)
+		if res.side == domain.OrderSideEnumSell {
+			paid = paid.Add(decimal.FromInt(*execution.Quantity))
+			received = received.Add(cost)
+		} else {
+			paid = paid.Add(cost)
+			received = received.Add(decimal.FromInt(*execution.Quantity))
+		}
+	}
+
+	target, err := reservationFor(order)
+	if err != nil {
+		return err
+	}
+
+	held := target.Sub(res.amount)
+	tx.change(res.pays(), held.Add(paid).Neg(), held)
+	if !received.IsZero() {
+		tx.change(res.receives(), received, decimal.Zero)
+	}
+
+	if order.Status.IsFinal() {
+		tx.reservations[*order.ID] = nil
+		return nil
+	}
+	res.amount = target
+	tx.reservations[*order.ID] = &res
+	return nil
+}
+
+// reservationFor returns what order must hold to pay for its remaining
+// quantity: the quantity itself for sells and its value at the order price
+// for buys. Final orders hold nothing, and neither do market orders: they
+// never rest, so their fills are paid from the available balance as they
+// execute and an order the balance does not cover is rejected by check.
+func reservationFor(order domain.Order) (decimal.Decimal, error) {
+	if order.Status.IsFinal() || *order.OrderType == domain.OrderTypeEnumMarket {
+		return decimal.Zero, nil
+	}
+
+	_, remaining := fillState(order)
+	if *order.Side == domain.OrderSideEnumSell {
+		return decimal.FromInt(remaining), nil
+	}
+
+	price, err := decimal.Parse(*order.Price)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("order price: %w", err)
+	}
+	return price.MulInt(remaining), nil
+}
+
+// adjust credits amount, or debits it when negative, to the available
+// balance of key.
+func (tx *ledgerTx) adjust(key accountKey, amount decimal.Decimal) {
+	tx.change(key, amount, decimal.Zero)
+}
+
+// check fails the transaction when it would overdraw an account.
+func (tx *ledgerTx) check() error {
+	for key := range tx.deltas {
+		b := tx.balance(key)
+		if b.reserved.Sign() < 0 {
+			return fmt.Errorf("ledger is out of sync: %s reserved of user %s is negative", key.asset, key.userID)
+		}
+		if b.available.Sign() < 0 {
+			return errs.ErrInsufficientBalance
+		}
+	}
+	return nil
+}
+
+// commitBalances applies the balance changes. Callers hold the stripes of
+// the users involved.
+func (tx *ledgerTx) commitBalances() {
+	for key, delta := range tx.deltas {
+		stripe := tx.ledger.stripeFor(key.userID)
+		b := stripe.balances[key]
+		stripe.balances[key] = balance{
+			available: b.available.Add(delta.available),
+			reserved:  b.reserved.Add(delta.reserved),
+		}
+	}
+}
+
+// commitReservations applies the reservation changes. Callers hold the
+// shards of the orders involved.
+func (tx *ledgerTx) commitReservations() {
+	for orderID, res := range tx.reservations {
+		shard := tx.shardFor(orderID)
+		if res == nil {
+			delete(shard.reservations, orderID)
+			continue
+		}
+		shard.reservations[orderID] = *res
+	}
+}
+
+// users returns the users whose balances the transaction changes.
+func (tx *ledgerTx) users() []uuid.UUID {
+	users := make([]uuid.UUID, 0, len(tx.deltas))
+	for key := range tx.deltas {
+		users = append(users, key.userID)
+	}
+	return users
+}
+
+// balancesOf returns the balances of userID ordered by asset. Callers hold
+// the stripe of userID.
+func (l *accountLedger) balancesOf(userID uuid.UUID) []domain.Balance {
+	var balances []domain.Balance
+	for key, b := range l.stripeFor(userID).balances {
+		if key.userID == userID {
+			balances = append(balances, toDomainBalance(key, b))
+		}
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		return *balances[i].Asset < *balances[j].Asset
+	})
+	return balances
+}
+
+func toDomainBalance(key accountKey, b balance) domain.Balance {
+	userID, asset := key.userID, key.asset
+	available, reserved := b.available.String(), b.reserved.String()
+	return domain.Balance{
+		UserID:    &userID,
+		Asset:     &asset,
+		Available: &available,
+		Reserved:  &reserved,
+	}
+}
+
+// GetBalances returns the balances of userID ordered by asset.
+func (r *orderInMemoryRepo) GetBalances(ctx context.Context, userID uuid.UUID) ([]domain.Balance, error) {
+	_, span := r.tracer.Start(ctx, "OrderInMemoryRepo.GetBalances")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("x-request-id", shared_context.XRequestIDFromContext(ctx)),
+		attribute.String("user.id", userID.String()),
+	)
+
+	if !r.accounts.enabled {
+		return nil, errs.ErrAccountsDisabled
+	}
+
+	unlock := r.accounts.lockUsers(userID)
+	defer unlock()
+	return r.accounts.balancesOf(userID), nil
+}
+
+// AdjustBalance credits or debits the available balance of an account. A
+// debit fails with ErrInsufficientBalance when the available balance does
+// not cover it.
+func (r *orderInMemoryRepo) AdjustBalance(ctx context.Context, req domain.AdjustBalanceRequest) (domain.Balance, error) {
+	const layer = "repo"
+	const method = "AdjustBalance"
+
+	ctx, span := r.tracer.Start(ctx, "OrderInMemoryRepo.AdjustBalance")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("user.id", req.UserID.String()),
+		attribute.String("account.asset", *req.Asset),
+	)
+
+	amount, err := decimal.Parse(*req.Amount)
+	if err != nil {
+		span.RecordError(err)
+		return domain.Balance{}, errs.ErrInvalidAmount
+	}
+
+	if !r.accounts.enabled {
+		return domain.Balance{}, errs.ErrAccountsDisabled
+	}
+
+	key := accountKey{userID: *req.UserID, asset: domain.NormalizeAsset(*req.Asset)}
+	tx := r.beginLedgerTx()
+	tx.adjust(key, amount)
+
+	b, err := r.commitLedgerTx(tx, walRecord{})
+	if err != nil {
+		span.RecordError(err)
+		if !errors.Is(err, errs.ErrInsufficientBalance) {
+			r.logger.WithContext(ctx).Error(layer, method, "failed to persist balance adjustment", err,
+				"x_request_id", xRequestID,
+				"user_id", req.UserID.String(),
+				"asset", key.asset,
+			)
+		}
+		return domain.Balance{}, err
+	}
+
+	r.logger.WithContext(ctx).Info(layer, method, "balance adjusted",
+		"x_request_id", xRequestID,
+		"user_id", req.UserID.String(),
+		"asset", key.asset,
+		"amount", amount.String(),
+		"available", b[key].available.String(),
+		"reserved", b[key].reserved.String(),
+	)
+	return toDomainBalance(key, b[key]), nil
+}
@@ -0,0 +1,277 @@
+package in_memory_repo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/FlyKarlik/orderService/config"
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/errs"
+	"github.com/FlyKarlik/orderService/pkg/decimal"
+	"github.com/FlyKarlik/orderService/pkg/logger"
+	"github.com/FlyKarlik/orderService/pkg/metric"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// newAccountsRepo returns a matching repository with accounts enabled for
+// one BTC/USD market.
+func newAccountsRepo(t *testing.T) (*orderInMemoryRepo, uuid.UUID) {
+	t.Helper()
+	marketID := uuid.New()
+	cfg := &config.Config{}
+	cfg.OrderService.LogLevel = "error"
+	cfg.OrderRepository.Shards = 4
+	cfg.OrderRepository.ExecutionMode = config.ExecutionModeMatching
+	cfg.Accounts.Enabled = true
+	cfg.Accounts.MarketAssets = marketID.String() + ":BTC/USD"
+
+	l, err := logger.New(cfg)
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	r, err := SetupOrderRepo(ctx, cfg, l, metric.NewRegistry(prometheus.NewRegistry()))
+	if err != nil {
+		t.Fatalf("SetupOrderRepo() error = %v", err)
+	}
+	return r, marketID
+}
+
+func fund(t *testing.T, r *orderInMemoryRepo, userID uuid.UUID, asset, amount string) {
+	t.Helper()
+	if _, err := r.AdjustBalance(context.Background(), domain.AdjustBalanceRequest{
+		UserID: &userID,
+		Asset:  &asset,
+		Amount: &amount,
+	}); err != nil {
+		t.Fatalf("AdjustBalance() error = %v", err)
+	}
+}
+
+func limitRequest(userID, marketID uuid.UUID, side domain.OrderSideEnum, price string, quantity int64) domain.CreateOrderRequest {
+	orderType := domain.OrderTypeEnumLimit
+	return domain.CreateOrderRequest{
+		UserID:    &userID,
+		MarketID:  &marketID,
+		OrderType: &orderType,
+		Side:      &side,
+		Price:     &price,
+		Quantity:  &quantity,
+		UserRoles: domain.UserRolesEnum{domain.UserRoleEnumTrader},
+	}
+}
+
+// balanceOf returns the available and reserved balance of an asset.
+func balanceOf(t *testing.T, r *orderInMemoryRepo, userID uuid.UUID, asset string) (decimal.Decimal, decimal.Decimal) {
+	t.Helper()
+	balances, err := r.GetBalances(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("GetBalances() error = %v", err)
+	}
+	for _, b := range balances {
+		if *b.Asset == asset {
+			return decimalOf(t, *b.Available), decimalOf(t, *b.Reserved)
+		}
+	}
+	return decimalOf(t, "0"), decimalOf(t, "0")
+}
+
+func checkBalance(t *testing.T, r *orderInMemoryRepo, userID uuid.UUID, asset, available, reserved string) {
+	t.Helper()
+	gotAvailable, gotReserved := balanceOf(t, r, userID, asset)
+	if gotAvailable.Cmp(decimalOf(t, available)) != 0 || gotReserved.Cmp(decimalOf(t, reserved)) != 0 {
+		t.Fatalf("%s of user %s = %s available %s reserved, want %s and %s",
+			asset, userID, gotAvailable, gotReserved, available, reserved)
+	}
+}
+
+func decimalOf(t *testing.T, s string) decimal.Decimal {
+	t.Helper()
+	d, err := decimal.Parse(s)
+	if err != nil {
+		t.Fatalf("decimal.Parse(%q) error = %v", s, err)
+	}
+	return d
+}
+
+// concurrently runs fn n times at once and returns how many calls
+// succeeded and how many failed with ErrInsufficientBalance. Any other
+// error fails the test.
+func concurrently(t *testing.T, n int, fn func(i int) error) (int, int) {
+	t.Helper()
+	var mu sync.Mutex
+	var ok, unfunded int
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := fn(i)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				ok++
+			case errors.Is(err, errs.ErrInsufficientBalance):
+				unfunded++
+			default:
+				t.Errorf("call %d error = %v", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return ok, unfunded
+}
+
+func TestLedgerReservesOnCreateAndReleasesOnCancel(t *testing.T) {
+	r, marketID := newAccountsRepo(t)
+	userID := uuid.New()
+	fund(t, r, userID, "USD", "1000")
+
+	// Twenty buys of 100 race for 1000: exactly ten are funded.
+	ok, unfunded := concurrently(t, 20, func(int) error {
+		_, err := r.CreateOrder(context.Background(), limitRequest(userID, marketID, domain.OrderSideEnumBuy, "10", 10))
+		return err
+	})
+	if ok != 10 || unfunded != 10 {
+		t.Fatalf("created %d and rejected %d as unfunded, want 10 and 10", ok, unfunded)
+	}
+	checkBalance(t, r, userID, "USD", "0", "1000")
+
+	// Overlapping mass cancels release every reservation exactly once.
+	var mu sync.Mutex
+	cancelled := 0
+	concurrently(t, 4, func(int) error {
+		resp, err := r.CancelOrders(context.Background(), domain.OrderFilter{UserID: &userID}, domain.CancelReasonEnumUser)
+		mu.Lock()
+		cancelled += len(resp.Cancelled)
+		mu.Unlock()
+		return err
+	})
+	if cancelled != 10 {
+		t.Fatalf("cancelled %d orders, want 10", cancelled)
+	}
+	checkBalance(t, r, userID, "USD", "1000", "0")
+}
+
+func TestLedgerSettlesFills(t *testing.T) {
+	r, marketID := newAccountsRepo(t)
+	buyer, seller := uuid.New(), uuid.New()
+	fund(t, r, buyer, "USD", "1000")
+	fund(t, r, seller, "BTC", "100")
+
+	// Every bid crosses every ask, so whichever arrives second trades at the
+	// price of the first and the book ends empty.
+	ok, unfunded := concurrently(t, 10, func(i int) error {
+		req := limitRequest(buyer, marketID, domain.OrderSideEnumBuy, "12", 2)
+		if i%2 == 1 {
+			req = limitRequest(seller, marketID, domain.OrderSideEnumSell, "10", 2)
+		}
+		_, err := r.CreateOrder(context.Background(), req)
+		return err
+	})
+	if ok != 10 || unfunded != 0 {
+		t.Fatalf("created %d and rejected %d as unfunded, want 10 and 0", ok, unfunded)
+	}
+
+	checkBalance(t, r, buyer, "BTC", "10", "0")
+	checkBalance(t, r, seller, "BTC", "90", "0")
+
+	buyerUSD, buyerReserved := balanceOf(t, r, buyer, "USD")
+	sellerUSD, _ := balanceOf(t, r, seller, "USD")
+	if !buyerReserved.IsZero() {
+		t.Fatalf("buyer has %s USD reserved after every bid filled, want 0", buyerReserved)
+	}
+	paid := decimalOf(t, "1000").Sub(buyerUSD)
+	received := sellerUSD
+	if paid.Cmp(received) != 0 {
+		t.Fatalf("buyer paid %s and seller received %s, want equal", paid, received)
+	}
+	if paid.Cmp(decimalOf(t, "100")) < 0 || paid.Cmp(decimalOf(t, "120")) > 0 {
+		t.Fatalf("buyer paid %s for 10 BTC, want between 100 and 120", paid)
+	}
+}
+
+func TestLedgerReReservesOnReplace(t *testing.T) {
+	r, marketID := newAccountsRepo(t)
+	userID := uuid.New()
+	fund(t, r, userID, "USD", "1000")
+
+	ids := make([]uuid.UUID, 4)
+	for i := range ids {
+		resp, err := r.CreateOrder(context.Background(), limitRequest(userID, marketID, domain.OrderSideEnumBuy, "10", 10))
+		if err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+		ids[i] = *resp.OrderID
+	}
+	checkBalance(t, r, userID, "USD", "600", "400")
+
+	replace := func(price string) func(i int) error {
+		return func(i int) error {
+			_, err := r.ReplaceOrder(context.Background(), domain.ReplaceOrderRequest{
+				OrderID: &ids[i],
+				UserID:  &userID,
+				Price:   &price,
+			})
+			return err
+		}
+	}
+
+	// Raising every price to 20 needs 100 more each, which 600 covers.
+	if ok, unfunded := concurrently(t, len(ids), replace("20")); ok != 4 || unfunded != 0 {
+		t.Fatalf("replaced %d and rejected %d as unfunded, want 4 and 0", ok, unfunded)
+	}
+	checkBalance(t, r, userID, "USD", "200", "800")
+
+	// Raising them to 30 needs 100 more each again, which only covers two.
+	if ok, unfunded := concurrently(t, len(ids), replace("30")); ok != 2 || unfunded != 2 {
+		t.Fatalf("replaced %d and rejected %d as unfunded, want 2 and 2", ok, unfunded)
+	}
+	checkBalance(t, r, userID, "USD", "0", "1000")
+
+	// Lowering the quantity releases the difference.
+	quantity := int64(5)
+	if _, err := r.ReplaceOrder(context.Background(), domain.ReplaceOrderRequest{
+		OrderID:  &ids[0],
+		UserID:   &userID,
+		Quantity: &quantity,
+	}); err != nil {
+		t.Fatalf("ReplaceOrder() error = %v", err)
+	}
+	order, err := r.GetOrderByID(context.Background(), ids[0])
+	if err != nil {
+		t.Fatalf("GetOrderByID() error = %v", err)
+	}
+	released := decimalOf(t, *order.Price).MulInt(5)
+	checkBalance(t, r, userID, "USD", released.String(), decimalOf(t, "1000").Sub(released).String())
+}
+
+func TestLedgerRejectsUnfundedOrders(t *testing.T) {
+	r, marketID := newAccountsRepo(t)
+	userID := uuid.New()
+	fund(t, r, userID, "USD", "99")
+	fund(t, r, userID, "BTC", "5")
+
+	cases := []struct {
+		name string
+		req  domain.CreateOrderRequest
+	}{
+		{name: "buy above the quote balance", req: limitRequest(userID, marketID, domain.OrderSideEnumBuy, "10", 10)},
+		{name: "sell above the base balance", req: limitRequest(userID, marketID, domain.OrderSideEnumSell, "10", 6)},
+		{name: "unfunded user", req: limitRequest(uuid.New(), marketID, domain.OrderSideEnumBuy, "1", 1)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := r.CreateOrder(context.Background(), tc.req); !errors.Is(err, errs.ErrInsufficientBalance) {
+				t.Fatalf("CreateOrder() error = %v, want %v", err, errs.ErrInsufficientBalance)
+			}
+		})
+	}
+	checkBalance(t, r, userID, "USD", "99", "0")
+	checkBalance(t, r, userID, "BTC", "5", "0")
+}
@@ -0,0 +1,153 @@
+package in_memory_repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/matching"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// openOrdersByMarket returns the IDs of the open orders matching filter,
+// grouped by market.
+func (r *orderInMemoryRepo) openOrdersByMarket(filter domain.OrderFilter) map[uuid.UUID][]uuid.UUID {
+	byMarket := make(map[uuid.UUID][]uuid.UUID)
+	for _, shard := range r.shards {
+		shard.mu.RLock()
+		for id, order := range shard.data {
+			if order.Status.IsFinal() || !filter.Matches(order) {
+				continue
+			}
+			byMarket[*order.MarketID] = append(byMarket[*order.MarketID], id)
+		}
+		shard.mu.RUnlock()
+	}
+	return byMarket
+}
+
+// CancelOrders cancels every open order matching filter and records reason
+// in the history of each. Orders are cancelled one market at a time: the
+// market's book and the shards of its orders are locked once, and the book
+// publishes a single depth update for the whole batch.
+func (r *orderInMemoryRepo) CancelOrders(
+	ctx context.Context,
+	filter domain.OrderFilter,
+	reason domain.CancelReasonEnum,
+) (domain.CancelAllOrdersResponse, error) {
+	const layer = "repo"
+	const method = "CancelOrders"
+
+	ctx, span := r.tracer.Start(ctx, "OrderInMemoryRepo.CancelOrders")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("cancel.reason", reason.String()),
+	)
+	if filter.UserID != nil {
+		span.SetAttributes(attribute.String("user.id", filter.UserID.String()))
+	}
+	if filter.MarketID != nil {
+		span.SetAttributes(attribute.String("market.id", filter.MarketID.String()))
+	}
+
+	resp := domain.CancelAllOrdersResponse{}
+	for marketID, ids := range r.openOrdersByMarket(filter) {
+		cancelled, failed := r.cancelMarketOrders(ctx, marketID, ids, reason)
+		for _, order := range cancelled {
+			resp.Cancelled = append(resp.Cancelled, *order.ID)
+			r.notifier.publish(order)
+		}
+		resp.Failed = append(resp.Failed, failed...)
+	}
+
+	span.SetAttributes(
+		attribute.Int("orders.cancelled", len(resp.Cancelled)),
+		attribute.Int("orders.failed", len(resp.Failed)),
+	)
+
+	r.logger.WithContext(ctx).Info(layer, method, "orders cancelled",
+		"x_request_id", xRequestID,
+		"user_id", filter.UserID,
+		"market_id", filter.MarketID,
+		"reason", reason,
+		"cancelled", len(resp.Cancelled),
+		"failed", len(resp.Failed),
+	)
+
+	return resp, nil
+}
+
+// cancelMarketOrders cancels the orders ids of one market. An order that
+// closed since it was selected is skipped; one whose cancellation could not
+// be persisted is reported as failed and stays open.
+func (r *orderInMemoryRepo) cancelMarketOrders(
+	ctx context.Context,
+	marketID uuid.UUID,
+	ids []uuid.UUID,
+	reason domain.CancelReasonEnum,
+) ([]domain.Order, []uuid.UUID) {
+	const layer = "repo"
+	const method = "cancelMarketOrders"
+
+	// The book lock is taken before the shard locks, as in submit.
+	var book *matching.Book
+	if r.engine != nil {
+		book = r.engine.Book(marketID)
+		book.Lock()
+		defer book.Unlock()
+	}
+
+	unlock := r.lockShards(ids...)
+	defer unlock()
+
+	var cancelled []domain.Order
+	var cancelledIDs, failed []uuid.UUID
+	now := time.Now()
+	action := domain.OrderHistoryActionEnumCancel
+	for _, id := range ids {
+		shard := r.shardFor(id)
+		order, ok := shard.data[id]
+		if !ok || order.Status.IsFinal() {
+			continue
+		}
+
+		entry := domain.OrderAmendment{
+			OrderID:          order.ID,
+			Action:           &action,
+			PreviousPrice:    order.Price,
+			Price:            order.Price,
+			PreviousQuantity: order.Quantity,
+			Quantity:         order.Quantity,
+			CancelReason:     &reason,
+			AmendedAt:        &now,
+		}
+
+		status := domain.OrderStatusEnumCancelled
+		order.Status = &status
+		order.UpdatedAt = &now
+		if err := r.persistMatch(order, nil, nil, &entry); err != nil {
+			r.logger.WithContext(ctx).Error(layer, method, "failed to persist order cancellation", err,
+				"order_id", id.String(),
+			)
+			failed = append(failed, id)
+			continue
+		}
+		shard.apply(order, nil)
+		shard.amend(entry)
+
+		cancelled = append(cancelled, order)
+		cancelledIDs = append(cancelledIDs, id)
+	}
+
+	if book != nil && len(cancelledIDs) > 0 {
+		book.RemoveAll(cancelledIDs)
+	}
+
+	return cancelled, failed
+}
@@ -0,0 +1,138 @@
+package in_memory_repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/pkg/decimal"
+	"github.com/google/uuid"
+)
+
+// avgPriceScale is the number of decimal places kept in AvgFillPrice.
+const avgPriceScale = 8
+
+// applyFill records a fill of quantity at price on order and returns the
+// execution. The order ends FILLED when nothing remains and PARTIALLY_FILLED
+// otherwise.
+func applyFill(order *domain.Order, price string, quantity int64, at time.Time) (domain.Execution, error) {
+	filled, remaining := fillState(*order)
+	if quantity <= 0 || quantity > remaining {
+		return domain.Execution{}, fmt.Errorf("fill quantity %d out of range (remaining %d)", quantity, remaining)
+	}
+
+	avg, err := averagePrice(order.AvgFillPrice, filled, price, quantity)
+	if err != nil {
+		return domain.Execution{}, err
+	}
+
+	filled += quantity
+	remaining -= quantity
+	status := domain.OrderStatusEnumPartiallyFilled
+	if remaining == 0 {
+		status = domain.OrderStatusEnumFilled
+	}
+
+	order.FilledQuantity = &filled
+	order.RemainingQuantity = &remaining
+	order.AvgFillPrice = &avg
+	order.Status = &status
+	order.UpdatedAt = &at
+
+	executionID := uuid.New()
+	return domain.Execution{
+		ID:         &executionID,
+		OrderID:    order.ID,
+		Price:      &price,
+		Quantity:   &quantity,
+		ExecutedAt: &at,
+	}, nil
+}
+
+// fillState returns the filled and remaining quantity of order.
+func fillState(order domain.Order) (int64, int64) {
+	var filled int64
+	if order.FilledQuantity != nil {
+		filled = *order.FilledQuantity
+	}
+	if order.RemainingQuantity != nil {
+		return filled, *order.RemainingQuantity
+	}
+	if order.Quantity == nil {
+		return filled, 0
+	}
+	return filled, *order.Quantity - filled
+}
+
+// averagePrice folds a fill into the volume weighted average of the previous
+// fills.
+func averagePrice(prevAvg *string, prevQuantity int64, price string, quantity int64) (string, error) {
+	p, err := decimal.Parse(price)
+	if err != nil {
+		return "", fmt.Errorf("fill price: %w", err)
+	}
+
+	notional := p.MulInt(quantity)
+	if prevAvg != nil && prevQuantity > 0 {
+		prev, err := decimal.Parse(*prevAvg)
+		if err != nil {
+			return "", fmt.Errorf("average fill price: %w", err)
+		}
+		notional = notional.Add(prev.MulInt(prevQuantity))
+	}
+
+	return notional.DivInt(prevQuantity + quantity).StringFixed(avgPriceScale), nil
+}
+
+// executeImmediate is the execution stub for IOC and FOK orders. An IOC
+// order fills whatever is available on arrival and the rest expires; a FOK
+// order fills completely or expires untouched.
+func executeImmediate(order *domain.Order, at time.Time) ([]domain.Execution, error) {
+	quantity := *order.Quantity
+	if *order.TimeInForce == domain.TimeInForceEnumFOK {
+		if rand.Intn(2) == 0 {
+			quantity = 0
+		}
+	} else {
+		quantity = rand.Int63n(quantity + 1)
+	}
+
+	var executions []domain.Execution
+	if quantity > 0 {
+		execution, err := applyFill(order, *order.Price, quantity, at)
+		if err != nil {
+			return nil, err
+		}
+		executions = append(executions, execution)
+	}
+
+	if *order.Status != domain.OrderStatusEnumFilled {
+		expired := domain.OrderStatusEnumExpired
+		order.Status = &expired
+		order.UpdatedAt = &at
+	}
+	return executions, nil
+}
+
+// ListExecutions returns the fills of an order, oldest first.
+func (r *orderInMemoryRepo) ListExecutions(ctx context.Context, orderID uuid.UUID) ([]domain.Execution, error) {
+	_, span := r.tracer.Start(ctx, "OrderInMemoryRepo.ListExecutions")
+	defer span.End()
+
+	shard := r.shardFor(orderID)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	if _, ok := shard.data[orderID]; !ok {
+		err := errors.New("order not found")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	executions := make([]domain.Execution, len(shard.executions[orderID]))
+	copy(executions, shard.executions[orderID])
+	return executions, nil
+}
@@ -0,0 +1,196 @@
+package in_memory_repo
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/matching"
+	"github.com/google/uuid"
+)
+
+type expiryItem struct {
+	orderID   uuid.UUID
+	expiresAt time.Time
+}
+
+// expiryQueue is a min-heap of deadlines, earliest first.
+type expiryQueue []expiryItem
+
+func (q expiryQueue) Len() int           { return len(q) }
+func (q expiryQueue) Less(i, j int) bool { return q[i].expiresAt.Before(q[j].expiresAt) }
+func (q expiryQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *expiryQueue) Push(x any)        { *q = append(*q, x.(expiryItem)) }
+func (q *expiryQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// expiryScheduler fires once per scheduled deadline. A single timer is armed
+// for the earliest deadline, so the cost of an expiry does not depend on the
+// number of orders in the store.
+//
+// Entries are never removed when an order is filled or rejected early; the
+// callback re-checks the order and ignores entries that no longer apply.
+type expiryScheduler struct {
+	mu    sync.Mutex
+	queue expiryQueue
+	wake  chan struct{}
+}
+
+func newExpiryScheduler() *expiryScheduler {
+	return &expiryScheduler{wake: make(chan struct{}, 1)}
+}
+
+func (s *expiryScheduler) schedule(orderID uuid.UUID, expiresAt time.Time) {
+	s.mu.Lock()
+	heap.Push(&s.queue, expiryItem{orderID: orderID, expiresAt: expiresAt})
+	earliest := s.queue[0].orderID == orderID && s.queue[0].expiresAt.Equal(expiresAt)
+	s.mu.Unlock()
+
+	if earliest {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// due pops every item whose deadline is not after now and returns the delay
+// until the next one, or a negative delay when the queue is empty.
+func (s *expiryScheduler) due(now time.Time) ([]expiryItem, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []expiryItem
+	for len(s.queue) > 0 && !s.queue[0].expiresAt.After(now) {
+		items = append(items, heap.Pop(&s.queue).(expiryItem))
+	}
+	if len(s.queue) == 0 {
+		return items, -1
+	}
+	return items, s.queue[0].expiresAt.Sub(now)
+}
+
+// run calls expire for every deadline as it passes until ctx is cancelled.
+func (s *expiryScheduler) run(ctx context.Context, expire func(ctx context.Context, item expiryItem)) {
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		items, next := s.due(time.Now())
+		for _, item := range items {
+			expire(ctx, item)
+		}
+
+		if next >= 0 {
+			timer.Reset(next)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		case <-timer.C:
+		}
+	}
+}
+
+// startExpiryScheduler schedules every open GTD order already in the store
+// and starts expiring them.
+func (r *orderInMemoryRepo) startExpiryScheduler(ctx context.Context) {
+	unlock := r.lockAll()
+	for _, shard := range r.shards {
+		for id, order := range shard.data {
+			if deadline, ok := expiryDeadline(order); ok {
+				r.expiry.schedule(id, deadline)
+			}
+		}
+	}
+	unlock()
+
+	go r.expiry.run(ctx, r.expireOrder)
+}
+
+// expiryDeadline returns the deadline of an open GTD order.
+func expiryDeadline(order domain.Order) (time.Time, bool) {
+	if order.Status == nil || order.Status.IsFinal() {
+		return time.Time{}, false
+	}
+	if order.TimeInForce == nil || *order.TimeInForce != domain.TimeInForceEnumGTD || order.ExpiresAt == nil {
+		return time.Time{}, false
+	}
+	return *order.ExpiresAt, true
+}
+
+func (r *orderInMemoryRepo) expireOrder(ctx context.Context, item expiryItem) {
+	const layer = "repo"
+	const method = "expireOrder"
+
+	shard := r.shardFor(item.orderID)
+
+	// A resting order leaves its book in the same step it expires. The book
+	// lock is taken before the shard lock, as in submit.
+	var book *matching.Book
+	if r.engine != nil {
+		shard.mu.RLock()
+		order, ok := shard.data[item.orderID]
+		shard.mu.RUnlock()
+		if !ok {
+			return
+		}
+		book = r.engine.Book(*order.MarketID)
+		book.Lock()
+		defer book.Unlock()
+	}
+
+	shard.mu.Lock()
+	order, ok := shard.data[item.orderID]
+	if !ok {
+		shard.mu.Unlock()
+		return
+	}
+	if deadline, open := expiryDeadline(order); !open || deadline.After(item.expiresAt) {
+		shard.mu.Unlock()
+		return
+	}
+
+	status := domain.OrderStatusEnumExpired
+	updatedAt := time.Now()
+	order.Status = &status
+	order.UpdatedAt = &updatedAt
+	if err := r.persist(order); err != nil {
+		shard.mu.Unlock()
+		r.logger.WithContext(ctx).Error(layer, method, "failed to persist order expiry", err,
+			"order_id", item.orderID.String(),
+		)
+		// Retry shortly rather than leaving the order open past its deadline.
+		r.expiry.schedule(item.orderID, updatedAt.Add(time.Second))
+		return
+	}
+	shard.apply(order, nil)
+	shard.mu.Unlock()
+	if book != nil {
+		book.Remove(item.orderID)
+	}
+
+	r.metrics.OrderExpired(domain.TimeInForceEnumGTD.String())
+	r.notifier.publish(order)
+
+	r.logger.WithContext(ctx).Info(layer, method, "order expired",
+		"order_id", item.orderID.String(),
+		"expires_at", *order.ExpiresAt,
+		"lag", updatedAt.Sub(*order.ExpiresAt),
+	)
+}
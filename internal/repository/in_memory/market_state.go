@@ -0,0 +1,83 @@
+package in_memory_repo
+
+import (
+	"context"
+	"sync"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// marketStateStore keeps the market states set by operators.
+type marketStateStore struct {
+	mu        sync.RWMutex
+	overrides map[uuid.UUID]domain.MarketStateOverride
+}
+
+func newMarketStateStore() *marketStateStore {
+	return &marketStateStore{
+		overrides: make(map[uuid.UUID]domain.MarketStateOverride),
+	}
+}
+
+// set stores override, or removes the override of its market when it has
+// no state. Callers hold mu.
+func (s *marketStateStore) set(override domain.MarketStateOverride) {
+	if override.State == nil {
+		delete(s.overrides, *override.MarketID)
+		return
+	}
+	s.overrides[*override.MarketID] = override
+}
+
+// GetMarketStateOverride returns the state an operator set for marketID.
+func (r *orderInMemoryRepo) GetMarketStateOverride(ctx context.Context, marketID uuid.UUID) (domain.MarketStateOverride, bool, error) {
+	_, span := r.tracer.Start(ctx, "OrderInMemoryRepo.GetMarketStateOverride")
+	defer span.End()
+
+	r.marketStates.mu.RLock()
+	override, ok := r.marketStates.overrides[marketID]
+	r.marketStates.mu.RUnlock()
+
+	span.SetAttributes(
+		attribute.String("market.id", marketID.String()),
+		attribute.Bool("market.state_overridden", ok),
+	)
+	return override, ok, nil
+}
+
+// SetMarketStateOverride stores the state an operator set for a market.
+// An override without a state clears the previous one.
+func (r *orderInMemoryRepo) SetMarketStateOverride(ctx context.Context, override domain.MarketStateOverride) error {
+	const layer = "repo"
+	const method = "SetMarketStateOverride"
+
+	ctx, span := r.tracer.Start(ctx, "OrderInMemoryRepo.SetMarketStateOverride")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("market.id", override.MarketID.String()),
+	)
+
+	r.marketStates.mu.Lock()
+	defer r.marketStates.mu.Unlock()
+
+	if r.persistence != nil {
+		persisted := toPersistedMarketState(override)
+		if err := r.appendWALRecord(walRecord{MarketState: &persisted}); err != nil {
+			span.RecordError(err)
+			r.logger.WithContext(ctx).Error(layer, method, "failed to persist market state", err,
+				"x_request_id", xRequestID,
+				"market_id", override.MarketID.String(),
+			)
+			return err
+		}
+	}
+	r.marketStates.set(override)
+	return nil
+}
@@ -0,0 +1,208 @@
+package in_memory_repo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/errs"
+	"github.com/FlyKarlik/orderService/internal/matching"
+	"github.com/FlyKarlik/orderService/pkg/decimal"
+	"github.com/google/uuid"
+)
+
+// matchOutcome is what storing an incoming order through the matching
+// engine changed.
+type matchOutcome struct {
+	order          domain.Order
+	counterparties []domain.Order
+	executions     []domain.Execution
+}
+
+// lockShards write-locks the shards of ids in index order and returns the
+// unlock function. The fixed order keeps it deadlock free against other
+// multi-shard callers.
+func (r *orderInMemoryRepo) lockShards(ids ...uuid.UUID) func() {
+	seen := make(map[*orderShard]bool, len(ids))
+	for _, id := range ids {
+		seen[r.shardFor(id)] = true
+	}
+
+	var locked []*orderShard
+	for _, shard := range r.shards {
+		if seen[shard] {
+			shard.mu.Lock()
+			locked = append(locked, shard)
+		}
+	}
+	return func() {
+		for _, shard := range locked {
+			shard.mu.Unlock()
+		}
+	}
+}
+
+// toBookOrder converts an open order into its book form.
+func toBookOrder(order domain.Order) (matching.Order, error) {
+	price := decimal.Zero
+	if *order.OrderType == domain.OrderTypeEnumLimit {
+		var err error
+		if price, err = decimal.Parse(*order.Price); err != nil {
+			return matching.Order{}, fmt.Errorf("order price: %w", err)
+		}
+	}
+
+	_, remaining := fillState(order)
+	return matching.Order{
+		ID:          *order.ID,
+		UserID:      *order.UserID,
+		Side:        *order.Side,
+		Type:        *order.OrderType,
+		TimeInForce: *order.TimeInForce,
+		Price:       price,
+		Quantity:    remaining,
+	}, nil
+}
+
+// submit matches a new order against the book of its market and stores the
+// order, the makers it traded with and the executions of both sides in one
+// mutation. The book stays locked until the mutation is durable and
+// applied, so the book and the store never disagree.
+func (r *orderInMemoryRepo) submit(order domain.Order) (matchOutcome, error) {
+	book := r.engine.Book(*order.MarketID)
+	book.Lock()
+	defer book.Unlock()
+
+	return r.execute(book, order, nil)
+}
+
+// execute matches order against book and stores the result. With an
+// amendment, order is the amended form of an order resting on book, which
+// is replaced rather than added. The caller holds the book lock.
+func (r *orderInMemoryRepo) execute(book *matching.Book, order domain.Order, amendment *domain.OrderAmendment) (matchOutcome, error) {
+	incoming, err := toBookOrder(order)
+	if err != nil {
+		return matchOutcome{}, err
+	}
+
+	result := book.Match(incoming)
+	if result.SelfTrade {
+		return matchOutcome{}, errs.ErrSelfTrade
+	}
+
+	ids := []uuid.UUID{*order.ID}
+	for _, fill := range result.Fills {
+		ids = append(ids, fill.MakerID)
+	}
+	unlock := r.lockShards(ids...)
+	defer unlock()
+
+	now := time.Now()
+	outcome := matchOutcome{}
+	for _, fill := range result.Fills {
+		maker, ok := r.shardFor(fill.MakerID).data[fill.MakerID]
+		if !ok || maker.Status.IsFinal() {
+			return matchOutcome{}, fmt.Errorf("book of market %s is out of sync: maker %s is not open", order.MarketID, fill.MakerID)
+		}
+
+		price := fill.Price.String()
+		takerExecution, err := applyFill(&order, price, fill.Quantity, now)
+		if err != nil {
+			return matchOutcome{}, err
+		}
+		makerExecution, err := applyFill(&maker, price, fill.Quantity, now)
+		if err != nil {
+			return matchOutcome{}, err
+		}
+
+		outcome.counterparties = append(outcome.counterparties, maker)
+		outcome.executions = append(outcome.executions, takerExecution, makerExecution)
+	}
+
+	switch {
+	case result.Remaining > 0 && !result.Rests:
+		expired := domain.OrderStatusEnumExpired
+		order.Status = &expired
+		order.UpdatedAt = &now
+	case len(result.Fills) == 0 && *order.Status == domain.OrderStatusEnumCreated:
+		pending := domain.OrderStatusEnumPending
+		order.Status = &pending
+	}
+	outcome.order = order
+
+	if err := r.persistMatch(order, outcome.counterparties, outcome.executions, amendment); err != nil {
+		return matchOutcome{}, err
+	}
+
+	takerShard := r.shardFor(*order.ID)
+	takerShard.apply(order, executionsOf(outcome.executions, *order.ID))
+	if amendment != nil {
+		takerShard.amend(*amendment)
+	}
+	for _, maker := range outcome.counterparties {
+		r.shardFor(*maker.ID).apply(maker, executionsOf(outcome.executions, *maker.ID))
+	}
+
+	if amendment != nil {
+		book.Replace(incoming, result)
+	} else {
+		book.Commit(incoming, result)
+	}
+
+	return outcome, nil
+}
+
+func executionsOf(executions []domain.Execution, orderID uuid.UUID) []domain.Execution {
+	var out []domain.Execution
+	for _, execution := range executions {
+		if *execution.OrderID == orderID {
+			out = append(out, execution)
+		}
+	}
+	return out
+}
+
+// rebuildBooks puts the open limit orders of a restored store back on their
+// books, oldest first so time priority survives the restart.
+func (r *orderInMemoryRepo) rebuildBooks(ctx context.Context) error {
+	const layer = "repo"
+	const method = "rebuildBooks"
+
+	var open []domain.Order
+	unlock := r.lockAll()
+	for _, shard := range r.shards {
+		for _, order := range shard.data {
+			if order.Status.IsFinal() || *order.OrderType != domain.OrderTypeEnumLimit || order.TimeInForce.IsImmediate() {
+				continue
+			}
+			open = append(open, order)
+		}
+	}
+	unlock()
+
+	sort.Slice(open, func(i, j int) bool {
+		if !open[i].CreatedAt.Equal(*open[j].CreatedAt) {
+			return open[i].CreatedAt.Before(*open[j].CreatedAt)
+		}
+		return open[i].ID.String() < open[j].ID.String()
+	})
+
+	for _, order := range open {
+		bookOrder, err := toBookOrder(order)
+		if err != nil {
+			return fmt.Errorf("rebuild book for order %s: %w", order.ID, err)
+		}
+		book := r.engine.Book(*order.MarketID)
+		book.Lock()
+		book.Rest(bookOrder)
+		book.Unlock()
+	}
+
+	r.logger.WithContext(ctx).Info(layer, method, "order books rebuilt",
+		"resting_orders", len(open),
+		"books", len(r.engine.Books()),
+	)
+	return nil
+}
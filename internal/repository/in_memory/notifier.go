@@ -0,0 +1,72 @@
+package in_memory_repo
+
+import (
+	"context"
+	"sync"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/google/uuid"
+)
+
+// orderNotifier fans order transitions out to the watchers of that order.
+// Sends never block the writer: a watcher that is not keeping up misses
+// intermediate updates and is expected to re-read the order.
+type orderNotifier struct {
+	mu       sync.Mutex
+	watchers map[uuid.UUID]map[chan domain.Order]struct{}
+}
+
+func newOrderNotifier() *orderNotifier {
+	return &orderNotifier{watchers: make(map[uuid.UUID]map[chan domain.Order]struct{})}
+}
+
+func (n *orderNotifier) watch(orderID uuid.UUID) (chan domain.Order, func()) {
+	ch := make(chan domain.Order, 1)
+
+	n.mu.Lock()
+	if n.watchers[orderID] == nil {
+		n.watchers[orderID] = make(map[chan domain.Order]struct{})
+	}
+	n.watchers[orderID][ch] = struct{}{}
+	n.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			n.mu.Lock()
+			delete(n.watchers[orderID], ch)
+			if len(n.watchers[orderID]) == 0 {
+				delete(n.watchers, orderID)
+			}
+			n.mu.Unlock()
+		})
+	}
+}
+
+func (n *orderNotifier) publish(order domain.Order) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for ch := range n.watchers[*order.ID] {
+		// Keep only the latest state in the buffer.
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- order:
+		default:
+		}
+	}
+}
+
+// WatchOrder returns a channel receiving the order after each transition
+// and a function that stops the watch. The watch also stops when ctx is done.
+func (r *orderInMemoryRepo) WatchOrder(ctx context.Context, ID uuid.UUID) (<-chan domain.Order, func()) {
+	ch, stop := r.notifier.watch(ID)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ch, stop
+}
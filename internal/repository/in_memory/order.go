@@ -2,39 +2,175 @@ package in_memory_repo
 
 import (
 	"context"
+	"encoding/binary"
 	"errors"
 	"math/rand"
 	"sync"
 	"time"
 
+	"github.com/FlyKarlik/orderService/config"
 	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/errs"
+	"github.com/FlyKarlik/orderService/internal/matching"
 	shared_context "github.com/FlyKarlik/orderService/pkg/context"
 	"github.com/FlyKarlik/orderService/pkg/logger"
+	"github.com/FlyKarlik/orderService/pkg/metric"
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
+// orderShard is one lock stripe of the store. Orders are assigned to shards
+// by ID, so operations on different orders rarely contend on the same lock.
+type orderShard struct {
+	mu         sync.RWMutex
+	data       map[uuid.UUID]domain.Order
+	executions map[uuid.UUID][]domain.Execution
+	amendments map[uuid.UUID][]domain.OrderAmendment
+	// open counts the open orders of the shard per user and market.
+	open map[userMarket]int
+	// reservations are what the open orders of the shard hold of their
+	// users' balances.
+	reservations map[uuid.UUID]reservation
+}
+
+type userMarket struct {
+	userID   uuid.UUID
+	marketID uuid.UUID
+}
+
+func newOrderShard() *orderShard {
+	return &orderShard{
+		data:       make(map[uuid.UUID]domain.Order),
+		executions: make(map[uuid.UUID][]domain.Execution),
+		amendments: make(map[uuid.UUID][]domain.OrderAmendment),
+		open:       make(map[userMarket]int),
+
+		reservations: make(map[uuid.UUID]reservation),
+	}
+}
+
+func openKey(order domain.Order) (userMarket, bool) {
+	if order.Status == nil || order.Status.IsFinal() || order.UserID == nil || order.MarketID == nil {
+		return userMarket{}, false
+	}
+	return userMarket{userID: *order.UserID, marketID: *order.MarketID}, true
+}
+
+// amend appends an entry to the history of its order. Callers hold mu.
+func (s *orderShard) amend(amendment domain.OrderAmendment) {
+	s.amendments[*amendment.OrderID] = append(s.amendments[*amendment.OrderID], amendment)
+}
+
+// apply stores order and appends its new executions. Callers hold mu.
+func (s *orderShard) apply(order domain.Order, executions []domain.Execution) {
+	if previous, ok := s.data[*order.ID]; ok {
+		if key, open := openKey(previous); open {
+			if s.open[key]--; s.open[key] == 0 {
+				delete(s.open, key)
+			}
+		}
+	}
+	if key, open := openKey(order); open {
+		s.open[key]++
+	}
+
+	s.data[*order.ID] = order
+	if len(executions) > 0 {
+		s.executions[*order.ID] = append(s.executions[*order.ID], executions...)
+	}
+}
+
 type orderInMemoryRepo struct {
-	mu     sync.RWMutex
-	logger logger.Logger
-	data   map[uuid.UUID]domain.Order
-	tracer trace.Tracer
+	shards      []*orderShard
+	logger      logger.Logger
+	tracer      trace.Tracer
+	metrics     *metric.Registry
+	persistence *persistence
+	expiry      *expiryScheduler
+	notifier    *orderNotifier
+	accounts    *accountLedger
+	// marketStates are the market states set by operators.
+	marketStates *marketStateStore
+	// engine matches orders when ORDER_REPOSITORY_EXECUTION_MODE is
+	// "matching"; it is nil in simulated mode.
+	engine *matching.Engine
 }
 
-func NewInMemoryOrderRepository(l logger.Logger) *orderInMemoryRepo {
+func NewInMemoryOrderRepository(l logger.Logger, metrics *metric.Registry, shardCount int) *orderInMemoryRepo {
+	if shardCount <= 0 {
+		shardCount = 1
+	}
+
+	shards := make([]*orderShard, shardCount)
+	for i := range shards {
+		shards[i] = newOrderShard()
+	}
+
 	return &orderInMemoryRepo{
-		data:   make(map[uuid.UUID]domain.Order),
-		logger: l,
-		tracer: otel.Tracer("order-service/repo"),
+		shards:   shards,
+		logger:   l,
+		tracer:   otel.Tracer("order-service/repo"),
+		metrics:  metrics,
+		expiry:   newExpiryScheduler(),
+		notifier: newOrderNotifier(),
+		accounts: newAccountLedger(shardCount),
+
+		marketStates: newMarketStateStore(),
 	}
 }
 
-func SetupOrderRepo(l logger.Logger) *orderInMemoryRepo {
-	orderRepo := NewInMemoryOrderRepository(l)
-	orderRepo.StartStatusUpdater(context.Background())
-	return orderRepo
+// shardFor picks the shard from the low bytes of the ID, which are random
+// for the v4 UUIDs the repository generates.
+func (r *orderInMemoryRepo) shardFor(id uuid.UUID) *orderShard {
+	return r.shards[binary.BigEndian.Uint64(id[8:])%uint64(len(r.shards))]
+}
+
+// lockAll read-locks every shard in index order and returns the unlock
+// function. It is used when a consistent view of the whole store is needed.
+func (r *orderInMemoryRepo) lockAll() func() {
+	for _, shard := range r.shards {
+		shard.mu.RLock()
+	}
+	return func() {
+		for _, shard := range r.shards {
+			shard.mu.RUnlock()
+		}
+	}
+}
+
+// SetupOrderRepo builds the repository, restores it from disk when
+// persistence is enabled and starts its background workers.
+func SetupOrderRepo(
+	ctx context.Context,
+	cfg *config.Config,
+	l logger.Logger,
+	metrics *metric.Registry,
+) (*orderInMemoryRepo, error) {
+	orderRepo := NewInMemoryOrderRepository(l, metrics, cfg.OrderRepository.Shards)
+	if cfg.OrderRepository.ExecutionMode == config.ExecutionModeMatching {
+		orderRepo.engine = matching.NewEngine()
+	}
+	if err := orderRepo.accounts.configure(cfg.Accounts); err != nil {
+		return nil, err
+	}
+	if cfg.OrderRepository.PersistenceEnabled {
+		if err := orderRepo.enablePersistence(ctx, cfg.OrderRepository); err != nil {
+			return nil, err
+		}
+		orderRepo.startSnapshotter(ctx)
+	}
+	if orderRepo.engine != nil {
+		if err := orderRepo.rebuildBooks(ctx); err != nil {
+			return nil, err
+		}
+	}
+	orderRepo.startExpiryScheduler(ctx)
+	if orderRepo.engine == nil {
+		orderRepo.StartStatusUpdater(ctx)
+	}
+	return orderRepo, nil
 }
 
 func (r *orderInMemoryRepo) CreateOrder(
@@ -52,20 +188,89 @@ func (r *orderInMemoryRepo) CreateOrder(
 	createdAt := time.Now()
 	status := domain.OrderStatusEnumCreated
 
+	timeInForce := domain.TimeInForceEnumGTC
+	if req.TimeInForce != nil {
+		timeInForce = *req.TimeInForce
+	}
+
+	side := domain.OrderSideEnumBuy
+	if req.Side != nil {
+		side = *req.Side
+	}
+
+	var filled int64
+	remaining := *req.Quantity
+
 	order := domain.Order{
-		ID:        &orderID,
-		UserID:    req.UserID,
-		MarketID:  req.MarketID,
-		OrderType: req.OrderType,
-		Price:     req.Price,
-		Quantity:  req.Quantity,
-		Status:    &status,
-		CreatedAt: &createdAt,
+		ID:          &orderID,
+		UserID:      req.UserID,
+		MarketID:    req.MarketID,
+		OrderType:   req.OrderType,
+		Side:        &side,
+		Price:       req.Price,
+		Quantity:    req.Quantity,
+		Status:      &status,
+		TimeInForce: &timeInForce,
+		ExpiresAt:   req.ExpiresAt,
+		CreatedAt:   &createdAt,
+
+		FilledQuantity:    &filled,
+		RemainingQuantity: &remaining,
 	}
 
-	r.mu.Lock()
-	r.data[orderID] = order
-	r.mu.Unlock()
+	var outcome matchOutcome
+	var err error
+	if r.engine != nil {
+		outcome, err = r.submit(order)
+	} else {
+		outcome, err = r.storeSimulated(order)
+	}
+	if err != nil {
+		span.RecordError(err)
+		var customErr *errs.CustomError
+		switch {
+		case errors.Is(err, errs.ErrInsufficientBalance):
+			r.logger.WithContext(ctx).Warn(layer, method, "order not funded", err,
+				"x_request_id", xRequestID,
+				"order_id", orderID.String(),
+				"user_id", req.UserID.String(),
+				"market_id", req.MarketID.String(),
+			)
+			return domain.CreateOrderResponse{}, err
+		case errors.As(err, &customErr):
+			r.logger.WithContext(ctx).Warn(layer, method, "order rejected", err,
+				"x_request_id", xRequestID,
+				"order_id", orderID.String(),
+				"user_id", req.UserID.String(),
+				"market_id", req.MarketID.String(),
+			)
+			return domain.CreateOrderResponse{}, err
+		}
+		r.logger.WithContext(ctx).Error(layer, method, "failed to store order", err,
+			"x_request_id", xRequestID,
+			"order_id", orderID.String(),
+		)
+		return domain.CreateOrderResponse{}, err
+	}
+	order = outcome.order
+	status = *order.Status
+
+	if deadline, ok := expiryDeadline(order); ok {
+		r.expiry.schedule(orderID, deadline)
+	}
+
+	switch status {
+	case domain.OrderStatusEnumFilled:
+		r.metrics.OrderFilled(order.OrderType.String(), 0)
+	case domain.OrderStatusEnumExpired:
+		r.metrics.OrderExpired(timeInForce.String())
+	}
+	for _, maker := range outcome.counterparties {
+		if *maker.Status == domain.OrderStatusEnumFilled {
+			r.metrics.OrderFilled(maker.OrderType.String(), createdAt.Sub(*maker.CreatedAt))
+		}
+		r.notifier.publish(maker)
+	}
 
 	span.SetAttributes(
 		attribute.String("x-request-id", xRequestID),
@@ -73,11 +278,13 @@ func (r *orderInMemoryRepo) CreateOrder(
 		attribute.String("user.id", req.UserID.String()),
 		attribute.String("market.id", req.MarketID.String()),
 		attribute.String("order.status", string(*order.Status)),
+		attribute.String("order.side", side.String()),
+		attribute.String("order.time_in_force", timeInForce.String()),
 		attribute.String("order.price", *order.Price),
 		attribute.Int64("order.quantity", *req.Quantity),
 	)
 
-	r.logger.Info(layer, method, "order created",
+	r.logger.WithContext(ctx).Info(layer, method, "order created",
 		"x_request_id", xRequestID,
 		"order_id", orderID.String(),
 		"user_id", req.UserID.String(),
@@ -85,15 +292,54 @@ func (r *orderInMemoryRepo) CreateOrder(
 		"price", req.Price,
 		"quantity", req.Quantity,
 		"status", status,
+		"side", side,
+		"filled_quantity", order.FilledQuantity,
+		"executions", len(outcome.executions),
+		"time_in_force", timeInForce,
+		"expires_at", req.ExpiresAt,
 		"created_at", createdAt,
 	)
 
 	return domain.CreateOrderResponse{
 		OrderID:     order.ID,
 		OrderStatus: order.Status,
+
+		FilledQuantity:    order.FilledQuantity,
+		RemainingQuantity: order.RemainingQuantity,
+		AvgFillPrice:      order.AvgFillPrice,
 	}, nil
 }
 
+// storeSimulated stores a new order when no matching engine runs. IOC and
+// FOK orders never rest, so they are resolved here, on arrival; the others
+// are left to the status updater. Market orders are refused: with no book
+// and no reference price they could only fill at their stored price of
+// zero.
+func (r *orderInMemoryRepo) storeSimulated(order domain.Order) (matchOutcome, error) {
+	if *order.OrderType == domain.OrderTypeEnumMarket {
+		return matchOutcome{}, errs.ErrMarketOrderUnavailable
+	}
+
+	var executions []domain.Execution
+	if order.TimeInForce.IsImmediate() {
+		var err error
+		if executions, err = executeImmediate(&order, *order.CreatedAt); err != nil {
+			return matchOutcome{}, err
+		}
+	}
+
+	shard := r.shardFor(*order.ID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if err := r.persist(order, executions...); err != nil {
+		return matchOutcome{}, err
+	}
+	shard.apply(order, executions)
+
+	return matchOutcome{order: order, executions: executions}, nil
+}
+
 func (r *orderInMemoryRepo) GetOrderByID(ctx context.Context, ID uuid.UUID) (domain.Order, error) {
 	const layer = "repo"
 	const method = "GetOrderByID"
@@ -108,16 +354,17 @@ func (r *orderInMemoryRepo) GetOrderByID(ctx context.Context, ID uuid.UUID) (dom
 		attribute.String("order.id", ID.String()),
 	)
 
-	r.mu.RLock()
-	order, ok := r.data[ID]
-	r.mu.RUnlock()
+	shard := r.shardFor(ID)
+	shard.mu.RLock()
+	order, ok := shard.data[ID]
+	shard.mu.RUnlock()
 
 	if !ok {
 		err := errors.New("order not found")
 		span.RecordError(err)
 		span.SetAttributes(attribute.Bool("order.found", false))
 
-		r.logger.Warn(layer, method, "order not found", nil,
+		r.logger.WithContext(ctx).Warn(layer, method, "order not found", nil,
 			"x_request_id", xRequestID,
 			"order_id", ID.String(),
 		)
@@ -130,7 +377,7 @@ func (r *orderInMemoryRepo) GetOrderByID(ctx context.Context, ID uuid.UUID) (dom
 		attribute.String("order.status", string(*order.Status)),
 	)
 
-	r.logger.Info(layer, method, "order retrieved",
+	r.logger.WithContext(ctx).Debug(layer, method, "order retrieved",
 		"x_request_id", xRequestID,
 		"order_id", ID.String(),
 		"user_id", order.UserID.String(),
@@ -140,7 +387,35 @@ func (r *orderInMemoryRepo) GetOrderByID(ctx context.Context, ID uuid.UUID) (dom
 	return order, nil
 }
 
-// Stub for test solution
+// CountOpenOrders returns how many open orders userID has in marketID.
+func (r *orderInMemoryRepo) CountOpenOrders(ctx context.Context, userID, marketID uuid.UUID) (int, error) {
+	_, span := r.tracer.Start(ctx, "OrderInMemoryRepo.CountOpenOrders")
+	defer span.End()
+
+	key := userMarket{userID: userID, marketID: marketID}
+
+	var n int
+	for _, shard := range r.shards {
+		shard.mu.RLock()
+		n += shard.open[key]
+		shard.mu.RUnlock()
+	}
+
+	span.SetAttributes(attribute.Int("orders.open", n))
+	return n, nil
+}
+
+func (r *orderInMemoryRepo) Ping(ctx context.Context) error {
+	if len(r.shards) == 0 {
+		return errors.New("order store is not initialized")
+	}
+	return nil
+}
+
+// Stub for test solution, used in simulated execution mode.
+//
+// The sweep locks one shard at a time, so requests for orders in other
+// shards are served while it runs.
 func (r *orderInMemoryRepo) StartStatusUpdater(ctx context.Context) {
 	const layer = "repo"
 	const method = "StatusUpdater"
@@ -148,48 +423,87 @@ func (r *orderInMemoryRepo) StartStatusUpdater(ctx context.Context) {
 	ticker := time.NewTicker(30 * time.Second)
 
 	go func() {
+		defer ticker.Stop()
 		for {
 			select {
 			case <-ctx.Done():
-				r.logger.Info(layer, method, "status updater stopped")
+				r.logger.WithContext(ctx).Info(layer, method, "status updater stopped")
 				return
 			case <-ticker.C:
-				r.mu.Lock()
-				for id, order := range r.data {
-					if order.Status == nil {
-						continue
-					}
-
-					if *order.Status == domain.OrderStatusEnumFilled || *order.Status == domain.OrderStatusEnumRejected {
-						continue
-					}
-
-					var nextStatus domain.OrderStatusEnum
-					switch *order.Status {
-					case domain.OrderStatusEnumCreated:
-						nextStatus = domain.OrderStatusEnumPending
-					case domain.OrderStatusEnumPending:
-						if rand.Intn(2) == 0 {
-							nextStatus = domain.OrderStatusEnumFilled
-						} else {
-							nextStatus = domain.OrderStatusEnumRejected
-						}
-					default:
-						continue
-					}
-
-					order.Status = &nextStatus
-					updatedAt := time.Now()
-					order.UpdatedAt = &updatedAt
-					r.data[id] = order
-
-					r.logger.Info(layer, method, "order status updated",
-						"order_id", id.String(),
-						"new_status", nextStatus,
-					)
+				for _, shard := range r.shards {
+					r.advanceShard(ctx, shard)
 				}
-				r.mu.Unlock()
 			}
 		}
 	}()
 }
+
+func (r *orderInMemoryRepo) advanceShard(ctx context.Context, shard *orderShard) {
+	const layer = "repo"
+	const method = "StatusUpdater"
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	for id, order := range shard.data {
+		if order.Status == nil {
+			continue
+		}
+
+		if order.Status.IsFinal() {
+			continue
+		}
+
+		updatedAt := time.Now()
+
+		var executions []domain.Execution
+		switch *order.Status {
+		case domain.OrderStatusEnumCreated:
+			pending := domain.OrderStatusEnumPending
+			order.Status = &pending
+			order.UpdatedAt = &updatedAt
+		case domain.OrderStatusEnumPending, domain.OrderStatusEnumPartiallyFilled:
+			filled, remaining := fillState(order)
+			if filled == 0 && rand.Intn(4) == 0 {
+				rejected := domain.OrderStatusEnumRejected
+				order.Status = &rejected
+				order.UpdatedAt = &updatedAt
+				break
+			}
+
+			execution, err := applyFill(&order, *order.Price, 1+rand.Int63n(remaining), updatedAt)
+			if err != nil {
+				r.logger.WithContext(ctx).Error(layer, method, "failed to fill order", err,
+					"order_id", id.String(),
+				)
+				continue
+			}
+			executions = append(executions, execution)
+		default:
+			continue
+		}
+
+		if err := r.persist(order, executions...); err != nil {
+			r.logger.WithContext(ctx).Error(layer, method, "failed to persist status update", err,
+				"order_id", id.String(),
+			)
+			continue
+		}
+		shard.apply(order, executions)
+
+		switch *order.Status {
+		case domain.OrderStatusEnumFilled:
+			r.metrics.OrderFilled(order.OrderType.String(), updatedAt.Sub(*order.CreatedAt))
+		case domain.OrderStatusEnumRejected:
+			r.metrics.OrderRejected(metric.RejectReasonExecution)
+		}
+		r.notifier.publish(order)
+
+		r.logger.WithContext(ctx).Info(layer, method, "order status updated",
+			"order_id", id.String(),
+			"new_status", *order.Status,
+			"filled_quantity", order.FilledQuantity,
+			"remaining_quantity", order.RemainingQuantity,
+		)
+	}
+}
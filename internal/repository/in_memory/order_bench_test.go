@@ -0,0 +1,121 @@
+package in_memory_repo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/FlyKarlik/orderService/config"
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/pkg/logger"
+	"github.com/FlyKarlik/orderService/pkg/metric"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// benchShardCounts compares a single lock against the default striping.
+var benchShardCounts = []int{1, 32}
+
+func newBenchRepo(b *testing.B, shardCount int) *orderInMemoryRepo {
+	b.Helper()
+	cfg := &config.Config{}
+	cfg.OrderService.LogLevel = "error"
+	l, err := logger.New(cfg)
+	if err != nil {
+		b.Fatalf("logger.New() error = %v", err)
+	}
+	return NewInMemoryOrderRepository(l, metric.NewRegistry(prometheus.NewRegistry()), shardCount)
+}
+
+// startSweep runs the status sweep of simulated mode back to back until the
+// benchmark ends, so requests contend with it the way they do when the
+// ticker fires on a loaded store.
+func startSweep(b *testing.B, r *orderInMemoryRepo) {
+	b.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ctx.Err() == nil {
+			for _, shard := range r.shards {
+				r.advanceShard(ctx, shard)
+			}
+		}
+	}()
+	b.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func benchCreateRequest() domain.CreateOrderRequest {
+	userID, marketID := uuid.New(), uuid.New()
+	orderType := domain.OrderTypeEnumLimit
+	price := "100.5"
+	quantity := int64(10)
+	return domain.CreateOrderRequest{
+		UserID:    &userID,
+		MarketID:  &marketID,
+		OrderType: &orderType,
+		Price:     &price,
+		Quantity:  &quantity,
+		UserRoles: domain.UserRolesEnum{domain.UserRoleEnumTrader},
+	}
+}
+
+func BenchmarkCreateOrder(b *testing.B) {
+	for _, shardCount := range benchShardCounts {
+		b.Run(fmt.Sprintf("shards=%d", shardCount), func(b *testing.B) {
+			r := newBenchRepo(b, shardCount)
+			startSweep(b, r)
+			req := benchCreateRequest()
+			ctx := context.Background()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := r.CreateOrder(ctx, req); err != nil {
+						b.Errorf("CreateOrder() error = %v", err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkGetOrderByID(b *testing.B) {
+	const orders = 10000
+
+	for _, shardCount := range benchShardCounts {
+		b.Run(fmt.Sprintf("shards=%d", shardCount), func(b *testing.B) {
+			r := newBenchRepo(b, shardCount)
+			req := benchCreateRequest()
+			ctx := context.Background()
+
+			ids := make([]uuid.UUID, orders)
+			for i := range ids {
+				resp, err := r.CreateOrder(ctx, req)
+				if err != nil {
+					b.Fatalf("CreateOrder() error = %v", err)
+				}
+				ids[i] = *resp.OrderID
+			}
+			startSweep(b, r)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					if _, err := r.GetOrderByID(ctx, ids[i%orders]); err != nil {
+						b.Errorf("GetOrderByID() error = %v", err)
+						return
+					}
+					i++
+				}
+			})
+		})
+	}
+}
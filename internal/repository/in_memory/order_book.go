@@ -0,0 +1,143 @@
+package in_memory_repo
+
+import (
+	"context"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/errs"
+	"github.com/FlyKarlik/orderService/internal/matching"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"github.com/FlyKarlik/orderService/pkg/decimal"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func toDomainOrderBookLevel(level matching.Level) domain.OrderBookLevel {
+	side := level.Side
+	price := level.Price.String()
+	quantity := level.Quantity
+	orders := int64(level.Orders)
+	return domain.OrderBookLevel{
+		Side:     &side,
+		Price:    &price,
+		Quantity: &quantity,
+		Orders:   &orders,
+	}
+}
+
+func toDomainOrderBookLevels(levels []matching.Level) []domain.OrderBookLevel {
+	out := make([]domain.OrderBookLevel, 0, len(levels))
+	for _, level := range levels {
+		out = append(out, toDomainOrderBookLevel(level))
+	}
+	return out
+}
+
+func toDomainOrderBook(marketID uuid.UUID, snapshot matching.Snapshot) domain.GetOrderBookResponse {
+	sequence := snapshot.Sequence
+	return domain.GetOrderBookResponse{
+		MarketID: &marketID,
+		Sequence: &sequence,
+		Bids:     toDomainOrderBookLevels(snapshot.Bids),
+		Asks:     toDomainOrderBookLevels(snapshot.Asks),
+	}
+}
+
+// GetOrderBook returns the top depth levels per side of a market's book.
+func (r *orderInMemoryRepo) GetOrderBook(ctx context.Context, marketID uuid.UUID, depth int) (domain.GetOrderBookResponse, error) {
+	const layer = "repo"
+	const method = "GetOrderBook"
+
+	ctx, span := r.tracer.Start(ctx, "OrderInMemoryRepo.GetOrderBook")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("x-request-id", shared_context.XRequestIDFromContext(ctx)),
+		attribute.String("market.id", marketID.String()),
+		attribute.Int("book.depth", depth),
+	)
+
+	if r.engine == nil {
+		span.RecordError(errs.ErrOrderBookUnavailable)
+		return domain.GetOrderBookResponse{}, errs.ErrOrderBookUnavailable
+	}
+
+	snapshot := r.engine.Book(marketID).Depth(depth)
+
+	r.logger.WithContext(ctx).Debug(layer, method, "order book retrieved",
+		"market_id", marketID.String(),
+		"sequence", snapshot.Sequence,
+		"bids", len(snapshot.Bids),
+		"asks", len(snapshot.Asks),
+	)
+
+	return toDomainOrderBook(marketID, snapshot), nil
+}
+
+// SubscribeOrderBook returns the full depth of a market's book and a channel
+// of the updates that follow it. The channel is closed when stop is called,
+// when ctx is done or when the subscriber falls too far behind; in the last
+// case the caller subscribes again to resynchronise.
+func (r *orderInMemoryRepo) SubscribeOrderBook(
+	ctx context.Context,
+	marketID uuid.UUID,
+) (domain.GetOrderBookResponse, <-chan domain.StreamOrderBookResponse, func(), error) {
+	if r.engine == nil {
+		return domain.GetOrderBookResponse{}, nil, nil, errs.ErrOrderBookUnavailable
+	}
+
+	snapshot, updates, stop := r.engine.Book(marketID).Subscribe()
+
+	out := make(chan domain.StreamOrderBookResponse, cap(updates))
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				stop()
+				return
+			case update, ok := <-updates:
+				if !ok {
+					return
+				}
+				sequence := update.Sequence
+				select {
+				case out <- domain.StreamOrderBookResponse{
+					MarketID: &marketID,
+					Sequence: &sequence,
+					Levels:   toDomainOrderBookLevels(update.Levels),
+				}:
+				case <-ctx.Done():
+					stop()
+					return
+				}
+			}
+		}
+	}()
+
+	return toDomainOrderBook(marketID, snapshot), out, stop, nil
+}
+
+// ReferencePrice returns the mid of the best bid and ask of a market, or the
+// best price of the only side that has orders. It reports false when the
+// book is empty or there are no books in simulated mode.
+func (r *orderInMemoryRepo) ReferencePrice(ctx context.Context, marketID uuid.UUID) (decimal.Decimal, bool, error) {
+	_, span := r.tracer.Start(ctx, "OrderInMemoryRepo.ReferencePrice")
+	defer span.End()
+
+	if r.engine == nil {
+		return decimal.Zero, false, nil
+	}
+
+	top := r.engine.Book(marketID).Depth(1)
+	switch {
+	case len(top.Bids) > 0 && len(top.Asks) > 0:
+		return top.Bids[0].Price.Add(top.Asks[0].Price).DivInt(2), true, nil
+	case len(top.Bids) > 0:
+		return top.Bids[0].Price, true, nil
+	case len(top.Asks) > 0:
+		return top.Asks[0].Price, true, nil
+	default:
+		return decimal.Zero, false, nil
+	}
+}
@@ -0,0 +1,712 @@
+package in_memory_repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/FlyKarlik/orderService/config"
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/pkg/decimal"
+	"github.com/FlyKarlik/orderService/pkg/wal"
+	"github.com/google/uuid"
+)
+
+// persistedOrder is the on-disk form of domain.Order. It is kept separate
+// from the domain type so the storage format only changes on purpose.
+type persistedOrder struct {
+	ID        uuid.UUID             `json:"id"`
+	UserID    *uuid.UUID            `json:"user_id,omitempty"`
+	MarketID  *uuid.UUID            `json:"market_id,omitempty"`
+	OrderType *domain.OrderTypeEnum `json:"order_type,omitempty"`
+	// Side is empty for orders written before order sides existed; those
+	// are BUY.
+	Side     *domain.OrderSideEnum   `json:"side,omitempty"`
+	Price    *string                 `json:"price,omitempty"`
+	Quantity *int64                  `json:"quantity,omitempty"`
+	Status   *domain.OrderStatusEnum `json:"status,omitempty"`
+	// TimeInForce is empty for orders written before time in force existed;
+	// those are GTC.
+	TimeInForce *domain.TimeInForceEnum `json:"time_in_force,omitempty"`
+	ExpiresAt   *time.Time              `json:"expires_at,omitempty"`
+	// The fill fields are empty for orders written before partial fills
+	// existed; they are derived from Quantity and Status on restore.
+	FilledQuantity    *int64     `json:"filled_quantity,omitempty"`
+	RemainingQuantity *int64     `json:"remaining_quantity,omitempty"`
+	AvgFillPrice      *string    `json:"avg_fill_price,omitempty"`
+	CreatedAt         *time.Time `json:"created_at,omitempty"`
+	UpdatedAt         *time.Time `json:"updated_at,omitempty"`
+}
+
+type persistedAmendment struct {
+	OrderID uuid.UUID `json:"order_id"`
+	// Action is empty for entries written before cancellations were
+	// recorded; those are replaces.
+	Action           domain.OrderHistoryActionEnum `json:"action,omitempty"`
+	PreviousPrice    *string                       `json:"previous_price,omitempty"`
+	Price            *string                       `json:"price,omitempty"`
+	PreviousQuantity *int64                        `json:"previous_quantity,omitempty"`
+	Quantity         *int64                        `json:"quantity,omitempty"`
+	KeptPriority     *bool                         `json:"kept_priority,omitempty"`
+	CancelReason     *domain.CancelReasonEnum      `json:"cancel_reason,omitempty"`
+	AmendedAt        *time.Time                    `json:"amended_at,omitempty"`
+}
+
+type persistedExecution struct {
+	ID         uuid.UUID  `json:"id"`
+	OrderID    uuid.UUID  `json:"order_id"`
+	Price      *string    `json:"price,omitempty"`
+	Quantity   *int64     `json:"quantity,omitempty"`
+	ExecutedAt *time.Time `json:"executed_at,omitempty"`
+}
+
+type persistedBalance struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Asset     string    `json:"asset"`
+	Available string    `json:"available"`
+	Reserved  string    `json:"reserved"`
+}
+
+type persistedReservation struct {
+	OrderID uuid.UUID            `json:"order_id"`
+	UserID  uuid.UUID            `json:"user_id"`
+	Side    domain.OrderSideEnum `json:"side"`
+	Base    string               `json:"base"`
+	Quote   string               `json:"quote"`
+	Amount  string               `json:"amount"`
+	// Released is set when the order no longer holds a reservation.
+	Released bool `json:"released,omitempty"`
+}
+
+type persistedMarketState struct {
+	MarketID uuid.UUID `json:"market_id"`
+	// State is empty when the override was cleared.
+	State     domain.MarketStateEnum `json:"state,omitempty"`
+	Reason    *string                `json:"reason,omitempty"`
+	UpdatedAt *time.Time             `json:"updated_at,omitempty"`
+}
+
+// walRecord is one order mutation. Every mutation stores the full order, so
+// replaying a record is an idempotent upsert of the order.
+type walRecord struct {
+	// Order is nil for balance adjustments and market state changes, which
+	// change no order.
+	Order *persistedOrder `json:"order,omitempty"`
+	// Executions are the fills applied by this mutation. They are appended
+	// on replay, so each fill is written exactly once.
+	Executions []persistedExecution `json:"executions,omitempty"`
+	// Counterparties are the resting orders a match filled. They share the
+	// record with the incoming order, so a match is replayed all or nothing.
+	Counterparties []persistedOrder `json:"counterparties,omitempty"`
+	// Amendment is set when the mutation replaced Order.
+	Amendment *persistedAmendment `json:"amendment,omitempty"`
+	// BalanceDeltas are the changes the mutation made to balances. They
+	// are added on replay, so records of different users may be replayed
+	// in any order. Reservations are those the mutation changed, as they
+	// are after it.
+	BalanceDeltas []persistedBalance     `json:"balance_deltas,omitempty"`
+	Reservations  []persistedReservation `json:"reservations,omitempty"`
+	// MarketState is set when an operator changed the state of a market.
+	MarketState *persistedMarketState `json:"market_state,omitempty"`
+}
+
+type snapshotState struct {
+	Orders       []persistedOrder       `json:"orders"`
+	Executions   []persistedExecution   `json:"executions,omitempty"`
+	Amendments   []persistedAmendment   `json:"amendments,omitempty"`
+	Balances     []persistedBalance     `json:"balances,omitempty"`
+	Reservations []persistedReservation `json:"reservations,omitempty"`
+	MarketStates []persistedMarketState `json:"market_states,omitempty"`
+}
+
+// persistence keeps the in-memory store on local disk: every mutation is
+// appended to a write-ahead log before it is applied, and periodic snapshots
+// let old log segments be dropped.
+type persistence struct {
+	dir              string
+	log              *wal.Log
+	snapshotInterval time.Duration
+	snapshotMu       sync.Mutex
+}
+
+func toPersistedOrder(order domain.Order) persistedOrder {
+	return persistedOrder{
+		ID:                *order.ID,
+		UserID:            order.UserID,
+		MarketID:          order.MarketID,
+		OrderType:         order.OrderType,
+		Side:              order.Side,
+		Price:             order.Price,
+		Quantity:          order.Quantity,
+		Status:            order.Status,
+		TimeInForce:       order.TimeInForce,
+		ExpiresAt:         order.ExpiresAt,
+		FilledQuantity:    order.FilledQuantity,
+		RemainingQuantity: order.RemainingQuantity,
+		AvgFillPrice:      order.AvgFillPrice,
+		CreatedAt:         order.CreatedAt,
+		UpdatedAt:         order.UpdatedAt,
+	}
+}
+
+func fromPersistedOrder(order persistedOrder) domain.Order {
+	id := order.ID
+	if order.TimeInForce == nil {
+		gtc := domain.TimeInForceEnumGTC
+		order.TimeInForce = &gtc
+	}
+	if order.Side == nil {
+		buy := domain.OrderSideEnumBuy
+		order.Side = &buy
+	}
+	if order.FilledQuantity == nil && order.Quantity != nil {
+		var filled int64
+		if order.Status != nil && *order.Status == domain.OrderStatusEnumFilled {
+			filled = *order.Quantity
+		}
+		remaining := *order.Quantity - filled
+		order.FilledQuantity = &filled
+		order.RemainingQuantity = &remaining
+	}
+	return domain.Order{
+		ID:                &id,
+		UserID:            order.UserID,
+		MarketID:          order.MarketID,
+		OrderType:         order.OrderType,
+		Side:              order.Side,
+		Price:             order.Price,
+		Quantity:          order.Quantity,
+		Status:            order.Status,
+		TimeInForce:       order.TimeInForce,
+		ExpiresAt:         order.ExpiresAt,
+		FilledQuantity:    order.FilledQuantity,
+		RemainingQuantity: order.RemainingQuantity,
+		AvgFillPrice:      order.AvgFillPrice,
+		CreatedAt:         order.CreatedAt,
+		UpdatedAt:         order.UpdatedAt,
+	}
+}
+
+func toPersistedExecution(execution domain.Execution) persistedExecution {
+	return persistedExecution{
+		ID:         *execution.ID,
+		OrderID:    *execution.OrderID,
+		Price:      execution.Price,
+		Quantity:   execution.Quantity,
+		ExecutedAt: execution.ExecutedAt,
+	}
+}
+
+func fromPersistedExecution(execution persistedExecution) domain.Execution {
+	id, orderID := execution.ID, execution.OrderID
+	return domain.Execution{
+		ID:         &id,
+		OrderID:    &orderID,
+		Price:      execution.Price,
+		Quantity:   execution.Quantity,
+		ExecutedAt: execution.ExecutedAt,
+	}
+}
+
+func toPersistedAmendment(amendment domain.OrderAmendment) persistedAmendment {
+	var action domain.OrderHistoryActionEnum
+	if amendment.Action != nil {
+		action = *amendment.Action
+	}
+	return persistedAmendment{
+		OrderID:          *amendment.OrderID,
+		Action:           action,
+		PreviousPrice:    amendment.PreviousPrice,
+		Price:            amendment.Price,
+		PreviousQuantity: amendment.PreviousQuantity,
+		Quantity:         amendment.Quantity,
+		KeptPriority:     amendment.KeptPriority,
+		CancelReason:     amendment.CancelReason,
+		AmendedAt:        amendment.AmendedAt,
+	}
+}
+
+func fromPersistedAmendment(amendment persistedAmendment) domain.OrderAmendment {
+	orderID := amendment.OrderID
+	action := amendment.Action
+	if action == "" {
+		action = domain.OrderHistoryActionEnumReplace
+	}
+	return domain.OrderAmendment{
+		OrderID:          &orderID,
+		Action:           &action,
+		PreviousPrice:    amendment.PreviousPrice,
+		Price:            amendment.Price,
+		PreviousQuantity: amendment.PreviousQuantity,
+		Quantity:         amendment.Quantity,
+		KeptPriority:     amendment.KeptPriority,
+		CancelReason:     amendment.CancelReason,
+		AmendedAt:        amendment.AmendedAt,
+	}
+}
+
+func toPersistedBalance(key accountKey, b balance) persistedBalance {
+	return persistedBalance{
+		UserID:    key.userID,
+		Asset:     key.asset,
+		Available: b.available.String(),
+		Reserved:  b.reserved.String(),
+	}
+}
+
+func toPersistedReservation(orderID uuid.UUID, res reservation) persistedReservation {
+	return persistedReservation{
+		OrderID: orderID,
+		UserID:  res.userID,
+		Side:    res.side,
+		Base:    res.base,
+		Quote:   res.quote,
+		Amount:  res.amount.String(),
+	}
+}
+
+func toPersistedMarketState(override domain.MarketStateOverride) persistedMarketState {
+	persisted := persistedMarketState{
+		MarketID:  *override.MarketID,
+		Reason:    override.Reason,
+		UpdatedAt: override.UpdatedAt,
+	}
+	if override.State != nil {
+		persisted.State = *override.State
+	}
+	return persisted
+}
+
+func fromPersistedMarketState(persisted persistedMarketState) domain.MarketStateOverride {
+	marketID := persisted.MarketID
+	override := domain.MarketStateOverride{
+		MarketID:  &marketID,
+		Reason:    persisted.Reason,
+		UpdatedAt: persisted.UpdatedAt,
+	}
+	if persisted.State != "" {
+		state := persisted.State
+		override.State = &state
+	}
+	return override
+}
+
+// persistedLedgerTx returns the changes of tx in their on-disk form.
+func persistedLedgerTx(tx *ledgerTx) ([]persistedBalance, []persistedReservation) {
+	var deltas []persistedBalance
+	for key, delta := range tx.deltas {
+		deltas = append(deltas, toPersistedBalance(key, delta))
+	}
+	var reservations []persistedReservation
+	for orderID, res := range tx.reservations {
+		if res == nil {
+			reservations = append(reservations, persistedReservation{OrderID: orderID, Released: true})
+			continue
+		}
+		reservations = append(reservations, toPersistedReservation(orderID, *res))
+	}
+	return deltas, reservations
+}
+
+// enablePersistence restores the store from the latest snapshot and the log
+// written after it, then opens the log for new mutations.
+func (r *orderInMemoryRepo) enablePersistence(ctx context.Context, cfg config.OrderRepositoryConfig) error {
+	const layer = "repo"
+	const method = "enablePersistence"
+
+	seq, payload, err := wal.ReadSnapshot(cfg.DataDir)
+	if err != nil {
+		return err
+	}
+
+	if payload != nil {
+		var state snapshotState
+		if err := json.Unmarshal(payload, &state); err != nil {
+			return fmt.Errorf("decode snapshot: %w", err)
+		}
+		for _, order := range state.Orders {
+			r.restoreOrder(order, nil)
+		}
+		r.restoreExecutions(state.Executions...)
+		r.restoreAmendments(state.Amendments...)
+		if err := r.restoreBalances(state.Balances, false); err != nil {
+			return err
+		}
+		if err := r.restoreReservations(state.Reservations); err != nil {
+			return err
+		}
+		r.restoreMarketStates(state.MarketStates...)
+	}
+	restored := r.count()
+
+	result, err := wal.Replay(cfg.DataDir, seq, func(payload []byte) error {
+		var record walRecord
+		if err := json.Unmarshal(payload, &record); err != nil {
+			return fmt.Errorf("decode wal record: %w", err)
+		}
+		if record.Order != nil {
+			r.restoreOrder(*record.Order, record.Executions)
+		}
+		for _, counterparty := range record.Counterparties {
+			r.restoreOrder(counterparty, nil)
+		}
+		if record.Amendment != nil {
+			r.restoreAmendments(*record.Amendment)
+		}
+		if record.MarketState != nil {
+			r.restoreMarketStates(*record.MarketState)
+		}
+		if err := r.restoreBalances(record.BalanceDeltas, true); err != nil {
+			return err
+		}
+		return r.restoreReservations(record.Reservations)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, corruption := range result.Corruptions {
+		r.logger.WithContext(ctx).Error(layer, method, "corrupt wal segment truncated", corruption.Err,
+			"segment", corruption.Segment,
+			"offset", corruption.Offset,
+			"quarantine", corruption.QuarantinePath,
+		)
+	}
+
+	log, err := wal.Open(cfg.DataDir, cfg.WALSync, cfg.WALSyncInterval)
+	if err != nil {
+		return err
+	}
+
+	r.persistence = &persistence{
+		dir:              cfg.DataDir,
+		log:              log,
+		snapshotInterval: cfg.SnapshotInterval,
+	}
+
+	r.logger.WithContext(ctx).Info(layer, method, "order store restored",
+		"data_dir", cfg.DataDir,
+		"snapshot_segment", seq,
+		"snapshot_orders", restored,
+		"wal_segments", result.Segments,
+		"wal_records", result.Records,
+		"orders", r.count(),
+	)
+	return nil
+}
+
+func (r *orderInMemoryRepo) restoreOrder(order persistedOrder, executions []persistedExecution) {
+	shard := r.shardFor(order.ID)
+	shard.mu.Lock()
+	shard.apply(fromPersistedOrder(order), nil)
+	shard.mu.Unlock()
+
+	r.restoreExecutions(executions...)
+}
+
+// restoreBalances sets balances, or adds them to the current ones when they
+// are deltas.
+func (r *orderInMemoryRepo) restoreBalances(balances []persistedBalance, deltas bool) error {
+	for _, persisted := range balances {
+		available, err := decimal.Parse(persisted.Available)
+		if err != nil {
+			return fmt.Errorf("restore balance: %w", err)
+		}
+		reserved, err := decimal.Parse(persisted.Reserved)
+		if err != nil {
+			return fmt.Errorf("restore balance: %w", err)
+		}
+
+		key := accountKey{userID: persisted.UserID, asset: persisted.Asset}
+		stripe := r.accounts.stripeFor(key.userID)
+		stripe.mu.Lock()
+		if deltas {
+			b := stripe.balances[key]
+			available, reserved = b.available.Add(available), b.reserved.Add(reserved)
+		}
+		stripe.balances[key] = balance{available: available, reserved: reserved}
+		stripe.mu.Unlock()
+	}
+	return nil
+}
+
+func (r *orderInMemoryRepo) restoreReservations(reservations []persistedReservation) error {
+	for _, persisted := range reservations {
+		shard := r.shardFor(persisted.OrderID)
+		if persisted.Released {
+			shard.mu.Lock()
+			delete(shard.reservations, persisted.OrderID)
+			shard.mu.Unlock()
+			continue
+		}
+		amount, err := decimal.Parse(persisted.Amount)
+		if err != nil {
+			return fmt.Errorf("restore reservation: %w", err)
+		}
+		shard.mu.Lock()
+		shard.reservations[persisted.OrderID] = reservation{
+			userID: persisted.UserID,
+			side:   persisted.Side,
+			base:   persisted.Base,
+			quote:  persisted.Quote,
+			amount: amount,
+		}
+		shard.mu.Unlock()
+	}
+	return nil
+}
+
+func (r *orderInMemoryRepo) restoreMarketStates(states ...persistedMarketState) {
+	r.marketStates.mu.Lock()
+	defer r.marketStates.mu.Unlock()
+
+	for _, state := range states {
+		r.marketStates.set(fromPersistedMarketState(state))
+	}
+}
+
+func (r *orderInMemoryRepo) restoreAmendments(amendments ...persistedAmendment) {
+	for _, amendment := range amendments {
+		shard := r.shardFor(amendment.OrderID)
+		shard.mu.Lock()
+		shard.amend(fromPersistedAmendment(amendment))
+		shard.mu.Unlock()
+	}
+}
+
+func (r *orderInMemoryRepo) restoreExecutions(executions ...persistedExecution) {
+	for _, execution := range executions {
+		shard := r.shardFor(execution.OrderID)
+		shard.mu.Lock()
+		shard.executions[execution.OrderID] = append(shard.executions[execution.OrderID], fromPersistedExecution(execution))
+		shard.mu.Unlock()
+	}
+}
+
+func (r *orderInMemoryRepo) count() int {
+	unlock := r.lockAll()
+	defer unlock()
+
+	var n int
+	for _, shard := range r.shards {
+		n += len(shard.data)
+	}
+	return n
+}
+
+// persist appends order and its new executions to the write-ahead log.
+// Callers hold the lock of the order's shard and apply the change only when
+// persist succeeds.
+func (r *orderInMemoryRepo) persist(order domain.Order, executions ...domain.Execution) error {
+	return r.persistMatch(order, nil, executions, nil)
+}
+
+// persistMatch appends an incoming order together with the counterparties
+// it traded with and the executions of both sides as one record, and books
+// the balance changes of the mutation when accounts are enabled. Callers
+// hold the locks of every involved shard.
+func (r *orderInMemoryRepo) persistMatch(
+	order domain.Order,
+	counterparties []domain.Order,
+	executions []domain.Execution,
+	amendment *domain.OrderAmendment,
+) error {
+	if r.persistence == nil && !r.accounts.enabled {
+		return nil
+	}
+
+	persistedOrder := toPersistedOrder(order)
+	record := walRecord{Order: &persistedOrder}
+	for _, execution := range executions {
+		record.Executions = append(record.Executions, toPersistedExecution(execution))
+	}
+	for _, counterparty := range counterparties {
+		record.Counterparties = append(record.Counterparties, toPersistedOrder(counterparty))
+	}
+	if amendment != nil {
+		persisted := toPersistedAmendment(*amendment)
+		record.Amendment = &persisted
+	}
+
+	if !r.accounts.enabled {
+		return r.appendWALRecord(record)
+	}
+
+	tx := r.beginLedgerTx()
+	for _, changed := range append([]domain.Order{order}, counterparties...) {
+		var previous *domain.Order
+		if stored, ok := r.shardFor(*changed.ID).data[*changed.ID]; ok {
+			previous = &stored
+		}
+		if err := tx.book(previous, changed, executionsOf(executions, *changed.ID)); err != nil {
+			return err
+		}
+	}
+	if _, err := r.commitLedgerTx(tx, record); err != nil {
+		return err
+	}
+	tx.commitReservations()
+	return nil
+}
+
+// commitLedgerTx writes record with the changes of tx and applies its
+// balance changes, returning the resulting balances of the accounts it
+// changed. The record is encoded before the stripes of the users are held,
+// and the balances change only once the record is committed, so a failed
+// write or sync leaves them untouched.
+//
+// Reservations are left to the caller, which holds the shards of the
+// orders involved and commits them once the mutation is stored.
+func (r *orderInMemoryRepo) commitLedgerTx(tx *ledgerTx, record walRecord) (map[accountKey]balance, error) {
+	var payload []byte
+	if r.persistence != nil {
+		record.BalanceDeltas, record.Reservations = persistedLedgerTx(tx)
+		var err error
+		if payload, err = json.Marshal(record); err != nil {
+			return nil, err
+		}
+	}
+
+	unlock := r.accounts.lockUsers(tx.users()...)
+	defer unlock()
+
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	// The stripes are held until the record is committed, so a snapshot,
+	// which holds every stripe, either covers both the record and the
+	// balances or neither, and no other mutation of these users is checked
+	// against balances that a failed sync would leave unlogged.
+	if payload != nil {
+		if err := r.persistence.log.Append(payload); err != nil {
+			return nil, err
+		}
+	}
+	tx.commitBalances()
+	balances := make(map[accountKey]balance, len(tx.deltas))
+	for key := range tx.deltas {
+		balances[key] = r.accounts.stripeFor(key.userID).balances[key]
+	}
+	return balances, nil
+}
+
+// appendWALRecord writes record to the log. It is a no-op when persistence
+// is disabled.
+func (r *orderInMemoryRepo) appendWALRecord(record walRecord) error {
+	if r.persistence == nil {
+		return nil
+	}
+
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return r.persistence.log.Append(payload)
+}
+
+// Snapshot writes the current state to disk and removes the log segments it
+// covers. It is a no-op when persistence is disabled.
+func (r *orderInMemoryRepo) Snapshot(ctx context.Context) error {
+	const layer = "repo"
+	const method = "Snapshot"
+
+	if r.persistence == nil {
+		return nil
+	}
+
+	r.persistence.snapshotMu.Lock()
+	defer r.persistence.snapshotMu.Unlock()
+
+	// Every shard stays read-locked until the log is rotated, so no mutation
+	// can land between the copied state and the first record of the new
+	// segment.
+	unlock := r.lockAll()
+	var state snapshotState
+	for _, shard := range r.shards {
+		for _, order := range shard.data {
+			state.Orders = append(state.Orders, toPersistedOrder(order))
+		}
+		for _, executions := range shard.executions {
+			for _, execution := range executions {
+				state.Executions = append(state.Executions, toPersistedExecution(execution))
+			}
+		}
+		for _, amendments := range shard.amendments {
+			for _, amendment := range amendments {
+				state.Amendments = append(state.Amendments, toPersistedAmendment(amendment))
+			}
+		}
+		for orderID, res := range shard.reservations {
+			state.Reservations = append(state.Reservations, toPersistedReservation(orderID, res))
+		}
+	}
+	unlockAccounts := r.accounts.lockAll()
+	for _, stripe := range r.accounts.stripes {
+		for key, b := range stripe.balances {
+			state.Balances = append(state.Balances, toPersistedBalance(key, b))
+		}
+	}
+	r.marketStates.mu.Lock()
+	for _, override := range r.marketStates.overrides {
+		state.MarketStates = append(state.MarketStates, toPersistedMarketState(override))
+	}
+	seq, err := r.persistence.log.Rotate()
+	r.marketStates.mu.Unlock()
+	unlockAccounts()
+	unlock()
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := wal.WriteSnapshot(r.persistence.dir, seq, payload); err != nil {
+		return err
+	}
+	if err := r.persistence.log.Remove(seq); err != nil {
+		r.logger.WithContext(ctx).Warn(layer, method, "failed to remove covered wal segments", err, "segment", seq)
+	}
+
+	r.logger.WithContext(ctx).Debug(layer, method, "snapshot written", "segment", seq, "orders", len(state.Orders))
+	return nil
+}
+
+func (r *orderInMemoryRepo) startSnapshotter(ctx context.Context) {
+	const layer = "repo"
+	const method = "startSnapshotter"
+
+	if r.persistence == nil || r.persistence.snapshotInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.persistence.snapshotInterval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.Snapshot(ctx); err != nil {
+					r.logger.WithContext(ctx).Error(layer, method, "failed to write snapshot", err)
+				}
+			}
+		}
+	}()
+}
+
+// Close writes a final snapshot and closes the log.
+func (r *orderInMemoryRepo) Close(ctx context.Context) error {
+	if r.persistence == nil {
+		return nil
+	}
+
+	snapshotErr := r.Snapshot(ctx)
+	return errors.Join(snapshotErr, r.persistence.log.Close())
+}
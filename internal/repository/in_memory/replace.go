@@ -0,0 +1,251 @@
+package in_memory_repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/errs"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"github.com/FlyKarlik/orderService/pkg/decimal"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// amendOrder applies req to a copy of order and returns it with the
+// history entry describing the change. A replace that only lowers the
+// quantity at the same price keeps the order's place in the queue; any
+// other change sends it to the back.
+func amendOrder(order domain.Order, req domain.ReplaceOrderRequest, at time.Time) (domain.Order, domain.OrderAmendment, error) {
+	if order.Status.IsFinal() {
+		return domain.Order{}, domain.OrderAmendment{}, errs.ErrOrderNotOpen
+	}
+
+	// Prices are compared as decimals, so "100.50" does not count as a new
+	// price for an order at "100.5" and the stored form is kept.
+	price := *order.Price
+	samePrice := true
+	if req.Price != nil {
+		current, err := decimal.Parse(*order.Price)
+		if err != nil {
+			return domain.Order{}, domain.OrderAmendment{}, fmt.Errorf("order price: %w", err)
+		}
+		requested, err := decimal.Parse(*req.Price)
+		if err != nil {
+			return domain.Order{}, domain.OrderAmendment{}, errs.ErrInvalidPrice
+		}
+		if requested.Cmp(current) != 0 {
+			price = *req.Price
+			samePrice = false
+		}
+	}
+	quantity := *order.Quantity
+	if req.Quantity != nil {
+		quantity = *req.Quantity
+	}
+
+	if samePrice && quantity == *order.Quantity {
+		return domain.Order{}, domain.OrderAmendment{}, errs.ErrNothingToReplace
+	}
+
+	filled, _ := fillState(order)
+	if quantity <= filled {
+		return domain.Order{}, domain.OrderAmendment{}, errs.ErrInvalidReplaceSize
+	}
+
+	keptPriority := samePrice && quantity < *order.Quantity
+	action := domain.OrderHistoryActionEnumReplace
+	amendment := domain.OrderAmendment{
+		OrderID:          order.ID,
+		Action:           &action,
+		PreviousPrice:    order.Price,
+		Price:            &price,
+		PreviousQuantity: order.Quantity,
+		Quantity:         &quantity,
+		KeptPriority:     &keptPriority,
+		AmendedAt:        &at,
+	}
+
+	remaining := quantity - filled
+	order.Price = &price
+	order.Quantity = &quantity
+	order.RemainingQuantity = &remaining
+	order.UpdatedAt = &at
+
+	return order, amendment, nil
+}
+
+// ReplaceOrder amends the price and/or quantity of an open order. In
+// matching mode the amended order is matched again unless it keeps its
+// priority, so a new price may trade immediately.
+func (r *orderInMemoryRepo) ReplaceOrder(
+	ctx context.Context,
+	req domain.ReplaceOrderRequest,
+) (domain.ReplaceOrderResponse, error) {
+	const layer = "repo"
+	const method = "ReplaceOrder"
+
+	ctx, span := r.tracer.Start(ctx, "OrderInMemoryRepo.ReplaceOrder")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("order.id", req.OrderID.String()),
+	)
+
+	var outcome matchOutcome
+	var amendment domain.OrderAmendment
+	var err error
+	if r.engine != nil {
+		outcome, amendment, err = r.replaceMatched(req)
+	} else {
+		outcome, amendment, err = r.replaceSimulated(req)
+	}
+	if err != nil {
+		span.RecordError(err)
+		r.logger.WithContext(ctx).Warn(layer, method, "failed to replace order", err,
+			"x_request_id", xRequestID,
+			"order_id", req.OrderID.String(),
+		)
+		return domain.ReplaceOrderResponse{}, err
+	}
+	order := outcome.order
+
+	if *order.Status == domain.OrderStatusEnumFilled {
+		r.metrics.OrderFilled(order.OrderType.String(), amendment.AmendedAt.Sub(*order.CreatedAt))
+	}
+	for _, maker := range outcome.counterparties {
+		if *maker.Status == domain.OrderStatusEnumFilled {
+			r.metrics.OrderFilled(maker.OrderType.String(), amendment.AmendedAt.Sub(*maker.CreatedAt))
+		}
+		r.notifier.publish(maker)
+	}
+	r.notifier.publish(order)
+
+	span.SetAttributes(
+		attribute.String("order.status", string(*order.Status)),
+		attribute.String("order.price", *order.Price),
+		attribute.Int64("order.quantity", *order.Quantity),
+		attribute.Bool("order.kept_priority", *amendment.KeptPriority),
+	)
+
+	r.logger.WithContext(ctx).Info(layer, method, "order replaced",
+		"x_request_id", xRequestID,
+		"order_id", req.OrderID.String(),
+		"previous_price", *amendment.PreviousPrice,
+		"price", *order.Price,
+		"previous_quantity", *amendment.PreviousQuantity,
+		"quantity", *order.Quantity,
+		"kept_priority", *amendment.KeptPriority,
+		"status", *order.Status,
+		"executions", len(outcome.executions),
+	)
+
+	return domain.ReplaceOrderResponse{
+		OrderID:           order.ID,
+		OrderStatus:       order.Status,
+		Price:             order.Price,
+		Quantity:          order.Quantity,
+		RemainingQuantity: order.RemainingQuantity,
+		KeptPriority:      amendment.KeptPriority,
+	}, nil
+}
+
+// replaceMatched amends a resting order under the lock of its book, taken
+// before the shard lock as in submit.
+func (r *orderInMemoryRepo) replaceMatched(req domain.ReplaceOrderRequest) (matchOutcome, domain.OrderAmendment, error) {
+	shard := r.shardFor(*req.OrderID)
+	shard.mu.RLock()
+	current, ok := shard.data[*req.OrderID]
+	shard.mu.RUnlock()
+	if !ok {
+		return matchOutcome{}, domain.OrderAmendment{}, errors.New("order not found")
+	}
+
+	book := r.engine.Book(*current.MarketID)
+	book.Lock()
+	defer book.Unlock()
+
+	shard.mu.RLock()
+	current = shard.data[*req.OrderID]
+	shard.mu.RUnlock()
+
+	if *current.OrderType != domain.OrderTypeEnumLimit && req.Price != nil {
+		return matchOutcome{}, domain.OrderAmendment{}, errs.ErrInvalidReplacePrice
+	}
+
+	order, amendment, err := amendOrder(current, req, time.Now())
+	if err != nil {
+		return matchOutcome{}, domain.OrderAmendment{}, err
+	}
+
+	if !*amendment.KeptPriority {
+		outcome, err := r.execute(book, order, &amendment)
+		return outcome, amendment, err
+	}
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if err := r.persistMatch(order, nil, nil, &amendment); err != nil {
+		return matchOutcome{}, domain.OrderAmendment{}, err
+	}
+	shard.apply(order, nil)
+	shard.amend(amendment)
+	book.Reduce(*order.ID, *order.RemainingQuantity)
+
+	return matchOutcome{order: order}, amendment, nil
+}
+
+// replaceSimulated amends an order of the simulated execution mode, where
+// there is no book to requeue on.
+func (r *orderInMemoryRepo) replaceSimulated(req domain.ReplaceOrderRequest) (matchOutcome, domain.OrderAmendment, error) {
+	shard := r.shardFor(*req.OrderID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	current, ok := shard.data[*req.OrderID]
+	if !ok {
+		return matchOutcome{}, domain.OrderAmendment{}, errors.New("order not found")
+	}
+	if *current.OrderType != domain.OrderTypeEnumLimit && req.Price != nil {
+		return matchOutcome{}, domain.OrderAmendment{}, errs.ErrInvalidReplacePrice
+	}
+
+	order, amendment, err := amendOrder(current, req, time.Now())
+	if err != nil {
+		return matchOutcome{}, domain.OrderAmendment{}, err
+	}
+
+	if err := r.persistMatch(order, nil, nil, &amendment); err != nil {
+		return matchOutcome{}, domain.OrderAmendment{}, err
+	}
+	shard.apply(order, nil)
+	shard.amend(amendment)
+
+	return matchOutcome{order: order}, amendment, nil
+}
+
+// GetOrderHistory returns the amendments of an order, oldest first.
+func (r *orderInMemoryRepo) GetOrderHistory(ctx context.Context, orderID uuid.UUID) ([]domain.OrderAmendment, error) {
+	_, span := r.tracer.Start(ctx, "OrderInMemoryRepo.GetOrderHistory")
+	defer span.End()
+
+	shard := r.shardFor(orderID)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	if _, ok := shard.data[orderID]; !ok {
+		err := errors.New("order not found")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	amendments := make([]domain.OrderAmendment, len(shard.amendments[orderID]))
+	copy(amendments, shard.amendments[orderID])
+	return amendments, nil
+}
@@ -0,0 +1,87 @@
+package in_memory_repo
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/errs"
+	"github.com/google/uuid"
+)
+
+func restingOrder(price string, quantity, filled int64) domain.Order {
+	id := uuid.New()
+	status := domain.OrderStatusEnumPending
+	if filled > 0 {
+		status = domain.OrderStatusEnumPartiallyFilled
+	}
+	remaining := quantity - filled
+	return domain.Order{
+		ID:                &id,
+		Price:             &price,
+		Quantity:          &quantity,
+		Status:            &status,
+		FilledQuantity:    &filled,
+		RemainingQuantity: &remaining,
+	}
+}
+
+func TestAmendOrder(t *testing.T) {
+	price := func(p string) *string { return &p }
+	quantity := func(q int64) *int64 { return &q }
+
+	cases := []struct {
+		name          string
+		req           domain.ReplaceOrderRequest
+		want          error
+		wantPrice     string
+		wantRemaining int64
+		wantKept      bool
+	}{
+		{name: "same price and quantity", req: domain.ReplaceOrderRequest{Price: price("100.5"), Quantity: quantity(10)}, want: errs.ErrNothingToReplace},
+		{name: "same price written differently", req: domain.ReplaceOrderRequest{Price: price("100.50")}, want: errs.ErrNothingToReplace},
+		{
+			name:          "lower quantity at an equal price keeps priority",
+			req:           domain.ReplaceOrderRequest{Price: price("100.500"), Quantity: quantity(6)},
+			wantPrice:     "100.5",
+			wantRemaining: 4,
+			wantKept:      true,
+		},
+		{
+			name:          "higher quantity loses priority",
+			req:           domain.ReplaceOrderRequest{Quantity: quantity(12)},
+			wantPrice:     "100.5",
+			wantRemaining: 10,
+		},
+		{
+			name:          "new price loses priority",
+			req:           domain.ReplaceOrderRequest{Price: price("100.25"), Quantity: quantity(6)},
+			wantPrice:     "100.25",
+			wantRemaining: 4,
+		},
+		{name: "malformed price", req: domain.ReplaceOrderRequest{Price: price("abc")}, want: errs.ErrInvalidPrice},
+		{name: "quantity not above the filled quantity", req: domain.ReplaceOrderRequest{Quantity: quantity(2)}, want: errs.ErrInvalidReplaceSize},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			order := restingOrder("100.5", 10, 2)
+			got, amendment, err := amendOrder(order, tc.req, time.Now())
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("amendOrder() error = %v, want %v", err, tc.want)
+			}
+			if err != nil {
+				return
+			}
+			if *got.Price != tc.wantPrice || *amendment.Price != tc.wantPrice {
+				t.Fatalf("amendOrder() price = %s, amendment price = %s, want %s", *got.Price, *amendment.Price, tc.wantPrice)
+			}
+			if *got.RemainingQuantity != tc.wantRemaining {
+				t.Fatalf("amendOrder() remaining = %d, want %d", *got.RemainingQuantity, tc.wantRemaining)
+			}
+			if *amendment.KeptPriority != tc.wantKept {
+				t.Fatalf("amendOrder() kept priority = %v, want %v", *amendment.KeptPriority, tc.wantKept)
+			}
+		})
+	}
+}
@@ -4,17 +4,44 @@ import (
 	"context"
 	"time"
 
+	"github.com/FlyKarlik/orderService/config"
 	"github.com/FlyKarlik/orderService/internal/domain"
 	redis_cache "github.com/FlyKarlik/orderService/internal/repository/cache"
 	in_memory_repo "github.com/FlyKarlik/orderService/internal/repository/in_memory"
 	"github.com/FlyKarlik/orderService/pkg/cache"
+	"github.com/FlyKarlik/orderService/pkg/decimal"
 	"github.com/FlyKarlik/orderService/pkg/logger"
+	"github.com/FlyKarlik/orderService/pkg/metric"
 	"github.com/google/uuid"
 )
 
 type IOrderRepository interface {
 	CreateOrder(ctx context.Context, req domain.CreateOrderRequest) (domain.CreateOrderResponse, error)
 	GetOrderByID(ctx context.Context, ID uuid.UUID) (domain.Order, error)
+	WatchOrder(ctx context.Context, ID uuid.UUID) (<-chan domain.Order, func())
+	ListExecutions(ctx context.Context, orderID uuid.UUID) ([]domain.Execution, error)
+	ReplaceOrder(ctx context.Context, req domain.ReplaceOrderRequest) (domain.ReplaceOrderResponse, error)
+	GetOrderHistory(ctx context.Context, orderID uuid.UUID) ([]domain.OrderAmendment, error)
+	CancelOrders(ctx context.Context, filter domain.OrderFilter, reason domain.CancelReasonEnum) (domain.CancelAllOrdersResponse, error)
+	CountOpenOrders(ctx context.Context, userID, marketID uuid.UUID) (int, error)
+	Ping(ctx context.Context) error
+	Close(ctx context.Context) error
+}
+
+type IOrderBookRepository interface {
+	GetOrderBook(ctx context.Context, marketID uuid.UUID, depth int) (domain.GetOrderBookResponse, error)
+	SubscribeOrderBook(ctx context.Context, marketID uuid.UUID) (domain.GetOrderBookResponse, <-chan domain.StreamOrderBookResponse, func(), error)
+	ReferencePrice(ctx context.Context, marketID uuid.UUID) (decimal.Decimal, bool, error)
+}
+
+type IAccountRepository interface {
+	GetBalances(ctx context.Context, userID uuid.UUID) ([]domain.Balance, error)
+	AdjustBalance(ctx context.Context, req domain.AdjustBalanceRequest) (domain.Balance, error)
+}
+
+type IMarketStateRepository interface {
+	GetMarketStateOverride(ctx context.Context, marketID uuid.UUID) (domain.MarketStateOverride, bool, error)
+	SetMarketStateOverride(ctx context.Context, override domain.MarketStateOverride) error
 }
 
 type IMarketsCache interface {
@@ -25,17 +52,40 @@ type IMarketsCache interface {
 
 type Repository interface {
 	IOrderRepository
+	IOrderBookRepository
+	IAccountRepository
+	IMarketStateRepository
 	IMarketsCache
 }
 
 type repositoryImpl struct {
 	IOrderRepository
+	IOrderBookRepository
+	IAccountRepository
+	IMarketStateRepository
 	IMarketsCache
 }
 
-func New(l logger.Logger, redisClient cache.RedisClient) *repositoryImpl {
-	return &repositoryImpl{
-		IOrderRepository: in_memory_repo.SetupOrderRepo(l),
-		IMarketsCache:    redis_cache.NewMarketsCache(l, redisClient),
+// New builds the repositories. Background workers owned by the repositories
+// run until ctx is cancelled.
+func New(
+	ctx context.Context,
+	cfg *config.Config,
+	l logger.Logger,
+	redisClient cache.RedisClient,
+	serializer *cache.Serializer,
+	metrics *metric.Registry,
+) (*repositoryImpl, error) {
+	orderRepo, err := in_memory_repo.SetupOrderRepo(ctx, cfg, l, metrics)
+	if err != nil {
+		return nil, err
 	}
+
+	return &repositoryImpl{
+		IOrderRepository:       orderRepo,
+		IOrderBookRepository:   orderRepo,
+		IAccountRepository:     orderRepo,
+		IMarketStateRepository: orderRepo,
+		IMarketsCache:          redis_cache.NewMarketsCache(l, redisClient, serializer, metrics),
+	}, nil
 }
@@ -0,0 +1,139 @@
+package risk
+
+import (
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/errs"
+	"github.com/FlyKarlik/orderService/pkg/decimal"
+	"github.com/google/uuid"
+)
+
+// Order is what the checks see of an incoming order, together with the
+// market state the pipeline looked up for it.
+type Order struct {
+	UserID   uuid.UUID
+	MarketID uuid.UUID
+	Type     domain.OrderTypeEnum
+	Side     domain.OrderSideEnum
+	// Price is the limit price; it is zero for market orders.
+	Price    decimal.Decimal
+	Quantity int64
+
+	// OpenOrders is the number of open orders the user has in the market.
+	OpenOrders int
+	// ReferencePrice is the mid of the market's book. HasReference is false
+	// when the book is empty.
+	ReferencePrice decimal.Decimal
+	HasReference   bool
+}
+
+// ICheck is one pre-trade risk check. Checks are pure: they decide from the
+// order and the limits of its market alone.
+type ICheck interface {
+	// Name labels the check in metrics and logs.
+	Name() string
+	Check(order Order, limits Limits) error
+}
+
+// MaxQuantityCheck caps the quantity of a single order.
+type MaxQuantityCheck struct{}
+
+func (MaxQuantityCheck) Name() string { return "max_order_quantity" }
+
+func (MaxQuantityCheck) Check(order Order, limits Limits) error {
+	if limits.MaxOrderQuantity > 0 && order.Quantity > limits.MaxOrderQuantity {
+		return errs.ErrRiskMaxQuantity
+	}
+	return nil
+}
+
+// MaxNotionalCheck caps price times quantity. Market orders are valued at
+// the reference price and pass when there is none.
+type MaxNotionalCheck struct{}
+
+func (MaxNotionalCheck) Name() string { return "max_order_notional" }
+
+func (MaxNotionalCheck) Check(order Order, limits Limits) error {
+	if limits.MaxOrderNotional.IsZero() {
+		return nil
+	}
+
+	price := order.Price
+	if order.Type == domain.OrderTypeEnumMarket {
+		if !order.HasReference {
+			return nil
+		}
+		price = order.ReferencePrice
+	}
+
+	if price.MulInt(order.Quantity).Cmp(limits.MaxOrderNotional) > 0 {
+		return errs.ErrRiskMaxNotional
+	}
+	return nil
+}
+
+// MaxOpenOrdersCheck caps the open orders a user may have in a market,
+// counting the incoming one.
+type MaxOpenOrdersCheck struct{}
+
+func (MaxOpenOrdersCheck) Name() string { return "max_open_orders" }
+
+func (MaxOpenOrdersCheck) Check(order Order, limits Limits) error {
+	if limits.MaxOpenOrders > 0 && order.OpenOrders >= limits.MaxOpenOrders {
+		return errs.ErrRiskMaxOpenOrders
+	}
+	return nil
+}
+
+// FatFingerCheck rejects limit prices off from the reference price by a
+// factor of FatFingerPriceRatio or more.
+type FatFingerCheck struct{}
+
+func (FatFingerCheck) Name() string { return "fat_finger" }
+
+func (FatFingerCheck) Check(order Order, limits Limits) error {
+	if limits.FatFingerPriceRatio.IsZero() || order.Type != domain.OrderTypeEnumLimit || !order.HasReference {
+		return nil
+	}
+
+	ratio := limits.FatFingerPriceRatio
+	tooHigh := order.Price.Cmp(order.ReferencePrice.Mul(ratio)) >= 0
+	tooLow := order.Price.Mul(ratio).Cmp(order.ReferencePrice) <= 0
+	if tooHigh || tooLow {
+		return errs.ErrRiskFatFinger
+	}
+	return nil
+}
+
+// PriceBandCheck keeps limit prices within PriceBandPercent of the
+// reference price.
+type PriceBandCheck struct{}
+
+func (PriceBandCheck) Name() string { return "price_band" }
+
+func (PriceBandCheck) Check(order Order, limits Limits) error {
+	if limits.PriceBandPercent.IsZero() || order.Type != domain.OrderTypeEnumLimit || !order.HasReference {
+		return nil
+	}
+
+	deviation := order.Price.Sub(order.ReferencePrice)
+	if deviation.Sign() < 0 {
+		deviation = deviation.Neg()
+	}
+	if deviation.MulInt(100).Cmp(order.ReferencePrice.Mul(limits.PriceBandPercent)) > 0 {
+		return errs.ErrRiskPriceBand
+	}
+	return nil
+}
+
+// DefaultChecks returns the checks of the pipeline in the order they run.
+// The fat finger check runs before the price band so a misplaced digit is
+// reported as such.
+func DefaultChecks() []ICheck {
+	return []ICheck{
+		MaxQuantityCheck{},
+		MaxNotionalCheck{},
+		MaxOpenOrdersCheck{},
+		FatFingerCheck{},
+		PriceBandCheck{},
+	}
+}
@@ -0,0 +1,134 @@
+package risk
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/errs"
+	"github.com/FlyKarlik/orderService/pkg/decimal"
+)
+
+func limitOrder(price string, quantity int64) Order {
+	return Order{
+		Type:     domain.OrderTypeEnumLimit,
+		Side:     domain.OrderSideEnumBuy,
+		Price:    decimal.MustParse(price),
+		Quantity: quantity,
+	}
+}
+
+func marketOrder(quantity int64) Order {
+	return Order{
+		Type:     domain.OrderTypeEnumMarket,
+		Side:     domain.OrderSideEnumBuy,
+		Quantity: quantity,
+	}
+}
+
+func withReference(order Order, reference string) Order {
+	order.ReferencePrice = decimal.MustParse(reference)
+	order.HasReference = true
+	return order
+}
+
+func withOpenOrders(order Order, open int) Order {
+	order.OpenOrders = open
+	return order
+}
+
+type checkCase struct {
+	name   string
+	order  Order
+	limits Limits
+	want   error
+}
+
+func runCheckCases(t *testing.T, check ICheck, cases []checkCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := check.Check(tc.order, tc.limits)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("%s.Check() = %v, want %v", check.Name(), err, tc.want)
+			}
+		})
+	}
+}
+
+func TestMaxQuantityCheck(t *testing.T) {
+	limits := Limits{MaxOrderQuantity: 100}
+	runCheckCases(t, MaxQuantityCheck{}, []checkCase{
+		{name: "disabled", order: limitOrder("1", 1_000_000)},
+		{name: "below limit", order: limitOrder("1", 99), limits: limits},
+		{name: "at limit", order: limitOrder("1", 100), limits: limits},
+		{name: "above limit", order: limitOrder("1", 101), limits: limits, want: errs.ErrRiskMaxQuantity},
+		{name: "market order above limit", order: marketOrder(101), limits: limits, want: errs.ErrRiskMaxQuantity},
+	})
+}
+
+func TestMaxNotionalCheck(t *testing.T) {
+	limits := Limits{MaxOrderNotional: decimal.MustParse("1000")}
+	runCheckCases(t, MaxNotionalCheck{}, []checkCase{
+		{name: "disabled", order: limitOrder("1000", 1000)},
+		{name: "at limit", order: limitOrder("10", 100), limits: limits},
+		{name: "above limit", order: limitOrder("10.01", 100), limits: limits, want: errs.ErrRiskMaxNotional},
+		{name: "market order without reference", order: marketOrder(1_000_000), limits: limits},
+		{name: "market order within limit", order: withReference(marketOrder(100), "10"), limits: limits},
+		{name: "market order above limit", order: withReference(marketOrder(101), "10"), limits: limits, want: errs.ErrRiskMaxNotional},
+	})
+}
+
+func TestMaxOpenOrdersCheck(t *testing.T) {
+	limits := Limits{MaxOpenOrders: 3}
+	runCheckCases(t, MaxOpenOrdersCheck{}, []checkCase{
+		{name: "disabled", order: withOpenOrders(limitOrder("1", 1), 100)},
+		{name: "below limit", order: withOpenOrders(limitOrder("1", 1), 2), limits: limits},
+		{name: "incoming order reaches limit", order: withOpenOrders(limitOrder("1", 1), 3), limits: limits, want: errs.ErrRiskMaxOpenOrders},
+	})
+}
+
+func TestFatFingerCheck(t *testing.T) {
+	limits := Limits{FatFingerPriceRatio: decimal.MustParse("10")}
+	runCheckCases(t, FatFingerCheck{}, []checkCase{
+		{name: "disabled", order: withReference(limitOrder("1000", 1), "10")},
+		{name: "without reference", order: limitOrder("1000", 1), limits: limits},
+		{name: "market order", order: withReference(marketOrder(1), "10"), limits: limits},
+		{name: "near reference", order: withReference(limitOrder("99.99", 1), "10"), limits: limits},
+		{name: "ratio above", order: withReference(limitOrder("100", 1), "10"), limits: limits, want: errs.ErrRiskFatFinger},
+		{name: "ratio below", order: withReference(limitOrder("1", 1), "10"), limits: limits, want: errs.ErrRiskFatFinger},
+		{name: "just above ratio below", order: withReference(limitOrder("1.01", 1), "10"), limits: limits},
+	})
+}
+
+func TestPriceBandCheck(t *testing.T) {
+	limits := Limits{PriceBandPercent: decimal.MustParse("5")}
+	runCheckCases(t, PriceBandCheck{}, []checkCase{
+		{name: "disabled", order: withReference(limitOrder("200", 1), "100")},
+		{name: "without reference", order: limitOrder("200", 1), limits: limits},
+		{name: "market order", order: withReference(marketOrder(1), "100"), limits: limits},
+		{name: "upper edge", order: withReference(limitOrder("105", 1), "100"), limits: limits},
+		{name: "lower edge", order: withReference(limitOrder("95", 1), "100"), limits: limits},
+		{name: "above band", order: withReference(limitOrder("105.01", 1), "100"), limits: limits, want: errs.ErrRiskPriceBand},
+		{name: "below band", order: withReference(limitOrder("94.99", 1), "100"), limits: limits, want: errs.ErrRiskPriceBand},
+	})
+}
+
+func TestDefaultChecksOrder(t *testing.T) {
+	// A misplaced digit breaks the price band too; it must be reported by
+	// the fat finger check that runs first.
+	order := withReference(limitOrder("1000", 1), "100")
+	limits := Limits{
+		FatFingerPriceRatio: decimal.MustParse("5"),
+		PriceBandPercent:    decimal.MustParse("5"),
+	}
+	for _, check := range DefaultChecks() {
+		if err := check.Check(order, limits); err != nil {
+			if !errors.Is(err, errs.ErrRiskFatFinger) {
+				t.Fatalf("first rejection = %v from %s, want %v", err, check.Name(), errs.ErrRiskFatFinger)
+			}
+			return
+		}
+	}
+	t.Fatal("order was not rejected")
+}
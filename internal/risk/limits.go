@@ -0,0 +1,169 @@
+package risk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/FlyKarlik/orderService/config"
+	"github.com/FlyKarlik/orderService/pkg/decimal"
+	"github.com/google/uuid"
+)
+
+// Limits are the risk limits of one market. A zero limit disables its check.
+type Limits struct {
+	MaxOrderQuantity int64
+	MaxOrderNotional decimal.Decimal
+	MaxOpenOrders    int
+	// PriceBandPercent is how far, in percent of the reference price, a
+	// limit price may be from it.
+	PriceBandPercent decimal.Decimal
+	// FatFingerPriceRatio rejects limit prices at least this many times
+	// above or below the reference price, the typical result of a misplaced
+	// digit. It must be greater than 1.
+	FatFingerPriceRatio decimal.Decimal
+}
+
+// limitTable holds the default limits and the per-market overrides.
+type limitTable struct {
+	defaults Limits
+	markets  map[uuid.UUID]Limits
+}
+
+func (t *limitTable) forMarket(marketID uuid.UUID) Limits {
+	if limits, ok := t.markets[marketID]; ok {
+		return limits
+	}
+	return t.defaults
+}
+
+func parseLimitTable(cfg config.RiskConfig) (*limitTable, error) {
+	defaults := Limits{
+		MaxOrderQuantity: cfg.MaxOrderQuantity,
+		MaxOpenOrders:    cfg.MaxOpenOrders,
+	}
+
+	values := map[string]string{
+		"max_order_notional":     cfg.MaxOrderNotional,
+		"price_band_percent":     cfg.PriceBandPercent,
+		"fat_finger_price_ratio": cfg.FatFingerPriceRatio,
+	}
+	for name, value := range values {
+		if err := defaults.set(name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	markets, err := parseMarketLimits(cfg.MarketLimits, defaults)
+	if err != nil {
+		return nil, err
+	}
+
+	return &limitTable{defaults: defaults, markets: markets}, nil
+}
+
+// parseMarketLimits parses overrides in the
+// "market_id:limit=value;limit=value,market_id:..." form. Limits a market
+// does not override keep their default.
+func parseMarketLimits(s string, defaults Limits) (map[uuid.UUID]Limits, error) {
+	markets := make(map[uuid.UUID]Limits)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		rawID, overrides, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid market limits %q, expected market_id:limit=value", entry)
+		}
+		marketID, err := uuid.Parse(strings.TrimSpace(rawID))
+		if err != nil {
+			return nil, fmt.Errorf("invalid market id in market limits %q: %w", entry, err)
+		}
+
+		limits := defaults
+		if existing, ok := markets[marketID]; ok {
+			limits = existing
+		}
+		for _, pair := range strings.Split(overrides, ";") {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+			name, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid market limit %q, expected limit=value", pair)
+			}
+			if err := limits.set(strings.TrimSpace(name), strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("market %s: %w", marketID, err)
+			}
+		}
+		markets[marketID] = limits
+	}
+	return markets, nil
+}
+
+// set assigns the limit called name. An empty value disables it.
+func (l *Limits) set(name, value string) error {
+	switch name {
+	case "max_order_quantity":
+		n, err := parseCount(name, value)
+		if err != nil {
+			return err
+		}
+		l.MaxOrderQuantity = n
+	case "max_open_orders":
+		n, err := parseCount(name, value)
+		if err != nil {
+			return err
+		}
+		l.MaxOpenOrders = int(n)
+	case "max_order_notional":
+		d, err := parseDecimal(name, value)
+		if err != nil {
+			return err
+		}
+		l.MaxOrderNotional = d
+	case "price_band_percent":
+		d, err := parseDecimal(name, value)
+		if err != nil {
+			return err
+		}
+		l.PriceBandPercent = d
+	case "fat_finger_price_ratio":
+		d, err := parseDecimal(name, value)
+		if err != nil {
+			return err
+		}
+		if !d.IsZero() && d.Cmp(decimal.FromInt(1)) <= 0 {
+			return fmt.Errorf("%s must be greater than 1, got %s", name, value)
+		}
+		l.FatFingerPriceRatio = d
+	default:
+		return fmt.Errorf("unknown risk limit %q", name)
+	}
+	return nil
+}
+
+func parseCount(name, value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer, got %q", name, value)
+	}
+	return n, nil
+}
+
+func parseDecimal(name, value string) (decimal.Decimal, error) {
+	if value == "" {
+		return decimal.Zero, nil
+	}
+	d, err := decimal.Parse(value)
+	if err != nil || d.Sign() < 0 {
+		return decimal.Zero, fmt.Errorf("%s must be a non-negative decimal, got %q", name, value)
+	}
+	return d, nil
+}
@@ -0,0 +1,63 @@
+package risk
+
+import (
+	"testing"
+
+	"github.com/FlyKarlik/orderService/config"
+	"github.com/FlyKarlik/orderService/pkg/decimal"
+	"github.com/google/uuid"
+)
+
+func TestParseLimitTable(t *testing.T) {
+	marketID := uuid.MustParse("0b7c4a5e-2f0d-4c1e-9a3b-6d8e1f2a3b4c")
+	table, err := parseLimitTable(config.RiskConfig{
+		MaxOrderQuantity:    1000,
+		MaxOrderNotional:    "50000",
+		MaxOpenOrders:       10,
+		PriceBandPercent:    "5",
+		FatFingerPriceRatio: "10",
+		MarketLimits:        marketID.String() + ":max_order_quantity=500;price_band_percent=",
+	})
+	if err != nil {
+		t.Fatalf("parseLimitTable() error = %v", err)
+	}
+
+	defaults := table.forMarket(uuid.New())
+	if defaults.MaxOrderQuantity != 1000 || defaults.MaxOpenOrders != 10 ||
+		!defaults.MaxOrderNotional.Equal(decimal.FromInt(50000)) ||
+		!defaults.PriceBandPercent.Equal(decimal.FromInt(5)) ||
+		!defaults.FatFingerPriceRatio.Equal(decimal.FromInt(10)) {
+		t.Fatalf("default limits = %+v", defaults)
+	}
+
+	market := table.forMarket(marketID)
+	if market.MaxOrderQuantity != 500 {
+		t.Errorf("market MaxOrderQuantity = %d, want 500", market.MaxOrderQuantity)
+	}
+	if !market.PriceBandPercent.IsZero() {
+		t.Errorf("market PriceBandPercent = %s, want disabled", market.PriceBandPercent)
+	}
+	if market.MaxOpenOrders != 10 || !market.MaxOrderNotional.Equal(decimal.FromInt(50000)) {
+		t.Errorf("market limits it does not override = %+v, want the defaults", market)
+	}
+}
+
+func TestParseLimitTableErrors(t *testing.T) {
+	marketID := uuid.New().String()
+	cases := map[string]config.RiskConfig{
+		"negative notional":        {MaxOrderNotional: "-1"},
+		"fat finger ratio of one":  {FatFingerPriceRatio: "1"},
+		"market without limits":    {MarketLimits: marketID},
+		"invalid market id":        {MarketLimits: "btc:max_order_quantity=1"},
+		"unknown limit":            {MarketLimits: marketID + ":max_leverage=3"},
+		"limit without value":      {MarketLimits: marketID + ":max_order_quantity"},
+		"negative market quantity": {MarketLimits: marketID + ":max_order_quantity=-1"},
+	}
+	for name, cfg := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseLimitTable(cfg); err == nil {
+				t.Fatal("parseLimitTable() error = nil, want an error")
+			}
+		})
+	}
+}
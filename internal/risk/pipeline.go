@@ -0,0 +1,215 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/FlyKarlik/orderService/config"
+	"github.com/FlyKarlik/orderService/internal/domain"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"github.com/FlyKarlik/orderService/pkg/decimal"
+	"github.com/FlyKarlik/orderService/pkg/logger"
+	"github.com/FlyKarlik/orderService/pkg/metric"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// IMarketData is the market state the pipeline looks up for an order.
+type IMarketData interface {
+	CountOpenOrders(ctx context.Context, userID, marketID uuid.UUID) (int, error)
+	ReferencePrice(ctx context.Context, marketID uuid.UUID) (decimal.Decimal, bool, error)
+}
+
+// IPipeline runs the pre-trade risk checks of an order before it is stored.
+type IPipeline interface {
+	Check(ctx context.Context, req domain.CreateOrderRequest) error
+	CheckAmendment(ctx context.Context, order domain.Order) error
+	ApplyConfig(cfg *config.Config)
+}
+
+type pipelineSettings struct {
+	enabled bool
+	limits  *limitTable
+}
+
+type pipeline struct {
+	logger   logger.Logger
+	tracer   trace.Tracer
+	metrics  *metric.Registry
+	data     IMarketData
+	checks   []ICheck
+	settings atomic.Pointer[pipelineSettings]
+}
+
+// New builds the pipeline with DefaultChecks. It fails when the limits in
+// cfg do not parse.
+func New(cfg *config.Config, l logger.Logger, data IMarketData, metrics *metric.Registry) (*pipeline, error) {
+	limits, err := parseLimitTable(cfg.Risk)
+	if err != nil {
+		return nil, fmt.Errorf("risk limits: %w", err)
+	}
+
+	p := &pipeline{
+		logger:  l,
+		tracer:  otel.Tracer("order-service/risk"),
+		metrics: metrics,
+		data:    data,
+		checks:  DefaultChecks(),
+	}
+	p.settings.Store(&pipelineSettings{enabled: cfg.Risk.Enabled, limits: limits})
+	return p, nil
+}
+
+// ApplyConfig picks up new limits. Limits that do not parse are logged and
+// the previous ones stay in force.
+func (p *pipeline) ApplyConfig(cfg *config.Config) {
+	const layer = "risk"
+	const method = "ApplyConfig"
+
+	limits, err := parseLimitTable(cfg.Risk)
+	if err != nil {
+		p.logger.Error(layer, method, "invalid risk limits, keeping the previous ones", err)
+		return
+	}
+	p.settings.Store(&pipelineSettings{enabled: cfg.Risk.Enabled, limits: limits})
+}
+
+// Check runs every check against req and returns the error of the first
+// that rejects it. The open order count is read without holding off
+// concurrent orders of the same user, so simultaneous orders can exceed
+// the limit by the number in flight.
+func (p *pipeline) Check(ctx context.Context, req domain.CreateOrderRequest) error {
+	side := domain.OrderSideEnumBuy
+	if req.Side != nil {
+		side = *req.Side
+	}
+	return p.run(ctx, "riskPipeline.Check", incoming{
+		userID:    *req.UserID,
+		marketID:  *req.MarketID,
+		orderType: *req.OrderType,
+		side:      side,
+		price:     *req.Price,
+		quantity:  *req.Quantity,
+	})
+}
+
+// CheckAmendment runs every check against order as a replace would leave
+// it, with its new price and total quantity. The order is already open, so
+// it is left out of the open order count.
+func (p *pipeline) CheckAmendment(ctx context.Context, order domain.Order) error {
+	return p.run(ctx, "riskPipeline.CheckAmendment", incoming{
+		userID:    *order.UserID,
+		marketID:  *order.MarketID,
+		orderType: *order.OrderType,
+		side:      *order.Side,
+		price:     *order.Price,
+		quantity:  *order.Quantity,
+		replacing: true,
+	})
+}
+
+// incoming is an order about to be stored, new or amended.
+type incoming struct {
+	userID    uuid.UUID
+	marketID  uuid.UUID
+	orderType domain.OrderTypeEnum
+	side      domain.OrderSideEnum
+	price     string
+	quantity  int64
+	// replacing is set when the order is already open.
+	replacing bool
+}
+
+func (p *pipeline) run(ctx context.Context, spanName string, in incoming) error {
+	const layer = "risk"
+	const method = "Check"
+
+	settings := p.settings.Load()
+	if !settings.enabled {
+		return nil
+	}
+
+	ctx, span := p.tracer.Start(ctx, spanName)
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("market.id", in.marketID.String()),
+		attribute.Bool("order.replacing", in.replacing),
+	)
+
+	limits := settings.limits.forMarket(in.marketID)
+	order, err := p.order(ctx, in, limits)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	for _, check := range p.checks {
+		if err := check.Check(order, limits); err != nil {
+			p.metrics.RiskRejected(check.Name())
+			span.RecordError(err)
+			span.SetAttributes(attribute.String("risk.rejected_by", check.Name()))
+
+			p.logger.WithContext(ctx).Warn(layer, method, "order rejected by risk check", err,
+				"x_request_id", xRequestID,
+				"check", check.Name(),
+				"user_id", order.UserID.String(),
+				"market_id", order.MarketID.String(),
+				"price", order.Price.String(),
+				"quantity", order.Quantity,
+				"reference_price", order.ReferencePrice.String(),
+				"open_orders", order.OpenOrders,
+				"replacing", in.replacing,
+			)
+			return err
+		}
+	}
+	return nil
+}
+
+// order builds the check input of in, looking up only the market state
+// that an enabled limit needs.
+func (p *pipeline) order(ctx context.Context, in incoming, limits Limits) (Order, error) {
+	order := Order{
+		UserID:   in.userID,
+		MarketID: in.marketID,
+		Type:     in.orderType,
+		Side:     in.side,
+		Quantity: in.quantity,
+	}
+	if order.Type == domain.OrderTypeEnumLimit {
+		price, err := decimal.Parse(in.price)
+		if err != nil {
+			return Order{}, fmt.Errorf("order price: %w", err)
+		}
+		order.Price = price
+	}
+
+	if limits.MaxOpenOrders > 0 {
+		open, err := p.data.CountOpenOrders(ctx, order.UserID, order.MarketID)
+		if err != nil {
+			return Order{}, fmt.Errorf("count open orders: %w", err)
+		}
+		if in.replacing && open > 0 {
+			open--
+		}
+		order.OpenOrders = open
+	}
+
+	needsReference := !limits.PriceBandPercent.IsZero() || !limits.FatFingerPriceRatio.IsZero() ||
+		(!limits.MaxOrderNotional.IsZero() && order.Type == domain.OrderTypeEnumMarket)
+	if needsReference {
+		reference, ok, err := p.data.ReferencePrice(ctx, order.MarketID)
+		if err != nil {
+			return Order{}, fmt.Errorf("reference price: %w", err)
+		}
+		order.ReferencePrice, order.HasReference = reference, ok
+	}
+
+	return order, nil
+}
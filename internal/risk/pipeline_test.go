@@ -0,0 +1,137 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/FlyKarlik/orderService/config"
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/errs"
+	"github.com/FlyKarlik/orderService/pkg/decimal"
+	"github.com/FlyKarlik/orderService/pkg/logger"
+	"github.com/FlyKarlik/orderService/pkg/metric"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type stubMarketData struct {
+	open      int
+	reference string
+}
+
+func (s stubMarketData) CountOpenOrders(context.Context, uuid.UUID, uuid.UUID) (int, error) {
+	return s.open, nil
+}
+
+func (s stubMarketData) ReferencePrice(context.Context, uuid.UUID) (decimal.Decimal, bool, error) {
+	if s.reference == "" {
+		return decimal.Zero, false, nil
+	}
+	return decimal.MustParse(s.reference), true, nil
+}
+
+func newTestPipeline(t *testing.T, risk config.RiskConfig, data IMarketData) *pipeline {
+	t.Helper()
+	cfg := &config.Config{Risk: risk}
+	cfg.OrderService.LogLevel = "error"
+	l, err := logger.New(cfg)
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+	p, err := New(cfg, l, data, metric.NewRegistry(prometheus.NewRegistry()))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return p
+}
+
+func createRequest(price string, quantity int64) domain.CreateOrderRequest {
+	userID, marketID := uuid.New(), uuid.New()
+	orderType := domain.OrderTypeEnumLimit
+	return domain.CreateOrderRequest{
+		UserID:    &userID,
+		MarketID:  &marketID,
+		OrderType: &orderType,
+		Price:     &price,
+		Quantity:  &quantity,
+	}
+}
+
+func openOrder(price string, quantity int64) domain.Order {
+	id, userID, marketID := uuid.New(), uuid.New(), uuid.New()
+	orderType := domain.OrderTypeEnumLimit
+	side := domain.OrderSideEnumSell
+	return domain.Order{
+		ID:        &id,
+		UserID:    &userID,
+		MarketID:  &marketID,
+		OrderType: &orderType,
+		Side:      &side,
+		Price:     &price,
+		Quantity:  &quantity,
+	}
+}
+
+func TestPipelineCheck(t *testing.T) {
+	p := newTestPipeline(t, config.RiskConfig{
+		Enabled:          true,
+		MaxOrderQuantity: 100,
+		PriceBandPercent: "5",
+	}, stubMarketData{reference: "100"})
+
+	if err := p.Check(context.Background(), createRequest("101", 100)); err != nil {
+		t.Fatalf("Check() within limits = %v, want nil", err)
+	}
+	if err := p.Check(context.Background(), createRequest("101", 101)); !errors.Is(err, errs.ErrRiskMaxQuantity) {
+		t.Fatalf("Check() above quantity = %v, want %v", err, errs.ErrRiskMaxQuantity)
+	}
+	if err := p.Check(context.Background(), createRequest("110", 1)); !errors.Is(err, errs.ErrRiskPriceBand) {
+		t.Fatalf("Check() outside band = %v, want %v", err, errs.ErrRiskPriceBand)
+	}
+}
+
+func TestPipelineDisabled(t *testing.T) {
+	p := newTestPipeline(t, config.RiskConfig{MaxOrderQuantity: 1}, stubMarketData{})
+	if err := p.Check(context.Background(), createRequest("1", 1000)); err != nil {
+		t.Fatalf("Check() with risk disabled = %v, want nil", err)
+	}
+}
+
+func TestPipelineCheckAmendment(t *testing.T) {
+	p := newTestPipeline(t, config.RiskConfig{
+		Enabled:          true,
+		MaxOrderQuantity: 100,
+		MaxOpenOrders:    3,
+		PriceBandPercent: "5",
+	}, stubMarketData{open: 3, reference: "100"})
+
+	// The user is at the open order limit, but the replaced order is one
+	// of those three and does not count against itself.
+	if err := p.CheckAmendment(context.Background(), openOrder("102", 50)); err != nil {
+		t.Fatalf("CheckAmendment() at open order limit = %v, want nil", err)
+	}
+	if err := p.Check(context.Background(), createRequest("102", 50)); !errors.Is(err, errs.ErrRiskMaxOpenOrders) {
+		t.Fatalf("Check() at open order limit = %v, want %v", err, errs.ErrRiskMaxOpenOrders)
+	}
+	if err := p.CheckAmendment(context.Background(), openOrder("102", 101)); !errors.Is(err, errs.ErrRiskMaxQuantity) {
+		t.Fatalf("CheckAmendment() above quantity = %v, want %v", err, errs.ErrRiskMaxQuantity)
+	}
+	if err := p.CheckAmendment(context.Background(), openOrder("90", 50)); !errors.Is(err, errs.ErrRiskPriceBand) {
+		t.Fatalf("CheckAmendment() outside band = %v, want %v", err, errs.ErrRiskPriceBand)
+	}
+}
+
+func TestPipelineApplyConfigKeepsLimitsOnError(t *testing.T) {
+	p := newTestPipeline(t, config.RiskConfig{Enabled: true, MaxOrderQuantity: 10}, stubMarketData{})
+
+	p.ApplyConfig(&config.Config{Risk: config.RiskConfig{Enabled: true, MarketLimits: "not-a-market"}})
+	if err := p.Check(context.Background(), createRequest("1", 11)); !errors.Is(err, errs.ErrRiskMaxQuantity) {
+		t.Fatalf("Check() after invalid reload = %v, want the previous limits to hold", err)
+	}
+
+	p.ApplyConfig(&config.Config{Risk: config.RiskConfig{Enabled: true, MaxOrderQuantity: 20}})
+	if err := p.Check(context.Background(), createRequest("1", 11)); err != nil {
+		t.Fatalf("Check() after reload = %v, want nil", err)
+	}
+}
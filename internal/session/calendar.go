@@ -0,0 +1,81 @@
+package session
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/FlyKarlik/orderService/config"
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// ICalendar tells which markets are inside their trading sessions.
+type ICalendar interface {
+	// StateAt returns the scheduled state of marketID at t. ok is false
+	// when the market has no schedule.
+	StateAt(marketID uuid.UUID, t time.Time) (state domain.MarketStateEnum, ok bool)
+	ApplyConfig(cfg *config.Config)
+}
+
+type calendarSettings struct {
+	location  *time.Location
+	schedules map[uuid.UUID]Schedule
+}
+
+type calendar struct {
+	logger   logger.Logger
+	settings atomic.Pointer[calendarSettings]
+}
+
+// New builds the calendar. It fails when the schedules or the time zone in
+// cfg do not parse.
+func New(cfg *config.Config, l logger.Logger) (*calendar, error) {
+	settings, err := parseSettings(cfg.MarketSessions)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &calendar{logger: l}
+	c.settings.Store(settings)
+	return c, nil
+}
+
+func parseSettings(cfg config.MarketSessionsConfig) (*calendarSettings, error) {
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("market sessions timezone: %w", err)
+	}
+	schedules, err := parseSchedules(cfg.Schedules)
+	if err != nil {
+		return nil, fmt.Errorf("market sessions: %w", err)
+	}
+	return &calendarSettings{location: location, schedules: schedules}, nil
+}
+
+// ApplyConfig picks up new schedules. Schedules that do not parse are
+// logged and the previous ones stay in force.
+func (c *calendar) ApplyConfig(cfg *config.Config) {
+	const layer = "session"
+	const method = "ApplyConfig"
+
+	settings, err := parseSettings(cfg.MarketSessions)
+	if err != nil {
+		c.logger.Error(layer, method, "invalid market sessions, keeping the previous ones", err)
+		return
+	}
+	c.settings.Store(settings)
+}
+
+func (c *calendar) StateAt(marketID uuid.UUID, t time.Time) (domain.MarketStateEnum, bool) {
+	settings := c.settings.Load()
+	schedule, ok := settings.schedules[marketID]
+	if !ok {
+		return "", false
+	}
+	if schedule.IsOpen(t.In(settings.location)) {
+		return domain.MarketStateEnumOpen, true
+	}
+	return domain.MarketStateEnumClosed, true
+}
@@ -0,0 +1,164 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const minutesPerDay = 24 * 60
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// window is one trading session: it opens at start on each of its days and
+// lasts until end, on the next day when end is not after start. Both are
+// minutes since midnight.
+type window struct {
+	days  [7]bool
+	start int
+	end   int
+}
+
+// contains reports whether the session is open at minute of day on weekday.
+func (w window) contains(weekday time.Weekday, minute int) bool {
+	if w.end > w.start {
+		return w.days[weekday] && minute >= w.start && minute < w.end
+	}
+	// The session runs past midnight: it is open from start on its own days
+	// and until end on the day after.
+	previous := (weekday + 6) % 7
+	return (w.days[weekday] && minute >= w.start) || (w.days[previous] && minute < w.end)
+}
+
+// Schedule is the set of trading sessions of one market.
+type Schedule struct {
+	windows []window
+}
+
+// IsOpen reports whether one of the sessions is open at t. t must already
+// be in the schedule's time zone.
+func (s Schedule) IsOpen(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	for _, w := range s.windows {
+		if w.contains(t.Weekday(), minute) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSchedules parses "market_id:days hh:mm-hh:mm;days hh:mm-hh:mm,...".
+func parseSchedules(s string) (map[uuid.UUID]Schedule, error) {
+	schedules := make(map[uuid.UUID]Schedule)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		id, sessions, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("schedule %q: want market_id:days hh:mm-hh:mm", entry)
+		}
+		marketID, err := uuid.Parse(strings.TrimSpace(id))
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", entry, err)
+		}
+
+		var schedule Schedule
+		for _, session := range strings.Split(sessions, ";") {
+			w, err := parseWindow(strings.TrimSpace(session))
+			if err != nil {
+				return nil, fmt.Errorf("schedule of market %s: %w", marketID, err)
+			}
+			schedule.windows = append(schedule.windows, w)
+		}
+		schedules[marketID] = schedule
+	}
+	return schedules, nil
+}
+
+// parseWindow parses "days hh:mm-hh:mm".
+func parseWindow(s string) (window, error) {
+	days, hours, ok := strings.Cut(s, " ")
+	if !ok {
+		return window{}, fmt.Errorf("session %q: want days hh:mm-hh:mm", s)
+	}
+
+	var w window
+	var err error
+	if w.days, err = parseDays(days); err != nil {
+		return window{}, fmt.Errorf("session %q: %w", s, err)
+	}
+
+	start, end, ok := strings.Cut(strings.TrimSpace(hours), "-")
+	if !ok {
+		return window{}, fmt.Errorf("session %q: want hh:mm-hh:mm", s)
+	}
+	if w.start, err = parseClock(start); err != nil {
+		return window{}, fmt.Errorf("session %q: %w", s, err)
+	}
+	if w.end, err = parseClock(end); err != nil {
+		return window{}, fmt.Errorf("session %q: %w", s, err)
+	}
+	if w.start == w.end {
+		return window{}, fmt.Errorf("session %q: start and end are equal", s)
+	}
+	return w, nil
+}
+
+// parseDays parses "daily", a single day or an inclusive range of days,
+// which may wrap around the end of the week ("fri-mon").
+func parseDays(s string) ([7]bool, error) {
+	var days [7]bool
+	s = strings.ToLower(s)
+	if s == "daily" {
+		for i := range days {
+			days[i] = true
+		}
+		return days, nil
+	}
+
+	first, last, isRange := strings.Cut(s, "-")
+	from, ok := weekdays[first]
+	if !ok {
+		return days, fmt.Errorf("unknown day %q", first)
+	}
+	to := from
+	if isRange {
+		if to, ok = weekdays[last]; !ok {
+			return days, fmt.Errorf("unknown day %q", last)
+		}
+	}
+
+	for day := from; ; day = (day + 1) % 7 {
+		days[day] = true
+		if day == to {
+			break
+		}
+	}
+	return days, nil
+}
+
+// parseClock parses "hh:mm" into minutes since midnight. "24:00" is
+// accepted as the end of the day.
+func parseClock(s string) (int, error) {
+	if s == "24:00" {
+		return minutesPerDay, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
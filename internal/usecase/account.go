@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/errs"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"github.com/FlyKarlik/orderService/pkg/decimal"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// GetBalances returns the balances of a user to that user or to an admin.
+func (o *orderUsecase) GetBalances(
+	ctx context.Context,
+	req domain.GetBalancesRequest,
+) (domain.GetBalancesResponse, error) {
+	const layer = "usecase"
+	const method = "GetBalances"
+
+	ctx, span := o.tracer.Start(ctx, "orderUsecase.GetBalances")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("user.id", req.UserID.String()),
+	)
+
+	if err := o.authorizeUser(ctx, method, *req.UserID); err != nil {
+		span.RecordError(err)
+		return domain.GetBalancesResponse{}, err
+	}
+
+	balances, err := o.repo.GetBalances(ctx, *req.UserID)
+	if err != nil {
+		span.RecordError(err)
+		var customErr *errs.CustomError
+		if errors.As(err, &customErr) {
+			return domain.GetBalancesResponse{}, customErr
+		}
+		o.logger.WithContext(ctx).Error(layer, method, "failed to get balances", err,
+			"x_request_id", xRequestID,
+			"user_id", req.UserID.String(),
+		)
+		return domain.GetBalancesResponse{}, errs.ErrUnknown
+	}
+
+	o.logger.WithContext(ctx).Debug(layer, method, "balances retrieved",
+		"x_request_id", xRequestID,
+		"user_id", req.UserID.String(),
+		"assets", len(balances),
+	)
+
+	return domain.GetBalancesResponse{Balances: balances}, nil
+}
+
+// AdjustBalance moves funds in or out of an account. It requires an admin
+// identity: deposits and withdrawals are settled outside the service and
+// booked here by an operator or a funding system.
+func (o *orderUsecase) AdjustBalance(
+	ctx context.Context,
+	req domain.AdjustBalanceRequest,
+) (domain.AdjustBalanceResponse, error) {
+	const layer = "usecase"
+	const method = "AdjustBalance"
+
+	ctx, span := o.tracer.Start(ctx, "orderUsecase.AdjustBalance")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("user.id", req.UserID.String()),
+		attribute.String("account.asset", *req.Asset),
+	)
+
+	if err := o.authorizeAdmin(ctx, method); err != nil {
+		span.RecordError(err)
+		return domain.AdjustBalanceResponse{}, err
+	}
+
+	asset := domain.NormalizeAsset(*req.Asset)
+	if asset == "" {
+		return domain.AdjustBalanceResponse{}, errs.ErrInvalidAsset
+	}
+	req.Asset = &asset
+
+	if amount, err := decimal.Parse(*req.Amount); err != nil || amount.IsZero() {
+		o.logger.WithContext(ctx).Warn(layer, method, "invalid amount", err,
+			"x_request_id", xRequestID,
+			"amount", *req.Amount,
+		)
+		return domain.AdjustBalanceResponse{}, errs.ErrInvalidAmount
+	}
+
+	balance, err := o.repo.AdjustBalance(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		var customErr *errs.CustomError
+		if errors.As(err, &customErr) {
+			o.logger.WithContext(ctx).Warn(layer, method, "balance adjustment rejected", err,
+				"x_request_id", xRequestID,
+				"user_id", req.UserID.String(),
+				"asset", asset,
+			)
+			return domain.AdjustBalanceResponse{}, customErr
+		}
+		o.logger.WithContext(ctx).Error(layer, method, "failed to adjust balance", err,
+			"x_request_id", xRequestID,
+			"user_id", req.UserID.String(),
+			"asset", asset,
+		)
+		return domain.AdjustBalanceResponse{}, errs.ErrUnknown
+	}
+
+	return domain.AdjustBalanceResponse{Balance: balance}, nil
+}
@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/FlyKarlik/orderService/internal/errs"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"github.com/google/uuid"
+)
+
+// parseIdentities splits a comma separated list of client identities.
+func parseIdentities(s string) []string {
+	var identities []string
+	for _, identity := range strings.Split(s, ",") {
+		if identity = strings.TrimSpace(identity); identity != "" {
+			identities = append(identities, identity)
+		}
+	}
+	return identities
+}
+
+// isAdmin reports whether identity is listed in AUTH_ADMIN_IDENTITIES.
+func (o *orderUsecase) isAdmin(identity shared_context.PeerIdentity) bool {
+	return slices.ContainsFunc(o.settings.Load().adminIdentities, identity.Matches)
+}
+
+// authorizeAdmin fails unless the request came over mutual TLS from an
+// admin identity. Roles sent with the request are chosen by the client and
+// are never trusted for admin operations.
+func (o *orderUsecase) authorizeAdmin(ctx context.Context, method string) error {
+	const layer = "usecase"
+
+	identity, ok := shared_context.PeerIdentityFromContext(ctx)
+	if !ok {
+		o.logger.WithContext(ctx).Warn(layer, method, "admin operation requested without a client certificate", nil,
+			"x_request_id", shared_context.XRequestIDFromContext(ctx),
+		)
+		return errs.ErrUnauthenticated
+	}
+	if !o.isAdmin(identity) {
+		o.logger.WithContext(ctx).Warn(layer, method, "admin operation requested by a non-admin identity", nil,
+			"x_request_id", shared_context.XRequestIDFromContext(ctx),
+			"peer_common_name", identity.CommonName,
+			"peer_uris", identity.URIs,
+		)
+		return errs.ErrPermissionDenied
+	}
+	return nil
+}
+
+// authorizeUser fails unless the request came over mutual TLS from userID,
+// identified by the common name of its certificate, or from an admin.
+func (o *orderUsecase) authorizeUser(ctx context.Context, method string, userID uuid.UUID) error {
+	const layer = "usecase"
+
+	identity, ok := shared_context.PeerIdentityFromContext(ctx)
+	if !ok {
+		o.logger.WithContext(ctx).Warn(layer, method, "account access requested without a client certificate", nil,
+			"x_request_id", shared_context.XRequestIDFromContext(ctx),
+		)
+		return errs.ErrUnauthenticated
+	}
+	if id, err := uuid.Parse(identity.CommonName); (err != nil || id != userID) && !o.isAdmin(identity) {
+		o.logger.WithContext(ctx).Warn(layer, method, "account access requested by another identity", nil,
+			"x_request_id", shared_context.XRequestIDFromContext(ctx),
+			"user_id", userID.String(),
+			"peer_common_name", identity.CommonName,
+		)
+		return errs.ErrPermissionDenied
+	}
+	return nil
+}
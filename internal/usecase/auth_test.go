@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/FlyKarlik/orderService/config"
+	"github.com/FlyKarlik/orderService/internal/errs"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"github.com/FlyKarlik/orderService/pkg/logger"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+func newAuthTestUsecase(t *testing.T, adminIdentities string) *orderUsecase {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.OrderService.LogLevel = "error"
+	cfg.Auth.AdminIdentities = adminIdentities
+	l, err := logger.New(cfg)
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+	o := &orderUsecase{logger: l, tracer: otel.Tracer("order-service/usecase")}
+	o.applyConfig(cfg)
+	return o
+}
+
+func withIdentity(commonName string, uris ...string) context.Context {
+	return shared_context.WithPeerIdentity(context.Background(), shared_context.PeerIdentity{
+		CommonName: commonName,
+		URIs:       uris,
+	})
+}
+
+func TestAuthorizeAdmin(t *testing.T) {
+	o := newAuthTestUsecase(t, "ops-console, spiffe://exchange/admin")
+
+	cases := []struct {
+		name string
+		ctx  context.Context
+		want error
+	}{
+		{name: "no client certificate", ctx: context.Background(), want: errs.ErrUnauthenticated},
+		{name: "admin common name", ctx: withIdentity("ops-console")},
+		{name: "admin uri", ctx: withIdentity("node-7", "spiffe://exchange/admin")},
+		{name: "other identity", ctx: withIdentity("trading-bot", "spiffe://exchange/bot"), want: errs.ErrPermissionDenied},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := o.authorizeAdmin(tc.ctx, "test"); !errors.Is(err, tc.want) {
+				t.Fatalf("authorizeAdmin() = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizeAdminWithoutAdmins(t *testing.T) {
+	o := newAuthTestUsecase(t, "")
+	if err := o.authorizeAdmin(withIdentity(""), "test"); !errors.Is(err, errs.ErrPermissionDenied) {
+		t.Fatalf("authorizeAdmin() with no admins configured = %v, want %v", err, errs.ErrPermissionDenied)
+	}
+}
+
+func TestAuthorizeUser(t *testing.T) {
+	o := newAuthTestUsecase(t, "ops-console")
+	userID := uuid.New()
+
+	cases := []struct {
+		name string
+		ctx  context.Context
+		want error
+	}{
+		{name: "no client certificate", ctx: context.Background(), want: errs.ErrUnauthenticated},
+		{name: "same user", ctx: withIdentity(userID.String())},
+		{name: "other user", ctx: withIdentity(uuid.NewString()), want: errs.ErrPermissionDenied},
+		{name: "admin", ctx: withIdentity("ops-console")},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := o.authorizeUser(tc.ctx, "test", userID); !errors.Is(err, tc.want) {
+				t.Fatalf("authorizeUser() = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
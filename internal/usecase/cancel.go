@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/errs"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"github.com/FlyKarlik/orderService/pkg/metric"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func (o *orderUsecase) CancelAllOrders(
+	ctx context.Context,
+	req domain.CancelAllOrdersRequest,
+) (domain.CancelAllOrdersResponse, error) {
+	const layer = "usecase"
+	const method = "CancelAllOrders"
+
+	ctx, span := o.tracer.Start(ctx, "orderUsecase.CancelAllOrders")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("user.id", req.UserID.String()),
+	)
+	if req.MarketID != nil {
+		span.SetAttributes(attribute.String("market.id", req.MarketID.String()))
+	}
+
+	if err := o.authorizeUser(ctx, method, *req.UserID); err != nil {
+		span.RecordError(err)
+		return domain.CancelAllOrdersResponse{}, err
+	}
+
+	o.logger.WithContext(ctx).Info(layer, method, "cancelling orders of user",
+		"x_request_id", xRequestID,
+		"user_id", req.UserID.String(),
+		"market_id", req.MarketID,
+	)
+
+	return o.cancelOrders(ctx, domain.OrderFilter{
+		UserID:   req.UserID,
+		MarketID: req.MarketID,
+	}, domain.CancelReasonEnumUser)
+}
+
+func (o *orderUsecase) AdminCancelAllOrders(
+	ctx context.Context,
+	req domain.AdminCancelAllOrdersRequest,
+) (domain.CancelAllOrdersResponse, error) {
+	const layer = "usecase"
+	const method = "AdminCancelAllOrders"
+
+	ctx, span := o.tracer.Start(ctx, "orderUsecase.AdminCancelAllOrders")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+
+	span.SetAttributes(attribute.String("x-request-id", xRequestID))
+	if req.MarketID != nil {
+		span.SetAttributes(attribute.String("market.id", req.MarketID.String()))
+	}
+
+	if err := o.authorizeAdmin(ctx, method); err != nil {
+		span.RecordError(err)
+		return domain.CancelAllOrdersResponse{}, err
+	}
+
+	identity, _ := shared_context.PeerIdentityFromContext(ctx)
+	o.logger.WithContext(ctx).Warn(layer, method, "cancelling orders of all users", nil,
+		"x_request_id", xRequestID,
+		"market_id", req.MarketID,
+		"admin", identity.CommonName,
+	)
+
+	return o.cancelOrders(ctx, domain.OrderFilter{MarketID: req.MarketID}, domain.CancelReasonEnumAdmin)
+}
+
+// cancelMetricReasons maps cancel reasons to orders_cancelled_total labels.
+var cancelMetricReasons = map[domain.CancelReasonEnum]string{
+	domain.CancelReasonEnumUser:       metric.CancelReasonUser,
+	domain.CancelReasonEnumAdmin:      metric.CancelReasonAdmin,
+	domain.CancelReasonEnumDisconnect: metric.CancelReasonDisconnect,
+	domain.CancelReasonEnumHalt:       metric.CancelReasonHalt,
+}
+
+func (o *orderUsecase) cancelOrders(
+	ctx context.Context,
+	filter domain.OrderFilter,
+	reason domain.CancelReasonEnum,
+) (domain.CancelAllOrdersResponse, error) {
+	const layer = "usecase"
+	const method = "cancelOrders"
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+
+	resp, err := o.repo.CancelOrders(ctx, filter, reason)
+	if err != nil {
+		o.logger.WithContext(ctx).Error(layer, method, "failed to cancel orders", err,
+			"x_request_id", xRequestID,
+		)
+		return domain.CancelAllOrdersResponse{}, errs.ErrUnknown
+	}
+	o.metrics.OrderCancelled(cancelMetricReasons[reason], len(resp.Cancelled))
+
+	o.logger.WithContext(ctx).Info(layer, method, "orders cancelled",
+		"x_request_id", xRequestID,
+		"reason", reason,
+		"cancelled", len(resp.Cancelled),
+		"failed", len(resp.Failed),
+	)
+
+	return resp, nil
+}
@@ -0,0 +1,33 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/errs"
+	"github.com/google/uuid"
+)
+
+func TestCancelAllOrdersRequiresOwner(t *testing.T) {
+	o := newAuthTestUsecase(t, "ops-console")
+	userID := uuid.New()
+
+	cases := []struct {
+		name string
+		ctx  context.Context
+		want error
+	}{
+		{name: "no client certificate", ctx: context.Background(), want: errs.ErrUnauthenticated},
+		{name: "other user", ctx: withIdentity(uuid.NewString()), want: errs.ErrPermissionDenied},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := o.CancelAllOrders(tc.ctx, domain.CancelAllOrdersRequest{UserID: &userID})
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("CancelAllOrders() error = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/google/uuid"
+)
+
+// disconnectGuard tracks the cancel-on-disconnect streams of each user. When
+// the last of them drops, the user's open orders are cancelled after a grace
+// period unless a new one is opened first.
+type disconnectGuard struct {
+	mu       sync.Mutex
+	sessions map[uuid.UUID]*disconnectSession
+}
+
+type disconnectSession struct {
+	streams int
+	timer   *time.Timer
+}
+
+func newDisconnectGuard() *disconnectGuard {
+	return &disconnectGuard{sessions: make(map[uuid.UUID]*disconnectSession)}
+}
+
+// open registers a stream of userID and reports whether it disarmed a
+// pending cancellation.
+func (g *disconnectGuard) open(userID uuid.UUID) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	session, ok := g.sessions[userID]
+	if !ok {
+		session = &disconnectSession{}
+		g.sessions[userID] = session
+	}
+	session.streams++
+
+	if session.timer == nil {
+		return false
+	}
+	session.timer.Stop()
+	session.timer = nil
+	return true
+}
+
+// close unregisters a stream of userID. If the stream was disconnected and
+// was the user's last one, fire runs after grace and close reports true.
+func (g *disconnectGuard) close(userID uuid.UUID, disconnected bool, grace time.Duration, fire func()) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	session, ok := g.sessions[userID]
+	if !ok {
+		return false
+	}
+	session.streams--
+	if session.streams > 0 {
+		return false
+	}
+	if !disconnected {
+		delete(g.sessions, userID)
+		return false
+	}
+
+	// The callback takes mu, so it cannot observe the session before timer
+	// is assigned below.
+	var timer *time.Timer
+	timer = time.AfterFunc(grace, func() {
+		g.mu.Lock()
+		current, ok := g.sessions[userID]
+		if !ok || current.timer != timer {
+			g.mu.Unlock()
+			return
+		}
+		delete(g.sessions, userID)
+		g.mu.Unlock()
+
+		fire()
+	})
+	session.timer = timer
+	return true
+}
+
+// watchDisconnect registers a cancel-on-disconnect stream and returns the
+// function that unregisters it. The stream counts as disconnected when ctx,
+// the context of the client's call, has ended by the time it is
+// unregistered; a stream ended by the server, such as on shutdown, does not.
+func (o *orderUsecase) watchDisconnect(ctx context.Context, userID uuid.UUID) func() {
+	const layer = "usecase"
+	const method = "watchDisconnect"
+
+	if o.disconnects.open(userID) {
+		o.logger.WithContext(ctx).Info(layer, method, "user reconnected, cancel on disconnect disarmed",
+			"user_id", userID.String(),
+		)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			grace := o.settings.Load().cancelOnDisconnectGrace
+			disconnected := ctx.Err() != nil
+			if !o.disconnects.close(userID, disconnected, grace, func() { o.cancelOnDisconnect(userID) }) {
+				return
+			}
+
+			o.logger.WithContext(ctx).Warn(layer, method, "cancel on disconnect stream dropped, open orders will be cancelled", nil,
+				"user_id", userID.String(),
+				"grace", grace,
+			)
+		})
+	}
+}
+
+// cancelOnDisconnect cancels the open orders of a user whose
+// cancel-on-disconnect streams dropped and were not reopened in time.
+func (o *orderUsecase) cancelOnDisconnect(userID uuid.UUID) {
+	const layer = "usecase"
+	const method = "cancelOnDisconnect"
+
+	ctx, span := o.tracer.Start(context.Background(), "orderUsecase.cancelOnDisconnect")
+	defer span.End()
+
+	resp, err := o.cancelOrders(ctx, domain.OrderFilter{UserID: &userID}, domain.CancelReasonEnumDisconnect)
+	if err != nil {
+		span.RecordError(err)
+		o.logger.WithContext(ctx).Error(layer, method, "failed to cancel orders on disconnect", err,
+			"user_id", userID.String(),
+		)
+		return
+	}
+
+	o.logger.WithContext(ctx).Warn(layer, method, "orders cancelled on disconnect", nil,
+		"user_id", userID.String(),
+		"cancelled", len(resp.Cancelled),
+		"failed", len(resp.Failed),
+	)
+}
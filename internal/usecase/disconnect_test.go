@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/errs"
+	"github.com/google/uuid"
+)
+
+const testGrace = 20 * time.Millisecond
+
+// fired returns a callback for disconnectGuard.close and a channel that
+// receives once per call.
+func fired() (func(), chan struct{}) {
+	ch := make(chan struct{}, 4)
+	return func() { ch <- struct{}{} }, ch
+}
+
+func expectFired(t *testing.T, ch chan struct{}) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(50 * testGrace):
+		t.Fatal("cancellation did not fire after the grace period")
+	}
+}
+
+func expectNotFired(t *testing.T, ch chan struct{}) {
+	t.Helper()
+	select {
+	case <-ch:
+		t.Fatal("cancellation fired, want none")
+	case <-time.After(5 * testGrace):
+	}
+}
+
+func TestDisconnectGuard(t *testing.T) {
+	t.Run("last stream dropped", func(t *testing.T) {
+		g := newDisconnectGuard()
+		userID := uuid.New()
+		fire, ch := fired()
+
+		g.open(userID)
+		if !g.close(userID, true, testGrace, fire) {
+			t.Fatal("close() = false, want a cancellation armed")
+		}
+		expectFired(t, ch)
+		expectNotFired(t, ch)
+		if len(g.sessions) != 0 {
+			t.Fatalf("sessions = %v, want none after firing", g.sessions)
+		}
+	})
+
+	t.Run("reconnect within grace", func(t *testing.T) {
+		g := newDisconnectGuard()
+		userID := uuid.New()
+		fire, ch := fired()
+
+		g.open(userID)
+		g.close(userID, true, testGrace, fire)
+		if !g.open(userID) {
+			t.Fatal("open() = false, want the pending cancellation disarmed")
+		}
+		expectNotFired(t, ch)
+
+		// The new stream is guarded again once it drops.
+		if !g.close(userID, true, testGrace, fire) {
+			t.Fatal("close() after reconnect = false, want a cancellation armed")
+		}
+		expectFired(t, ch)
+	})
+
+	t.Run("multiple streams", func(t *testing.T) {
+		g := newDisconnectGuard()
+		userID := uuid.New()
+		fire, ch := fired()
+
+		g.open(userID)
+		g.open(userID)
+		if g.close(userID, true, testGrace, fire) {
+			t.Fatal("close() with a stream left = true, want nothing armed")
+		}
+		expectNotFired(t, ch)
+		if !g.close(userID, true, testGrace, fire) {
+			t.Fatal("close() of the last stream = false, want a cancellation armed")
+		}
+		expectFired(t, ch)
+	})
+
+	t.Run("other users are independent", func(t *testing.T) {
+		g := newDisconnectGuard()
+		dropped, kept := uuid.New(), uuid.New()
+		fire, ch := fired()
+
+		g.open(dropped)
+		g.open(kept)
+		g.close(dropped, true, testGrace, fire)
+		if g.open(kept) {
+			t.Fatal("open() of another user disarmed a cancellation")
+		}
+		expectFired(t, ch)
+	})
+
+	t.Run("server initiated close", func(t *testing.T) {
+		g := newDisconnectGuard()
+		userID := uuid.New()
+		fire, ch := fired()
+
+		g.open(userID)
+		if g.close(userID, false, testGrace, fire) {
+			t.Fatal("close() of a stream ended by the server = true, want nothing armed")
+		}
+		expectNotFired(t, ch)
+		if len(g.sessions) != 0 {
+			t.Fatalf("sessions = %v, want none", g.sessions)
+		}
+	})
+
+	t.Run("close without open", func(t *testing.T) {
+		g := newDisconnectGuard()
+		fire, ch := fired()
+
+		if g.close(uuid.New(), true, testGrace, fire) {
+			t.Fatal("close() of an unknown user = true, want nothing armed")
+		}
+		expectNotFired(t, ch)
+	})
+}
+
+func TestWatchDisconnectServerClose(t *testing.T) {
+	o := newAuthTestUsecase(t, "")
+	o.disconnects = newDisconnectGuard()
+	userID := uuid.New()
+
+	// The client's call is still live when the server ends the stream, as
+	// on shutdown, so nothing is armed.
+	release := o.watchDisconnect(context.Background(), userID)
+	release()
+	release()
+	if len(o.disconnects.sessions) != 0 {
+		t.Fatalf("sessions = %v, want none", o.disconnects.sessions)
+	}
+}
+
+func TestSubscribeToOrderStatusCancelOnDisconnectRequiresOwner(t *testing.T) {
+	o := newAuthTestUsecase(t, "ops-console")
+	userID, orderID := uuid.New(), uuid.New()
+
+	cases := []struct {
+		name string
+		ctx  context.Context
+		want error
+	}{
+		{name: "no client certificate", ctx: context.Background(), want: errs.ErrUnauthenticated},
+		{name: "other user", ctx: withIdentity(uuid.NewString()), want: errs.ErrPermissionDenied},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := o.SubscribeToOrderStatus(tc.ctx, domain.StreamOrderUpdatesRequest{
+				OrderID:            &orderID,
+				UserID:             &userID,
+				CancelOnDisconnect: true,
+			})
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("SubscribeToOrderStatus() error = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
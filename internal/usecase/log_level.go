@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/errs"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// GetLogLevels returns the current log levels to an admin.
+func (o *orderUsecase) GetLogLevels(ctx context.Context) (domain.LogLevels, error) {
+	const method = "GetLogLevels"
+
+	ctx, span := o.tracer.Start(ctx, "OrderUsecase.GetLogLevels")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("x-request-id", shared_context.XRequestIDFromContext(ctx)))
+
+	if err := o.authorizeAdmin(ctx, method); err != nil {
+		span.RecordError(err)
+		return domain.LogLevels{}, err
+	}
+	return o.logLevels(), nil
+}
+
+// SetLogLevel changes a log level at runtime for an admin. The change lasts
+// until a config reload changes the configured levels or the service
+// restarts.
+func (o *orderUsecase) SetLogLevel(ctx context.Context, req domain.SetLogLevelRequest) (domain.LogLevels, error) {
+	const layer = "usecase"
+	const method = "SetLogLevel"
+
+	ctx, span := o.tracer.Start(ctx, "OrderUsecase.SetLogLevel")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("log.layer", req.Layer),
+		attribute.String("log.level", req.Level),
+	)
+
+	if err := o.authorizeAdmin(ctx, method); err != nil {
+		span.RecordError(err)
+		return domain.LogLevels{}, err
+	}
+
+	if err := o.logger.Levels().SetLevel(req.Layer, req.Level); err != nil {
+		span.RecordError(err)
+		o.logger.WithContext(ctx).Warn(layer, method, "invalid log level", err,
+			"x_request_id", xRequestID,
+		)
+		return domain.LogLevels{}, errs.ErrInvalidLogLevel
+	}
+
+	identity, _ := shared_context.PeerIdentityFromContext(ctx)
+	o.logger.WithContext(ctx).Warn(layer, method, "log level changed", nil,
+		"x_request_id", xRequestID,
+		"admin", identity.CommonName,
+		"layer", req.Layer,
+		"level", req.Level,
+	)
+	return o.logLevels(), nil
+}
+
+func (o *orderUsecase) logLevels() domain.LogLevels {
+	snapshot := o.logger.Levels().Snapshot()
+	return domain.LogLevels{Level: snapshot.Level, Layers: snapshot.Layers}
+}
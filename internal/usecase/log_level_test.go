@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/errs"
+)
+
+func TestSetLogLevel(t *testing.T) {
+	cases := []struct {
+		name string
+		ctx  context.Context
+		req  domain.SetLogLevelRequest
+		want error
+	}{
+		{name: "no client certificate", ctx: context.Background(), req: domain.SetLogLevelRequest{Level: "debug"}, want: errs.ErrUnauthenticated},
+		{name: "not an admin", ctx: withIdentity("trading-bot"), req: domain.SetLogLevelRequest{Level: "debug"}, want: errs.ErrPermissionDenied},
+		{name: "service wide level", ctx: withIdentity("ops-console"), req: domain.SetLogLevelRequest{Level: "debug"}},
+		{name: "layer override", ctx: withIdentity("ops-console"), req: domain.SetLogLevelRequest{Layer: "repo", Level: "warn"}},
+		{name: "unknown level", ctx: withIdentity("ops-console"), req: domain.SetLogLevelRequest{Level: "verbose"}, want: errs.ErrInvalidLogLevel},
+		{name: "unknown layer", ctx: withIdentity("ops-console"), req: domain.SetLogLevelRequest{Layer: "reop", Level: "debug"}, want: errs.ErrInvalidLogLevel},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := newAuthTestUsecase(t, "ops-console")
+			got, err := o.SetLogLevel(tc.ctx, tc.req)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("SetLogLevel() error = %v, want %v", err, tc.want)
+			}
+			if err != nil {
+				if snapshot := o.logger.Levels().Snapshot(); snapshot.Level != "error" || len(snapshot.Layers) != 0 {
+					t.Fatalf("SetLogLevel() changed levels to %+v on error", snapshot)
+				}
+				return
+			}
+			if tc.req.Layer == "" && got.Level != tc.req.Level {
+				t.Fatalf("SetLogLevel() level = %q, want %q", got.Level, tc.req.Level)
+			}
+			if tc.req.Layer != "" && got.Layers[tc.req.Layer] != tc.req.Level {
+				t.Fatalf("SetLogLevel() layer %q = %q, want %q", tc.req.Layer, got.Layers[tc.req.Layer], tc.req.Level)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/errs"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// marketStatus resolves the state of market at now. SpotInstrumentService
+// delisting a market closes it whatever else is set; otherwise an operator
+// override wins over the schedule, and markets with neither are open.
+func (o *orderUsecase) marketStatus(ctx context.Context, market domain.Market, now time.Time) (domain.MarketStatus, error) {
+	status := domain.MarketStatus{MarketID: market.ID}
+	resolved := func(state domain.MarketStateEnum, source domain.MarketStateSourceEnum) (domain.MarketStatus, error) {
+		status.State, status.Source = &state, &source
+		return status, nil
+	}
+
+	if !market.IsListed() {
+		status.UpdatedAt = market.DeletedAt
+		return resolved(domain.MarketStateEnumClosed, domain.MarketStateSourceEnumDriver)
+	}
+
+	override, ok, err := o.repo.GetMarketStateOverride(ctx, *market.ID)
+	if err != nil {
+		return domain.MarketStatus{}, err
+	}
+	if ok {
+		status.Reason, status.UpdatedAt = override.Reason, override.UpdatedAt
+		return resolved(*override.State, domain.MarketStateSourceEnumAdmin)
+	}
+
+	if state, ok := o.sessions.StateAt(*market.ID, now); ok {
+		return resolved(state, domain.MarketStateSourceEnumSchedule)
+	}
+	return resolved(domain.MarketStateEnumOpen, domain.MarketStateSourceEnumDefault)
+}
+
+// ensureMarketOpen fails with ErrMarketNotOpen unless market accepts orders.
+func (o *orderUsecase) ensureMarketOpen(ctx context.Context, market domain.Market) error {
+	const layer = "usecase"
+	const method = "ensureMarketOpen"
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+
+	status, err := o.marketStatus(ctx, market, time.Now())
+	if err != nil {
+		o.logger.WithContext(ctx).Error(layer, method, "failed to resolve market state", err,
+			"x_request_id", xRequestID,
+			"market_id", market.ID.String(),
+		)
+		return errs.ErrUnknown
+	}
+
+	if *status.State != domain.MarketStateEnumOpen {
+		o.logger.WithContext(ctx).Warn(layer, method, "market is not open", nil,
+			"x_request_id", xRequestID,
+			"market_id", market.ID.String(),
+			"state", *status.State,
+			"source", *status.Source,
+		)
+		return errs.ErrMarketNotOpen
+	}
+	return nil
+}
+
+// findMarket returns the market with the ID of req if roles may see it.
+func (o *orderUsecase) findMarket(ctx context.Context, req domain.GetMarketStateRequest) (domain.Market, error) {
+	const layer = "usecase"
+	const method = "findMarket"
+
+	marketsResp, err := o.visibleMarkets(ctx, req.UserRoles)
+	if err != nil {
+		return domain.Market{}, errs.ErrUnknown
+	}
+
+	market, ok := marketsResp.Find(*req.MarketID)
+	if !ok {
+		o.logger.WithContext(ctx).Warn(layer, method, "market not found or not allowed", nil,
+			"x_request_id", shared_context.XRequestIDFromContext(ctx),
+			"market_id", req.MarketID.String(),
+			"user_roles", req.UserRoles,
+		)
+		return domain.Market{}, errs.ErrMarketNotFound
+	}
+	return market, nil
+}
+
+func (o *orderUsecase) GetMarketState(
+	ctx context.Context,
+	req domain.GetMarketStateRequest,
+) (domain.GetMarketStateResponse, error) {
+	const layer = "usecase"
+	const method = "GetMarketState"
+
+	ctx, span := o.tracer.Start(ctx, "orderUsecase.GetMarketState")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("market.id", req.MarketID.String()),
+	)
+
+	market, err := o.findMarket(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		return domain.GetMarketStateResponse{}, err
+	}
+
+	status, err := o.marketStatus(ctx, market, time.Now())
+	if err != nil {
+		span.RecordError(err)
+		o.logger.WithContext(ctx).Error(layer, method, "failed to resolve market state", err,
+			"x_request_id", xRequestID,
+			"market_id", req.MarketID.String(),
+		)
+		return domain.GetMarketStateResponse{}, errs.ErrUnknown
+	}
+
+	span.SetAttributes(
+		attribute.String("market.state", status.State.String()),
+		attribute.String("market.state_source", status.Source.String()),
+	)
+	return domain.GetMarketStateResponse{Status: status}, nil
+}
+
+// SetMarketState sets or clears the operator state of a market and, when
+// asked to, cancels its resting orders. The cancel runs once the new state
+// is in force, so only orders that passed the state check before the change
+// can still land behind it.
+func (o *orderUsecase) SetMarketState(
+	ctx context.Context,
+	req domain.SetMarketStateRequest,
+) (domain.SetMarketStateResponse, error) {
+	const layer = "usecase"
+	const method = "SetMarketState"
+
+	ctx, span := o.tracer.Start(ctx, "orderUsecase.SetMarketState")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("market.id", req.MarketID.String()),
+		attribute.Bool("market.cancel_resting_orders", req.CancelRestingOrders),
+	)
+
+	if err := o.authorizeAdmin(ctx, method); err != nil {
+		span.RecordError(err)
+		return domain.SetMarketStateResponse{}, err
+	}
+
+	if req.CancelRestingOrders && (req.State == nil || *req.State == domain.MarketStateEnumOpen) {
+		return domain.SetMarketStateResponse{}, errs.ErrCancelWhileTrading
+	}
+
+	market, err := o.findMarket(ctx, domain.GetMarketStateRequest{
+		MarketID:  req.MarketID,
+		UserRoles: req.UserRoles,
+	})
+	if err != nil {
+		span.RecordError(err)
+		return domain.SetMarketStateResponse{}, err
+	}
+
+	now := time.Now()
+	if err := o.repo.SetMarketStateOverride(ctx, domain.MarketStateOverride{
+		MarketID:  req.MarketID,
+		State:     req.State,
+		Reason:    req.Reason,
+		UpdatedAt: &now,
+	}); err != nil {
+		span.RecordError(err)
+		o.logger.WithContext(ctx).Error(layer, method, "failed to set market state", err,
+			"x_request_id", xRequestID,
+			"market_id", req.MarketID.String(),
+		)
+		return domain.SetMarketStateResponse{}, errs.ErrUnknown
+	}
+
+	status, err := o.marketStatus(ctx, market, now)
+	if err != nil {
+		span.RecordError(err)
+		return domain.SetMarketStateResponse{}, errs.ErrUnknown
+	}
+
+	identity, _ := shared_context.PeerIdentityFromContext(ctx)
+	o.logger.WithContext(ctx).Warn(layer, method, "market state changed", nil,
+		"x_request_id", xRequestID,
+		"admin", identity.CommonName,
+		"market_id", req.MarketID.String(),
+		"override", req.State,
+		"state", *status.State,
+		"source", *status.Source,
+		"reason", req.Reason,
+	)
+
+	resp := domain.SetMarketStateResponse{Status: status}
+	if !req.CancelRestingOrders {
+		return resp, nil
+	}
+
+	cancelled, err := o.cancelOrders(ctx, domain.OrderFilter{MarketID: req.MarketID}, domain.CancelReasonEnumHalt)
+	if err != nil {
+		span.RecordError(err)
+		return domain.SetMarketStateResponse{}, err
+	}
+	resp.Cancelled, resp.Failed = cancelled.Cancelled, cancelled.Failed
+	return resp, nil
+}
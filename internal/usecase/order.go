@@ -2,37 +2,79 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/FlyKarlik/orderService/config"
 	"github.com/FlyKarlik/orderService/internal/domain"
 	"github.com/FlyKarlik/orderService/internal/driver"
 	"github.com/FlyKarlik/orderService/internal/errs"
 	"github.com/FlyKarlik/orderService/internal/repository"
+	"github.com/FlyKarlik/orderService/internal/risk"
+	"github.com/FlyKarlik/orderService/internal/session"
 	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"github.com/FlyKarlik/orderService/pkg/decimal"
 	"github.com/FlyKarlik/orderService/pkg/logger"
+	"github.com/FlyKarlik/orderService/pkg/metric"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
 type orderUsecase struct {
-	logger logger.Logger
-	driver driver.Driver
-	repo   repository.Repository
-	tracer trace.Tracer
+	logger   logger.Logger
+	driver   driver.Driver
+	repo     repository.Repository
+	risk     risk.IPipeline
+	sessions session.ICalendar
+	tracer   trace.Tracer
+	metrics  *metric.Registry
+	settings atomic.Pointer[orderSettings]
+
+	disconnects *disconnectGuard
+}
+
+// orderSettings are the reloadable settings of orderUsecase.
+type orderSettings struct {
+	marketsCacheTTL         time.Duration
+	streamPollInterval      time.Duration
+	cancelOnDisconnectGrace time.Duration
+	adminIdentities         []string
 }
 
 func newOrderUsecase(
+	cfg *config.Config,
 	logger logger.Logger,
 	driver driver.Driver,
-	repo repository.Repository) *orderUsecase {
-	return &orderUsecase{
-		logger: logger,
-		driver: driver,
-		repo:   repo,
-		tracer: otel.Tracer("order-service/usecase"),
+	repo repository.Repository,
+	risk risk.IPipeline,
+	sessions session.ICalendar,
+	metrics *metric.Registry) *orderUsecase {
+	o := &orderUsecase{
+		logger:   logger,
+		driver:   driver,
+		repo:     repo,
+		risk:     risk,
+		sessions: sessions,
+		tracer:   otel.Tracer("order-service/usecase"),
+		metrics:  metrics,
+
+		disconnects: newDisconnectGuard(),
 	}
+	o.applyConfig(cfg)
+	return o
+}
+
+func (o *orderUsecase) applyConfig(cfg *config.Config) {
+	o.settings.Store(&orderSettings{
+		marketsCacheTTL:    cfg.OrderService.MarketsCacheTTL,
+		streamPollInterval: cfg.OrderService.StreamPollInterval,
+
+		cancelOnDisconnectGrace: cfg.OrderService.CancelOnDisconnectGrace,
+		adminIdentities:         parseIdentities(cfg.Auth.AdminIdentities),
+	})
 }
 
 func (o *orderUsecase) CreateOrder(
@@ -47,7 +89,7 @@ func (o *orderUsecase) CreateOrder(
 
 	xReqID := shared_context.XRequestIDFromContext(ctx)
 
-	o.logger.Info(layer, method, "creating order",
+	o.logger.WithContext(ctx).Info(layer, method, "creating order",
 		"x_request_id", xReqID,
 		"user_id", req.UserID.String(),
 		"market_id", req.MarketID.String(),
@@ -60,76 +102,79 @@ func (o *orderUsecase) CreateOrder(
 		attribute.String("market_id", req.MarketID.String()),
 	)
 
-	cacheKey := "markets:" + strings.Join(req.UserRoles.Strings(), ",")
-
-	var marketsResp domain.ViewMarketsResponse
-
-	cacheSpanCtx, cacheSpan := o.tracer.Start(ctx, "RedisCache.Get")
-	marketsResp, err := o.repo.Get(cacheSpanCtx, cacheKey)
-	cacheSpan.End()
-
-	if err != nil {
-		o.logger.Error(layer, method, "failed to get markets from cache", err,
+	if err := validateTimeInForce(req, time.Now()); err != nil {
+		o.logger.WithContext(ctx).Warn(layer, method, "invalid time in force", err,
 			"x_request_id", xReqID,
+			"time_in_force", req.TimeInForce,
+			"expires_at", req.ExpiresAt,
 		)
+		return domain.CreateOrderResponse{}, err
 	}
 
-	if len(marketsResp.Markets) == 0 {
-		o.logger.Info(layer, method, "cache miss — calling SpotInstrumentService",
-			"x_request_id", xReqID,
-		)
-
-		svcSpanCtx, svcSpan := o.tracer.Start(ctx, "SpotInstrumentService.ViewMarkets")
-		marketsResp, err = o.driver.ViewMarkets(svcSpanCtx, domain.ViewMarketsRequest{
-			UserRoles: req.UserRoles,
-		})
-		svcSpan.End()
-
-		if err != nil {
-			o.logger.Error(layer, method, "failed to get markets from SpotInstrumentService", err,
-				"x_request_id", xReqID,
-			)
-			return domain.CreateOrderResponse{}, errs.ErrUnknown
-		}
-
-		setSpanCtx, setSpan := o.tracer.Start(ctx, "RedisCache.Set")
-		err = o.repo.Set(setSpanCtx, cacheKey, marketsResp, 5*time.Minute)
-		setSpan.End()
-
-		if err != nil {
-			o.logger.Error(layer, method, "failed to set markets to cache", err,
-				"x_request_id", xReqID,
-			)
-		}
-	} else {
-		o.logger.Info(layer, method, "cache hit — using cached markets",
+	// Market orders trade at the prices on the book, so their price is not
+	// checked and is stored as zero.
+	if *req.OrderType == domain.OrderTypeEnumMarket {
+		zero := decimal.Zero.String()
+		req.Price = &zero
+	} else if price, err := decimal.Parse(*req.Price); err != nil || price.Sign() <= 0 {
+		o.logger.WithContext(ctx).Warn(layer, method, "invalid price", err,
 			"x_request_id", xReqID,
+			"price", *req.Price,
 		)
+		return domain.CreateOrderResponse{}, errs.ErrInvalidPrice
 	}
 
-	marketExists := false
-	for _, m := range marketsResp.Markets {
-		if m.ID.String() == req.MarketID.String() {
-			marketExists = true
-			break
-		}
+	marketsResp, err := o.visibleMarkets(ctx, req.UserRoles)
+	if err != nil {
+		o.metrics.OrderRejected(metric.RejectReasonUpstreamFailure)
+		return domain.CreateOrderResponse{}, errs.ErrUnknown
 	}
 
-	if !marketExists {
-		o.logger.Warn(layer, method, "market not found or not allowed",
+	market, ok := marketsResp.Find(*req.MarketID)
+	if !ok {
+		o.logger.WithContext(ctx).Warn(layer, method, "market not found or not allowed",
 			nil,
 			"x_request_id", xReqID,
 			"market_id", req.MarketID.String(),
 			"user_roles", req.UserRoles,
 		)
+		o.metrics.OrderRejected(metric.RejectReasonMarketNotFound)
 		return domain.CreateOrderResponse{}, errs.ErrMarketNotFound
 	}
 
+	if err := o.ensureMarketOpen(ctx, market); err != nil {
+		if err == errs.ErrMarketNotOpen {
+			o.metrics.OrderRejected(metric.RejectReasonMarketNotOpen)
+		} else {
+			o.metrics.OrderRejected(metric.RejectReasonStorageFailure)
+		}
+		return domain.CreateOrderResponse{}, err
+	}
+
+	if err := o.risk.Check(ctx, req); err != nil {
+		var customErr *errs.CustomError
+		if !errors.As(err, &customErr) {
+			o.logger.WithContext(ctx).Error(layer, method, "failed to run risk checks", err,
+				"x_request_id", xReqID,
+			)
+			o.metrics.OrderRejected(metric.RejectReasonStorageFailure)
+			return domain.CreateOrderResponse{}, errs.ErrUnknown
+		}
+		o.metrics.OrderRejected(metric.RejectReasonRisk)
+		return domain.CreateOrderResponse{}, customErr
+	}
+
 	resp, err := o.repo.CreateOrder(ctx, req)
 	if err != nil {
-		o.logger.Error(layer, method, "failed to create order", err,
+		var customErr *errs.CustomError
+		if errors.As(err, &customErr) {
+			o.metrics.OrderRejected(repoRejectReason(customErr))
+			return domain.CreateOrderResponse{}, customErr
+		}
+		o.logger.WithContext(ctx).Error(layer, method, "failed to create order", err,
 			"x_request_id", xReqID,
 		)
+		o.metrics.OrderRejected(metric.RejectReasonStorageFailure)
 		return domain.CreateOrderResponse{}, errs.ErrUnknown
 	}
 
@@ -137,8 +182,9 @@ func (o *orderUsecase) CreateOrder(
 		attribute.String("order_id", resp.OrderID.String()),
 		attribute.String("order_status", string(*resp.OrderStatus)),
 	)
+	o.metrics.OrderCreated(req.MarketID.String(), req.OrderType.String())
 
-	o.logger.Info(layer, method, "order created successfully",
+	o.logger.WithContext(ctx).Info(layer, method, "order created successfully",
 		"x_request_id", xReqID,
 		"order_id", resp.OrderID.String(),
 		"status", *resp.OrderStatus,
@@ -147,6 +193,77 @@ func (o *orderUsecase) CreateOrder(
 	return resp, nil
 }
 
+// repoRejectReason names the reason label of an order the repository
+// refused.
+func repoRejectReason(err error) string {
+	switch {
+	case errors.Is(err, errs.ErrInsufficientBalance):
+		return metric.RejectReasonFunds
+	case errors.Is(err, errs.ErrMarketAssetsUnknown):
+		return metric.RejectReasonMarketAssetsUnknown
+	case errors.Is(err, errs.ErrSelfTrade):
+		return metric.RejectReasonSelfTrade
+	default:
+		return metric.RejectReasonExecution
+	}
+}
+
+// visibleMarkets returns the markets the roles may trade, from the cache when
+// possible and from SpotInstrumentService otherwise.
+func (o *orderUsecase) visibleMarkets(ctx context.Context, roles domain.UserRolesEnum) (domain.ViewMarketsResponse, error) {
+	const layer = "usecase"
+	const method = "visibleMarkets"
+
+	xReqID := shared_context.XRequestIDFromContext(ctx)
+	cacheKey := "markets:" + strings.Join(roles.Strings(), ",")
+
+	cacheSpanCtx, cacheSpan := o.tracer.Start(ctx, "RedisCache.Get")
+	marketsResp, err := o.repo.Get(cacheSpanCtx, cacheKey)
+	cacheSpan.End()
+
+	if err != nil {
+		o.logger.WithContext(ctx).Error(layer, method, "failed to get markets from cache", err,
+			"x_request_id", xReqID,
+		)
+	}
+
+	if len(marketsResp.Markets) > 0 {
+		o.logger.WithContext(ctx).Info(layer, method, "cache hit — using cached markets",
+			"x_request_id", xReqID,
+		)
+		return marketsResp, nil
+	}
+
+	o.logger.WithContext(ctx).Info(layer, method, "cache miss — calling SpotInstrumentService",
+		"x_request_id", xReqID,
+	)
+
+	svcSpanCtx, svcSpan := o.tracer.Start(ctx, "SpotInstrumentService.ViewMarkets")
+	marketsResp, err = o.driver.ViewMarkets(svcSpanCtx, domain.ViewMarketsRequest{
+		UserRoles: roles,
+	})
+	svcSpan.End()
+
+	if err != nil {
+		o.logger.WithContext(ctx).Error(layer, method, "failed to get markets from SpotInstrumentService", err,
+			"x_request_id", xReqID,
+		)
+		return domain.ViewMarketsResponse{}, err
+	}
+
+	setSpanCtx, setSpan := o.tracer.Start(ctx, "RedisCache.Set")
+	err = o.repo.Set(setSpanCtx, cacheKey, marketsResp, o.settings.Load().marketsCacheTTL)
+	setSpan.End()
+
+	if err != nil {
+		o.logger.WithContext(ctx).Error(layer, method, "failed to set markets to cache", err,
+			"x_request_id", xReqID,
+		)
+	}
+
+	return marketsResp, nil
+}
+
 func (o *orderUsecase) GetOrderStatus(
 	ctx context.Context,
 	req domain.GetOrderStatusRequest,
@@ -165,7 +282,7 @@ func (o *orderUsecase) GetOrderStatus(
 		attribute.String("user.id", req.UserID.String()),
 	)
 
-	o.logger.Info(layer, method, "fetching order status",
+	o.logger.WithContext(ctx).Info(layer, method, "fetching order status",
 		"x_request_id", xRequestID,
 		"order_id", req.OrderID.String(),
 		"user_id", req.UserID.String(),
@@ -174,7 +291,7 @@ func (o *orderUsecase) GetOrderStatus(
 	order, err := o.repo.GetOrderByID(ctx, *req.OrderID)
 	if err != nil {
 		span.RecordError(err)
-		o.logger.Warn(layer, method, "order not found", err,
+		o.logger.WithContext(ctx).Warn(layer, method, "order not found", err,
 			"x_request_id", xRequestID,
 			"order_id", req.OrderID.String(),
 		)
@@ -188,7 +305,7 @@ func (o *orderUsecase) GetOrderStatus(
 			attribute.String("provided.user_id", req.UserID.String()),
 		)
 
-		o.logger.Warn(layer, method, "user ID mismatch", nil,
+		o.logger.WithContext(ctx).Warn(layer, method, "user ID mismatch", nil,
 			"x_request_id", xRequestID,
 			"expected_user_id", order.UserID.String(),
 			"provided_user_id", req.UserID.String(),
@@ -196,7 +313,7 @@ func (o *orderUsecase) GetOrderStatus(
 		return domain.GetOrderStatusResponse{}, errs.ErrInvalidUserID
 	}
 
-	o.logger.Info(layer, method, "order status retrieved",
+	o.logger.WithContext(ctx).Info(layer, method, "order status retrieved",
 		"x_request_id", xRequestID,
 		"order_id", req.OrderID.String(),
 		"status", *order.Status,
@@ -204,7 +321,12 @@ func (o *orderUsecase) GetOrderStatus(
 
 	span.SetAttributes(attribute.String("order.status", string(*order.Status)))
 
-	return domain.GetOrderStatusResponse{Status: order.Status}, nil
+	return domain.GetOrderStatusResponse{
+		Status:            order.Status,
+		FilledQuantity:    order.FilledQuantity,
+		RemainingQuantity: order.RemainingQuantity,
+		AvgFillPrice:      order.AvgFillPrice,
+	}, nil
 }
 
 func (o *orderUsecase) SubscribeToOrderStatus(
@@ -222,9 +344,18 @@ func (o *orderUsecase) SubscribeToOrderStatus(
 	orderID := *req.OrderID
 	userID := *req.UserID
 
+	// A cancel-on-disconnect stream can cancel every open order of the
+	// user, so only the user or an admin may open one.
+	if req.CancelOnDisconnect {
+		if err := o.authorizeUser(ctx, method, userID); err != nil {
+			span.RecordError(err)
+			return nil, nil, err
+		}
+	}
+
 	order, err := o.repo.GetOrderByID(ctx, orderID)
 	if err != nil {
-		o.logger.Warn(layer, method, "order not found",
+		o.logger.WithContext(ctx).Warn(layer, method, "order not found",
 			nil,
 			"x_request_id", xRequestID,
 			"order_id", orderID.String(),
@@ -240,7 +371,7 @@ func (o *orderUsecase) SubscribeToOrderStatus(
 			attribute.String("provided.user_id", req.UserID.String()),
 		)
 
-		o.logger.Warn(layer, method, "user ID mismatch", nil,
+		o.logger.WithContext(ctx).Warn(layer, method, "user ID mismatch", nil,
 			"x_request_id", xRequestID,
 			"expected_user_id", order.UserID.String(),
 			"provided_user_id", req.UserID.String(),
@@ -249,17 +380,27 @@ func (o *orderUsecase) SubscribeToOrderStatus(
 	}
 
 	ch := make(chan domain.StreamOrderUpdatesResponse, 10)
-	ctx, cancel := context.WithCancel(ctx)
+	streamCtx, cancel := context.WithCancel(ctx)
 
-	go o.streamOrderStatusUpdates(ctx, ch, req)
+	go o.streamOrderStatusUpdates(streamCtx, ch, req)
+
+	stop := cancel
+	if req.CancelOnDisconnect {
+		release := o.watchDisconnect(ctx, userID)
+		stop = func() {
+			cancel()
+			release()
+		}
+	}
 
-	o.logger.Info(layer, method, "started order status subscription",
+	o.logger.WithContext(ctx).Info(layer, method, "started order status subscription",
 		"x_request_id", xRequestID,
 		"order_id", orderID.String(),
 		"user_id", userID.String(),
+		"cancel_on_disconnect", req.CancelOnDisconnect,
 	)
 
-	return ch, cancel, nil
+	return ch, stop, nil
 }
 
 func (o *orderUsecase) streamOrderStatusUpdates(
@@ -270,26 +411,103 @@ func (o *orderUsecase) streamOrderStatusUpdates(
 	const layer = "usecase"
 	const method = "streamOrderStatusUpdates"
 
-	ticker := time.NewTicker(5 * time.Second)
+	o.metrics.StreamOpened()
+	defer o.metrics.StreamClosed()
+
+	pollInterval := o.settings.Load().streamPollInterval
+	ticker := time.NewTicker(pollInterval)
 	defer ticker.Stop()
 	defer close(ch)
 
-	var lastStatus *domain.OrderStatusEnum
+	updates, stopWatch := o.repo.WatchOrder(ctx, *req.OrderID)
+	defer stopWatch()
+
+	var (
+		lastStatus *domain.OrderStatusEnum
+		lastFilled int64
+		sentFills  int
+	)
+
+	// emit sends order when its status or filled quantity changed, together
+	// with the fills not sent yet, and reports whether the stream should go
+	// on.
+	emit := func(order domain.Order) bool {
+		if *order.UserID != *req.UserID {
+			o.logger.WithContext(ctx).Warn(layer, method, "unauthorized update access attempt",
+				nil,
+				"order_id", req.OrderID.String(),
+				"user_id", req.UserID.String(),
+			)
+			return true
+		}
+
+		var filled int64
+		if order.FilledQuantity != nil {
+			filled = *order.FilledQuantity
+		}
+
+		if order.Status != nil && (lastStatus == nil || *order.Status != *lastStatus || filled != lastFilled) {
+			lastStatus = order.Status
+			lastFilled = filled
+
+			var executions []domain.Execution
+			if all, err := o.repo.ListExecutions(ctx, *req.OrderID); err != nil {
+				o.logger.WithContext(ctx).Warn(layer, method, "failed to list executions", err,
+					"order_id", req.OrderID.String(),
+				)
+			} else if len(all) > sentFills {
+				executions = all[sentFills:]
+				sentFills = len(all)
+			}
+
+			o.logger.WithContext(ctx).Info(layer, method, "order status update streamed",
+				"order_id", req.OrderID.String(),
+				"user_id", req.UserID.String(),
+				"status", *order.Status,
+				"filled_quantity", filled,
+			)
+
+			select {
+			case ch <- domain.StreamOrderUpdatesResponse{
+				OrderID:           order.ID,
+				OrderStatus:       order.Status,
+				FilledQuantity:    order.FilledQuantity,
+				RemainingQuantity: order.RemainingQuantity,
+				AvgFillPrice:      order.AvgFillPrice,
+				Executions:        executions,
+				UpdatedAt:         order.UpdatedAt,
+			}:
+			case <-ctx.Done():
+				return false
+			}
+		}
+		return true
+	}
 
 LOOP:
 	for {
 		select {
 		case <-ctx.Done():
-			o.logger.Info(layer, method, "subscription cancelled",
+			o.logger.WithContext(ctx).Info(layer, method, "subscription cancelled",
 				"order_id", req.OrderID.String(),
 				"user_id", req.UserID.String(),
 			)
 			break LOOP
 
+		case order := <-updates:
+			if !emit(order) {
+				break LOOP
+			}
+
 		case <-ticker.C:
+			if interval := o.settings.Load().streamPollInterval; interval != pollInterval {
+				pollInterval = interval
+				ticker.Reset(pollInterval)
+			}
+
 			order, err := o.repo.GetOrderByID(ctx, *req.OrderID)
 			if err != nil {
-				o.logger.Warn(layer, method, "failed to fetch order",
+				o.logger.WithContext(ctx).Warn(layer, method, "failed to fetch order",
 					nil,
 					"order_id", req.OrderID.String(),
 					"err", err,
@@ -297,30 +515,32 @@ LOOP:
 				continue
 			}
 
-			if *order.UserID != *req.UserID {
-				o.logger.Warn(layer, method, "unauthorized update access attempt",
-					nil,
-					"order_id", req.OrderID.String(),
-					"user_id", req.UserID.String(),
-				)
-				continue
+			if !emit(order) {
+				break LOOP
 			}
+		}
+	}
+}
 
-			if order.Status != nil && (lastStatus == nil || *order.Status != *lastStatus) {
-				lastStatus = order.Status
-
-				o.logger.Info(layer, method, "order status update streamed",
-					"order_id", req.OrderID.String(),
-					"user_id", req.UserID.String(),
-					"status", *order.Status,
-				)
+// validateTimeInForce defaults the time in force to GTC and checks that
+// ExpiresAt is set, in the future, exactly for GTD orders.
+func validateTimeInForce(req domain.CreateOrderRequest, now time.Time) error {
+	timeInForce := domain.TimeInForceEnumGTC
+	if req.TimeInForce != nil {
+		timeInForce = *req.TimeInForce
+	}
 
-				ch <- domain.StreamOrderUpdatesResponse{
-					OrderID:     order.ID,
-					OrderStatus: order.Status,
-					UpdatedAt:   order.UpdatedAt,
-				}
-			}
+	switch timeInForce {
+	case domain.TimeInForceEnumGTD:
+		if req.ExpiresAt == nil || !req.ExpiresAt.After(now) {
+			return errs.ErrInvalidTimeInForce
+		}
+	case domain.TimeInForceEnumGTC, domain.TimeInForceEnumIOC, domain.TimeInForceEnumFOK:
+		if req.ExpiresAt != nil {
+			return errs.ErrInvalidTimeInForce
 		}
+	default:
+		return errs.ErrInvalidTimeInForce
 	}
+	return nil
 }
@@ -0,0 +1,190 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/errs"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// defaultOrderBookDepth is the number of levels per side GetOrderBook
+// returns when the request does not ask for a depth.
+const defaultOrderBookDepth = 20
+
+// checkMarketVisible applies the visibility rules of CreateOrder: a market
+// is visible when SpotInstrumentService lists it for the roles.
+func (o *orderUsecase) checkMarketVisible(ctx context.Context, req domain.GetOrderBookRequest) error {
+	markets, err := o.visibleMarkets(ctx, req.UserRoles)
+	if err != nil {
+		return errs.ErrUnknown
+	}
+	if !markets.Contains(*req.MarketID) {
+		return errs.ErrMarketNotFound
+	}
+	return nil
+}
+
+func (o *orderUsecase) GetOrderBook(
+	ctx context.Context,
+	req domain.GetOrderBookRequest,
+) (domain.GetOrderBookResponse, error) {
+	const layer = "usecase"
+	const method = "GetOrderBook"
+
+	ctx, span := o.tracer.Start(ctx, "orderUsecase.GetOrderBook")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+
+	depth := defaultOrderBookDepth
+	if req.Depth != nil {
+		depth = int(*req.Depth)
+	}
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("market.id", req.MarketID.String()),
+		attribute.Int("book.depth", depth),
+	)
+
+	if err := o.checkMarketVisible(ctx, req); err != nil {
+		span.RecordError(err)
+		o.logger.WithContext(ctx).Warn(layer, method, "market not found or not allowed", err,
+			"x_request_id", xRequestID,
+			"market_id", req.MarketID.String(),
+			"user_roles", req.UserRoles,
+		)
+		return domain.GetOrderBookResponse{}, err
+	}
+
+	book, err := o.repo.GetOrderBook(ctx, *req.MarketID, depth)
+	if err != nil {
+		span.RecordError(err)
+		o.logger.WithContext(ctx).Error(layer, method, "failed to get order book", err,
+			"x_request_id", xRequestID,
+			"market_id", req.MarketID.String(),
+		)
+		return domain.GetOrderBookResponse{}, err
+	}
+
+	return book, nil
+}
+
+func (o *orderUsecase) SubscribeToOrderBook(
+	ctx context.Context,
+	req domain.StreamOrderBookRequest,
+) (<-chan domain.StreamOrderBookResponse, func(), error) {
+	const layer = "usecase"
+	const method = "SubscribeToOrderBook"
+
+	ctx, span := o.tracer.Start(ctx, "orderUsecase.SubscribeToOrderBook")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("market.id", req.MarketID.String()),
+	)
+
+	err := o.checkMarketVisible(ctx, domain.GetOrderBookRequest{
+		MarketID:  req.MarketID,
+		UserRoles: req.UserRoles,
+	})
+	if err != nil {
+		span.RecordError(err)
+		o.logger.WithContext(ctx).Warn(layer, method, "market not found or not allowed", err,
+			"x_request_id", xRequestID,
+			"market_id", req.MarketID.String(),
+			"user_roles", req.UserRoles,
+		)
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	snapshot, updates, _, err := o.repo.SubscribeOrderBook(ctx, *req.MarketID)
+	if err != nil {
+		cancel()
+		span.RecordError(err)
+		o.logger.WithContext(ctx).Error(layer, method, "failed to subscribe to order book", err,
+			"x_request_id", xRequestID,
+			"market_id", req.MarketID.String(),
+		)
+		return nil, nil, err
+	}
+
+	ch := make(chan domain.StreamOrderBookResponse, 16)
+	go o.streamOrderBook(ctx, ch, req, snapshot, updates)
+
+	o.logger.WithContext(ctx).Info(layer, method, "started order book subscription",
+		"x_request_id", xRequestID,
+		"market_id", req.MarketID.String(),
+	)
+
+	return ch, cancel, nil
+}
+
+// streamOrderBook forwards a snapshot followed by its updates. When the
+// repository drops the subscription for falling behind, it subscribes again
+// and starts over with a new snapshot.
+func (o *orderUsecase) streamOrderBook(
+	ctx context.Context,
+	ch chan<- domain.StreamOrderBookResponse,
+	req domain.StreamOrderBookRequest,
+	snapshot domain.GetOrderBookResponse,
+	updates <-chan domain.StreamOrderBookResponse,
+) {
+	const layer = "usecase"
+	const method = "streamOrderBook"
+
+	defer close(ch)
+
+	send := func(msg domain.StreamOrderBookResponse) bool {
+		select {
+		case ch <- msg:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for {
+		if !send(domain.StreamOrderBookResponse{
+			MarketID: snapshot.MarketID,
+			Sequence: snapshot.Sequence,
+			Snapshot: &snapshot,
+		}) {
+			return
+		}
+
+		for update := range updates {
+			if !send(update) {
+				return
+			}
+		}
+
+		if ctx.Err() != nil {
+			o.logger.WithContext(ctx).Info(layer, method, "subscription cancelled",
+				"market_id", req.MarketID.String(),
+			)
+			return
+		}
+
+		o.logger.WithContext(ctx).Warn(layer, method, "order book subscriber fell behind, resynchronising", nil,
+			"market_id", req.MarketID.String(),
+			"sequence", *snapshot.Sequence,
+		)
+
+		var err error
+		snapshot, updates, _, err = o.repo.SubscribeOrderBook(ctx, *req.MarketID)
+		if err != nil {
+			o.logger.WithContext(ctx).Error(layer, method, "failed to resubscribe to order book", err,
+				"market_id", req.MarketID.String(),
+			)
+			return
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/FlyKarlik/orderService/internal/domain"
+	"github.com/FlyKarlik/orderService/internal/errs"
+	shared_context "github.com/FlyKarlik/orderService/pkg/context"
+	"github.com/FlyKarlik/orderService/pkg/decimal"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ownedOrder checks the result of looking up an order for the caller: the
+// order must exist and belong to userID.
+func (o *orderUsecase) ownedOrder(ctx context.Context, orderID, userID string, order domain.Order, err error) error {
+	const layer = "usecase"
+	const method = "ownedOrder"
+
+	if err != nil {
+		o.logger.WithContext(ctx).Warn(layer, method, "order not found", err,
+			"order_id", orderID,
+		)
+		return errs.ErrInvalidOrderID
+	}
+	if order.UserID.String() != userID {
+		o.logger.WithContext(ctx).Warn(layer, method, "user ID mismatch", nil,
+			"order_id", orderID,
+			"expected_user_id", order.UserID.String(),
+			"provided_user_id", userID,
+		)
+		return errs.ErrInvalidUserID
+	}
+	return nil
+}
+
+func (o *orderUsecase) ReplaceOrder(
+	ctx context.Context,
+	req domain.ReplaceOrderRequest,
+) (domain.ReplaceOrderResponse, error) {
+	const layer = "usecase"
+	const method = "ReplaceOrder"
+
+	ctx, span := o.tracer.Start(ctx, "orderUsecase.ReplaceOrder")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("order.id", req.OrderID.String()),
+		attribute.String("user.id", req.UserID.String()),
+	)
+
+	o.logger.WithContext(ctx).Info(layer, method, "replacing order",
+		"x_request_id", xRequestID,
+		"order_id", req.OrderID.String(),
+		"user_id", req.UserID.String(),
+		"price", req.Price,
+		"quantity", req.Quantity,
+	)
+
+	if req.Price == nil && req.Quantity == nil {
+		return domain.ReplaceOrderResponse{}, errs.ErrNothingToReplace
+	}
+
+	if req.Price != nil {
+		if price, err := decimal.Parse(*req.Price); err != nil || price.Sign() <= 0 {
+			o.logger.WithContext(ctx).Warn(layer, method, "invalid price", err,
+				"x_request_id", xRequestID,
+				"price", *req.Price,
+			)
+			return domain.ReplaceOrderResponse{}, errs.ErrInvalidPrice
+		}
+	}
+
+	order, err := o.repo.GetOrderByID(ctx, *req.OrderID)
+	if err := o.ownedOrder(ctx, req.OrderID.String(), req.UserID.String(), order, err); err != nil {
+		span.RecordError(err)
+		return domain.ReplaceOrderResponse{}, err
+	}
+
+	marketsResp, err := o.visibleMarkets(ctx, req.UserRoles)
+	if err != nil {
+		span.RecordError(err)
+		return domain.ReplaceOrderResponse{}, errs.ErrUnknown
+	}
+
+	market, ok := marketsResp.Find(*order.MarketID)
+	if !ok {
+		span.RecordError(errs.ErrMarketNotFound)
+		o.logger.WithContext(ctx).Warn(layer, method, "market not found or not allowed", nil,
+			"x_request_id", xRequestID,
+			"market_id", order.MarketID.String(),
+			"user_roles", req.UserRoles,
+		)
+		return domain.ReplaceOrderResponse{}, errs.ErrMarketNotFound
+	}
+
+	if err := o.ensureMarketOpen(ctx, market); err != nil {
+		span.RecordError(err)
+		return domain.ReplaceOrderResponse{}, err
+	}
+
+	amended := order
+	if req.Price != nil {
+		amended.Price = req.Price
+	}
+	if req.Quantity != nil {
+		amended.Quantity = req.Quantity
+	}
+	if err := o.risk.CheckAmendment(ctx, amended); err != nil {
+		span.RecordError(err)
+		var customErr *errs.CustomError
+		if errors.As(err, &customErr) {
+			return domain.ReplaceOrderResponse{}, customErr
+		}
+		o.logger.WithContext(ctx).Error(layer, method, "failed to run risk checks", err,
+			"x_request_id", xRequestID,
+			"order_id", req.OrderID.String(),
+		)
+		return domain.ReplaceOrderResponse{}, errs.ErrUnknown
+	}
+
+	resp, err := o.repo.ReplaceOrder(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		var customErr *errs.CustomError
+		if errors.As(err, &customErr) {
+			return domain.ReplaceOrderResponse{}, customErr
+		}
+		o.logger.WithContext(ctx).Error(layer, method, "failed to replace order", err,
+			"x_request_id", xRequestID,
+			"order_id", req.OrderID.String(),
+		)
+		return domain.ReplaceOrderResponse{}, errs.ErrUnknown
+	}
+
+	span.SetAttributes(
+		attribute.String("order.status", string(*resp.OrderStatus)),
+		attribute.Bool("order.kept_priority", *resp.KeptPriority),
+	)
+
+	o.logger.WithContext(ctx).Info(layer, method, "order replaced successfully",
+		"x_request_id", xRequestID,
+		"order_id", req.OrderID.String(),
+		"status", *resp.OrderStatus,
+		"kept_priority", *resp.KeptPriority,
+	)
+
+	return resp, nil
+}
+
+func (o *orderUsecase) GetOrderHistory(
+	ctx context.Context,
+	req domain.GetOrderHistoryRequest,
+) (domain.GetOrderHistoryResponse, error) {
+	const layer = "usecase"
+	const method = "GetOrderHistory"
+
+	ctx, span := o.tracer.Start(ctx, "orderUsecase.GetOrderHistory")
+	defer span.End()
+
+	xRequestID := shared_context.XRequestIDFromContext(ctx)
+
+	span.SetAttributes(
+		attribute.String("x-request-id", xRequestID),
+		attribute.String("order.id", req.OrderID.String()),
+		attribute.String("user.id", req.UserID.String()),
+	)
+
+	order, err := o.repo.GetOrderByID(ctx, *req.OrderID)
+	if err := o.ownedOrder(ctx, req.OrderID.String(), req.UserID.String(), order, err); err != nil {
+		span.RecordError(err)
+		return domain.GetOrderHistoryResponse{}, err
+	}
+
+	amendments, err := o.repo.GetOrderHistory(ctx, *req.OrderID)
+	if err != nil {
+		span.RecordError(err)
+		o.logger.WithContext(ctx).Error(layer, method, "failed to get order history", err,
+			"x_request_id", xRequestID,
+			"order_id", req.OrderID.String(),
+		)
+		return domain.GetOrderHistoryResponse{}, errs.ErrUnknown
+	}
+
+	o.logger.WithContext(ctx).Debug(layer, method, "order history retrieved",
+		"x_request_id", xRequestID,
+		"order_id", req.OrderID.String(),
+		"amendments", len(amendments),
+	)
+
+	return domain.GetOrderHistoryResponse{Amendments: amendments}, nil
+}
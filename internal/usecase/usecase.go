@@ -3,28 +3,85 @@ package usecase
 import (
 	"context"
 
+	"github.com/FlyKarlik/orderService/config"
 	"github.com/FlyKarlik/orderService/internal/domain"
 	"github.com/FlyKarlik/orderService/internal/driver"
 	"github.com/FlyKarlik/orderService/internal/repository"
+	"github.com/FlyKarlik/orderService/internal/risk"
+	"github.com/FlyKarlik/orderService/internal/session"
 	"github.com/FlyKarlik/orderService/pkg/logger"
+	"github.com/FlyKarlik/orderService/pkg/metric"
 )
 
 type IOrderUsecase interface {
 	CreateOrder(ctx context.Context, req domain.CreateOrderRequest) (domain.CreateOrderResponse, error)
 	GetOrderStatus(ctx context.Context, req domain.GetOrderStatusRequest) (domain.GetOrderStatusResponse, error)
 	SubscribeToOrderStatus(ctx context.Context, req domain.StreamOrderUpdatesRequest) (<-chan domain.StreamOrderUpdatesResponse, func(), error)
+	ReplaceOrder(ctx context.Context, req domain.ReplaceOrderRequest) (domain.ReplaceOrderResponse, error)
+	GetOrderHistory(ctx context.Context, req domain.GetOrderHistoryRequest) (domain.GetOrderHistoryResponse, error)
+	CancelAllOrders(ctx context.Context, req domain.CancelAllOrdersRequest) (domain.CancelAllOrdersResponse, error)
+	AdminCancelAllOrders(ctx context.Context, req domain.AdminCancelAllOrdersRequest) (domain.CancelAllOrdersResponse, error)
+}
+
+type IOrderBookUsecase interface {
+	GetOrderBook(ctx context.Context, req domain.GetOrderBookRequest) (domain.GetOrderBookResponse, error)
+	SubscribeToOrderBook(ctx context.Context, req domain.StreamOrderBookRequest) (<-chan domain.StreamOrderBookResponse, func(), error)
+}
+
+type IAccountUsecase interface {
+	GetBalances(ctx context.Context, req domain.GetBalancesRequest) (domain.GetBalancesResponse, error)
+	AdjustBalance(ctx context.Context, req domain.AdjustBalanceRequest) (domain.AdjustBalanceResponse, error)
+}
+
+type IMarketStateUsecase interface {
+	GetMarketState(ctx context.Context, req domain.GetMarketStateRequest) (domain.GetMarketStateResponse, error)
+	SetMarketState(ctx context.Context, req domain.SetMarketStateRequest) (domain.SetMarketStateResponse, error)
+}
+
+type ILogLevelUsecase interface {
+	GetLogLevels(ctx context.Context) (domain.LogLevels, error)
+	SetLogLevel(ctx context.Context, req domain.SetLogLevelRequest) (domain.LogLevels, error)
 }
 
 type Usecase interface {
 	IOrderUsecase
+	IOrderBookUsecase
+	IAccountUsecase
+	IMarketStateUsecase
+	ILogLevelUsecase
+	ApplyConfig(cfg *config.Config)
 }
 
 type usecaseImpl struct {
 	IOrderUsecase
+	IOrderBookUsecase
+	IAccountUsecase
+	IMarketStateUsecase
+	ILogLevelUsecase
+	orders *orderUsecase
 }
 
-func New(logger logger.Logger, driver driver.Driver, repo repository.Repository) *usecaseImpl {
+func New(
+	cfg *config.Config,
+	logger logger.Logger,
+	driver driver.Driver,
+	repo repository.Repository,
+	risk risk.IPipeline,
+	sessions session.ICalendar,
+	metrics *metric.Registry,
+) *usecaseImpl {
+	orderUsecase := newOrderUsecase(cfg, logger, driver, repo, risk, sessions, metrics)
 	return &usecaseImpl{
-		IOrderUsecase: newOrderUsecase(logger, driver, repo),
+		IOrderUsecase:       orderUsecase,
+		IOrderBookUsecase:   orderUsecase,
+		IAccountUsecase:     orderUsecase,
+		IMarketStateUsecase: orderUsecase,
+		ILogLevelUsecase:    orderUsecase,
+		orders:              orderUsecase,
 	}
 }
+
+// ApplyConfig picks up reloadable settings without a restart.
+func (u *usecaseImpl) ApplyConfig(cfg *config.Config) {
+	u.orders.applyConfig(cfg)
+}
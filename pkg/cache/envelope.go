@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/FlyKarlik/orderService/config"
+)
+
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+)
+
+// Envelope header layout:
+//
+//	[0]   magic
+//	[1]   envelope format version
+//	[2]   codec id
+//	[3]   flags, bit 0 set when the payload is gzip compressed
+//	[4:6] schema version of the cached type, big endian
+const (
+	envelopeMagic  byte = 0xCE
+	envelopeFormat byte = 1
+	envelopeHeader      = 6
+	flagCompressed byte = 1 << 0
+)
+
+// ErrVersionMismatch is returned for entries written in another format, with
+// another codec or for another schema version, including raw values written
+// before envelopes were introduced. Callers treat it as a cache miss.
+var ErrVersionMismatch = errors.New("cache entry version mismatch")
+
+// Serializer wraps values in a versioned envelope using the configured codec
+// and optional compression.
+type Serializer struct {
+	codec            Codec
+	compress         bool
+	compressMinBytes int
+}
+
+func NewSerializer(cfg *config.Config) (*Serializer, error) {
+	redisCfg := cfg.Infrastructure.RedisConfig
+
+	codec, err := NewCodec(redisCfg.CacheCodec)
+	if err != nil {
+		return nil, err
+	}
+
+	switch redisCfg.CacheCompression {
+	case CompressionNone, "", CompressionGzip:
+	default:
+		return nil, fmt.Errorf("unknown cache compression %q", redisCfg.CacheCompression)
+	}
+
+	return &Serializer{
+		codec:            codec,
+		compress:         redisCfg.CacheCompression == CompressionGzip,
+		compressMinBytes: redisCfg.CacheCompressMinBytes,
+	}, nil
+}
+
+func (s *Serializer) Codec() Codec {
+	return s.codec
+}
+
+// Marshal encodes v with the codec and prefixes the envelope header. Payloads
+// smaller than the compression threshold are stored uncompressed.
+func (s *Serializer) Marshal(schema uint16, v interface{}) ([]byte, error) {
+	payload, err := s.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var flags byte
+	if s.compress && len(payload) >= s.compressMinBytes {
+		payload, err = gzipBytes(payload)
+		if err != nil {
+			return nil, err
+		}
+		flags |= flagCompressed
+	}
+
+	data := make([]byte, envelopeHeader, envelopeHeader+len(payload))
+	data[0] = envelopeMagic
+	data[1] = envelopeFormat
+	data[2] = s.codec.ID()
+	data[3] = flags
+	binary.BigEndian.PutUint16(data[4:6], schema)
+
+	return append(data, payload...), nil
+}
+
+// Unmarshal checks the envelope header against the expected schema and the
+// configured codec and decodes the payload into v.
+func (s *Serializer) Unmarshal(data []byte, schema uint16, v interface{}) error {
+	if len(data) < envelopeHeader || data[0] != envelopeMagic {
+		return fmt.Errorf("%w: missing envelope", ErrVersionMismatch)
+	}
+	if data[1] != envelopeFormat {
+		return fmt.Errorf("%w: envelope format %d", ErrVersionMismatch, data[1])
+	}
+	if data[2] != s.codec.ID() {
+		return fmt.Errorf("%w: codec id %d", ErrVersionMismatch, data[2])
+	}
+	if got := binary.BigEndian.Uint16(data[4:6]); got != schema {
+		return fmt.Errorf("%w: schema %d, want %d", ErrVersionMismatch, got, schema)
+	}
+
+	payload := data[envelopeHeader:]
+	if data[3]&flagCompressed != 0 {
+		var err error
+		payload, err = gunzipBytes(payload)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.codec.Unmarshal(payload, v)
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gunzipBytes(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
@@ -2,27 +2,90 @@ package cache
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
 	"time"
 
 	"github.com/FlyKarlik/orderService/config"
+	"github.com/FlyKarlik/orderService/pkg/tlsconfig"
 	"github.com/go-redis/redis/v8"
 )
 
+const (
+	RedisModeStandalone = "standalone"
+	RedisModeSentinel   = "sentinel"
+	RedisModeCluster    = "cluster"
+)
+
 type RedisClient interface {
 	Get(ctx context.Context, key string) *redis.StringCmd
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
 	Del(ctx context.Context, keys ...string) *redis.IntCmd
+	Ping(ctx context.Context) *redis.StatusCmd
+	Close() error
 }
 
-func NewRedisClient(config *config.Config) RedisClient {
-	client := redis.NewClient(&redis.Options{
-		Addr:         config.Infrastructure.RedisConfig.Host + ":" + config.Infrastructure.RedisConfig.Port,
-		MinIdleConns: config.Infrastructure.RedisConfig.MinIdleConns,
-		PoolSize:     config.Infrastructure.RedisConfig.PoolSize,
-		PoolTimeout:  time.Duration(config.Infrastructure.RedisConfig.PoolSize) * time.Second,
-		Password:     "",
-		DB:           0,
-	})
-
-	return client
+// NewRedisClient builds a client for a single node, a Sentinel managed
+// master or a Redis Cluster depending on REDIS_MODE. All three satisfy
+// RedisClient, so callers do not depend on the topology. With TLS enabled
+// the certificates are reloaded until ctx is done and failed reloads are
+// reported through onTLSReloadError.
+func NewRedisClient(ctx context.Context, config *config.Config, onTLSReloadError func(error)) (RedisClient, error) {
+	redisCfg := config.Infrastructure.RedisConfig
+
+	tlsConfig, err := newRedisTLSConfig(ctx, redisCfg, onTLSReloadError)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &redis.UniversalOptions{
+		Addrs:            redisCfg.Addrs,
+		DB:               redisCfg.DB,
+		Username:         redisCfg.Username,
+		Password:         redisCfg.Password,
+		SentinelUsername: redisCfg.SentinelUsername,
+		SentinelPassword: redisCfg.SentinelPassword,
+		MasterName:       redisCfg.MasterName,
+		DialTimeout:      redisCfg.DialTimeout,
+		ReadTimeout:      redisCfg.ReadTimeout,
+		WriteTimeout:     redisCfg.WriteTimeout,
+		PoolSize:         redisCfg.PoolSize,
+		MinIdleConns:     redisCfg.MinIdleConns,
+		PoolTimeout:      redisCfg.PoolTimeout,
+		TLSConfig:        tlsConfig,
+	}
+
+	switch redisCfg.Mode {
+	case RedisModeStandalone, "":
+		opts.Addrs = []string{net.JoinHostPort(redisCfg.Host, redisCfg.Port)}
+		return redis.NewClient(opts.Simple()), nil
+	case RedisModeSentinel:
+		return redis.NewFailoverClient(opts.Failover()), nil
+	case RedisModeCluster:
+		if redisCfg.DB != 0 {
+			return nil, fmt.Errorf("redis cluster supports only DB 0, got %d", redisCfg.DB)
+		}
+		return redis.NewClusterClient(opts.Cluster()), nil
+	default:
+		return nil, fmt.Errorf("unknown redis mode %q", redisCfg.Mode)
+	}
+}
+
+func newRedisTLSConfig(ctx context.Context, redisCfg config.RedisConfig, onReloadError func(error)) (*tls.Config, error) {
+	if !redisCfg.TLSEnabled {
+		return nil, nil
+	}
+
+	reloader, err := tlsconfig.NewCertReloader(
+		redisCfg.TLSCertFile,
+		redisCfg.TLSKeyFile,
+		redisCfg.TLSCAFile,
+	)
+	if err != nil {
+		return nil, err
+	}
+	reloader.Watch(ctx, redisCfg.TLSReloadInterval, onReloadError)
+
+	return reloader.ClientConfig(redisCfg.TLSServerName), nil
 }
@@ -2,11 +2,14 @@ package grpc_client
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/FlyKarlik/orderService/config"
+	"github.com/FlyKarlik/orderService/pkg/tlsconfig"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/backoff"
 	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
@@ -22,9 +25,23 @@ type IGRPCClient interface {
 	WaitForStateChange(ctx context.Context, sourceState connectivity.State) bool
 }
 
-func New(address string, cfg *config.Config, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+// New dials address. With TLS enabled the client certificates are reloaded
+// until ctx is done and failed reloads are reported through
+// onTLSReloadError.
+func New(
+	ctx context.Context,
+	address string,
+	cfg *config.Config,
+	onTLSReloadError func(error),
+	opts ...grpc.DialOption,
+) (*grpc.ClientConn, error) {
+	creds, err := newTransportCredentials(ctx, cfg, onTLSReloadError)
+	if err != nil {
+		return nil, err
+	}
+
 	gGRPCopts := []grpc.DialOption{
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(creds),
 		grpc.WithConnectParams(grpc.ConnectParams{
 			Backoff: backoff.Config{
 				BaseDelay:  cfg.GRPCClient.BaseBackoffDelay,
@@ -35,7 +52,51 @@ func New(address string, cfg *config.Config, opts ...grpc.DialOption) (*grpc.Cli
 			MinConnectTimeout: cfg.GRPCClient.ConnectTimeout,
 		}),
 	}
+
+	callOpts := make([]grpc.CallOption, 0, 2)
+	if cfg.GRPCClient.MaxRecvMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(cfg.GRPCClient.MaxRecvMsgSize))
+	}
+	if cfg.GRPCClient.MaxSendMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(cfg.GRPCClient.MaxSendMsgSize))
+	}
+	if len(callOpts) > 0 {
+		gGRPCopts = append(gGRPCopts, grpc.WithDefaultCallOptions(callOpts...))
+	}
+
 	gGRPCopts = append(gGRPCopts, opts...)
 
 	return grpc.NewClient(address, gGRPCopts...)
 }
+
+func newTransportCredentials(ctx context.Context, cfg *config.Config, onReloadError func(error)) (credentials.TransportCredentials, error) {
+	if !cfg.GRPCClient.TLSEnabled {
+		return insecure.NewCredentials(), nil
+	}
+
+	reloader, err := tlsconfig.NewCertReloader(
+		cfg.GRPCClient.TLSCertFile,
+		cfg.GRPCClient.TLSKeyFile,
+		cfg.GRPCClient.TLSCAFile,
+	)
+	if err != nil {
+		return nil, err
+	}
+	reloader.Watch(ctx, cfg.GRPCClient.TLSReloadInterval, onReloadError)
+
+	return credentials.NewTLS(reloader.ClientConfig(cfg.GRPCClient.TLSServerName)), nil
+}
+
+// CheckConnectivity reports an error when the connection is failing or shut
+// down. An idle connection is asked to connect and considered usable.
+func CheckConnectivity(client IGRPCClient) error {
+	switch state := client.GetState(); state {
+	case connectivity.Idle:
+		client.Connect()
+		return nil
+	case connectivity.Ready, connectivity.Connecting:
+		return nil
+	default:
+		return fmt.Errorf("grpc connection to %s is %s", client.Target(), state)
+	}
+}
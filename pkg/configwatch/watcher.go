@@ -0,0 +1,152 @@
+package configwatch
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/FlyKarlik/orderService/config"
+	"github.com/FlyKarlik/orderService/pkg/logger"
+)
+
+// Subscriber is called with the new config after a reload changed at least
+// one reloadable setting. It must not block.
+type Subscriber func(cfg *config.Config)
+
+type subscription struct {
+	name string
+	fn   Subscriber
+}
+
+// Watcher reloads the configuration on SIGHUP and, when the service was
+// started with a config file and a reload interval, whenever the file
+// changes. Only settings tagged reload:"true" are applied; other changes are
+// logged and ignored until the next restart.
+type Watcher struct {
+	logger   logger.Logger
+	interval time.Duration
+
+	current atomic.Pointer[config.Config]
+
+	mu          sync.Mutex
+	subscribers []subscription
+	modTime     time.Time
+}
+
+func New(logger logger.Logger, cfg *config.Config) *Watcher {
+	w := &Watcher{
+		logger:   logger,
+		interval: cfg.OrderService.ConfigReloadInterval,
+	}
+	w.current.Store(cfg)
+	w.modTime = fileModTime(cfg.File)
+	return w
+}
+
+// Current returns the config with the latest reloadable settings applied.
+func (w *Watcher) Current() *config.Config {
+	return w.current.Load()
+}
+
+func (w *Watcher) Subscribe(name string, fn Subscriber) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.subscribers = append(w.subscribers, subscription{name: name, fn: fn})
+}
+
+// Run waits for reload triggers until ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	var poll <-chan time.Time
+	if w.Current().File != "" && w.interval > 0 {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-signals:
+			_ = w.Reload()
+		case <-poll:
+			if w.fileChanged() {
+				_ = w.Reload()
+			}
+		}
+	}
+}
+
+// Reload reads and validates the configuration from the original sources,
+// swaps in the reloadable subset and notifies subscribers. An invalid config
+// is rejected as a whole and the running one is kept.
+func (w *Watcher) Reload() error {
+	const layer = "configwatch"
+	const method = "Reload"
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	current := w.current.Load()
+
+	next, err := config.New(current.File)
+	if err == nil {
+		err = config.Validate(next)
+	}
+	if err != nil {
+		w.logger.Error(layer, method, "config reload rejected", err, "file", current.File)
+		return err
+	}
+
+	merged, result := config.MergeReloadable(current, next)
+	if len(result.Rejected) > 0 {
+		w.logger.Warn(layer, method, "ignoring changes to settings that require a restart", nil,
+			"settings", result.Rejected,
+		)
+	}
+	if len(result.Changed) == 0 {
+		w.logger.Info(layer, method, "config reloaded, nothing to apply")
+		return nil
+	}
+
+	w.current.Store(merged)
+	for _, sub := range w.subscribers {
+		w.logger.Debug(layer, method, "applying config", "subscriber", sub.name)
+		sub.fn(merged)
+	}
+
+	w.logger.Info(layer, method, "config reloaded", "changed", result.Changed)
+	return nil
+}
+
+func (w *Watcher) fileChanged() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	modTime := fileModTime(w.current.Load().File)
+	if modTime.IsZero() || modTime.Equal(w.modTime) {
+		return false
+	}
+	w.modTime = modTime
+	return true
+}
+
+func fileModTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
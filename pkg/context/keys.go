@@ -3,7 +3,8 @@ package shared_context
 type ContextKeyEnum string
 
 const (
-	ContextKeyEnumXRequestID ContextKeyEnum = "X_REQUEST_ID"
+	ContextKeyEnumXRequestID   ContextKeyEnum = "X_REQUEST_ID"
+	ContextKeyEnumPeerIdentity ContextKeyEnum = "PEER_IDENTITY"
 )
 
 func (c ContextKeyEnum) String() string {
@@ -0,0 +1,48 @@
+package shared_context
+
+import (
+	"context"
+	"crypto/x509"
+	"slices"
+)
+
+// PeerIdentity describes the client certificate presented on a mutual TLS
+// connection.
+type PeerIdentity struct {
+	CommonName   string
+	Organization []string
+	DNSNames     []string
+	URIs         []string
+	SerialNumber string
+}
+
+// NewPeerIdentity describes cert, the leaf certificate of a client.
+func NewPeerIdentity(cert *x509.Certificate) PeerIdentity {
+	uris := make([]string, 0, len(cert.URIs))
+	for _, uri := range cert.URIs {
+		uris = append(uris, uri.String())
+	}
+
+	return PeerIdentity{
+		CommonName:   cert.Subject.CommonName,
+		Organization: cert.Subject.Organization,
+		DNSNames:     cert.DNSNames,
+		URIs:         uris,
+		SerialNumber: cert.SerialNumber.String(),
+	}
+}
+
+// Matches reports whether name is the common name or one of the URI SANs
+// of the certificate.
+func (p PeerIdentity) Matches(name string) bool {
+	return name != "" && (p.CommonName == name || slices.Contains(p.URIs, name))
+}
+
+func WithPeerIdentity(ctx context.Context, identity PeerIdentity) context.Context {
+	return context.WithValue(ctx, ContextKeyEnumPeerIdentity, identity)
+}
+
+func PeerIdentityFromContext(ctx context.Context) (PeerIdentity, bool) {
+	identity, ok := ctx.Value(ContextKeyEnumPeerIdentity).(PeerIdentity)
+	return identity, ok
+}
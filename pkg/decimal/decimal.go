@@ -0,0 +1,121 @@
+// Package decimal implements exact decimal numbers for prices and amounts.
+//
+// Values are immutable; every operation returns a new Decimal. Only plain
+// decimal notation ("12", "-0.5", "100.25") is accepted, so values
+// round-trip through their string form without surprises.
+package decimal
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// MaxScale is the number of fractional digits String keeps for results that
+// are not exact decimals, such as averages.
+const MaxScale = 18
+
+var decimalPattern = regexp.MustCompile(`^[+-]?[0-9]+(\.[0-9]+)?$`)
+
+// Decimal is an exact decimal number. The zero value is 0.
+type Decimal struct {
+	rat *big.Rat
+}
+
+// Zero is the decimal 0.
+var Zero = Decimal{}
+
+// Parse parses a decimal in plain notation.
+func Parse(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if !decimalPattern.MatchString(s) {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	rat, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	return Decimal{rat: rat}, nil
+}
+
+// MustParse is Parse for constants; it panics on invalid input.
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// FromInt returns n as a decimal.
+func FromInt(n int64) Decimal {
+	return Decimal{rat: new(big.Rat).SetInt64(n)}
+}
+
+func (d Decimal) value() *big.Rat {
+	if d.rat == nil {
+		return new(big.Rat)
+	}
+	return d.rat
+}
+
+func (d Decimal) Cmp(other Decimal) int {
+	return d.value().Cmp(other.value())
+}
+
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+func (d Decimal) Sign() int {
+	return d.value().Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+func (d Decimal) Add(other Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Add(d.value(), other.value())}
+}
+
+func (d Decimal) Sub(other Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Sub(d.value(), other.value())}
+}
+
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Mul(d.value(), other.value())}
+}
+
+func (d Decimal) MulInt(n int64) Decimal {
+	return d.Mul(FromInt(n))
+}
+
+// DivInt divides d by n. It panics when n is zero.
+func (d Decimal) DivInt(n int64) Decimal {
+	return Decimal{rat: new(big.Rat).Quo(d.value(), new(big.Rat).SetInt64(n))}
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{rat: new(big.Rat).Neg(d.value())}
+}
+
+// String formats d in plain notation without trailing zeros. Values that
+// are not exact decimals are rounded to MaxScale fractional digits.
+func (d Decimal) String() string {
+	return d.StringFixed(MaxScale)
+}
+
+// StringFixed formats d rounded to at most scale fractional digits, without
+// trailing zeros.
+func (d Decimal) StringFixed(scale int) string {
+	s := d.value().FloatString(scale)
+	if strings.Contains(s, ".") {
+		s = strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
@@ -0,0 +1,170 @@
+package healthcheck
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/FlyKarlik/orderService/pkg/logger"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Check reports whether a dependency is usable. A nil error means healthy.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker drives a grpc.health.v1 server from dependency checks. Every
+// registered service is SERVING only while all of its checks pass; the
+// overall ("") status is SERVING only while every check passes.
+type Checker struct {
+	logger   logger.Logger
+	server   *health.Server
+	interval time.Duration
+	timeout  time.Duration
+
+	mu       sync.Mutex
+	checks   map[string]namedCheck
+	services map[string][]string
+	stopped  bool
+}
+
+func New(logger logger.Logger, interval, timeout time.Duration) *Checker {
+	server := health.NewServer()
+	server.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	return &Checker{
+		logger:   logger,
+		server:   server,
+		interval: interval,
+		timeout:  timeout,
+		checks:   make(map[string]namedCheck),
+		services: make(map[string][]string),
+	}
+}
+
+func (c *Checker) Server() *health.Server {
+	return c.server
+}
+
+// AddCheck registers a named dependency check. The same check may back
+// several services, it is executed once per round.
+func (c *Checker) AddCheck(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks[name] = namedCheck{name: name, check: check}
+}
+
+// AddService declares a gRPC service whose status depends on the named checks.
+func (c *Checker) AddService(service string, checkNames ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.services[service] = checkNames
+	c.server.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+// Start runs a first round synchronously and then re-evaluates every interval
+// until ctx is done or Shutdown is called.
+func (c *Checker) Start(ctx context.Context) {
+	c.runChecks(ctx)
+
+	if c.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(c.interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.runChecks(ctx)
+			}
+		}
+	}()
+}
+
+// Shutdown flips every service to NOT_SERVING permanently, so load balancers
+// stop routing new work before the listener is closed.
+func (c *Checker) Shutdown() {
+	const layer = "healthcheck"
+	const method = "Shutdown"
+
+	c.mu.Lock()
+	c.stopped = true
+	c.mu.Unlock()
+
+	c.server.Shutdown()
+	c.logger.Info(layer, method, "health status set to NOT_SERVING")
+}
+
+func (c *Checker) runChecks(ctx context.Context) {
+	const layer = "healthcheck"
+	const method = "runChecks"
+
+	c.mu.Lock()
+	checks := make([]namedCheck, 0, len(c.checks))
+	for _, check := range c.checks {
+		checks = append(checks, check)
+	}
+	c.mu.Unlock()
+
+	results := make(map[string]error, len(checks))
+	for _, nc := range checks {
+		checkCtx := ctx
+		cancel := func() {}
+		if c.timeout > 0 {
+			checkCtx, cancel = context.WithTimeout(ctx, c.timeout)
+		}
+		err := nc.check(checkCtx)
+		cancel()
+
+		results[nc.name] = err
+		if err != nil {
+			c.logger.Warn(layer, method, "dependency check failed", err, "check", nc.name)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stopped {
+		return
+	}
+
+	allHealthy := true
+	for _, err := range results {
+		if err != nil {
+			allHealthy = false
+			break
+		}
+	}
+	c.server.SetServingStatus("", servingStatus(allHealthy))
+
+	for service, names := range c.services {
+		healthy := true
+		for _, name := range names {
+			if err, ok := results[name]; ok && err != nil {
+				healthy = false
+				break
+			}
+		}
+		c.server.SetServingStatus(service, servingStatus(healthy))
+	}
+}
+
+func servingStatus(healthy bool) healthpb.HealthCheckResponse_ServingStatus {
+	if healthy {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
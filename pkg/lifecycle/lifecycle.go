@@ -0,0 +1,122 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/FlyKarlik/orderService/pkg/logger"
+	"golang.org/x/sync/errgroup"
+)
+
+// Component is a unit managed by Manager. Every hook is optional.
+//
+//   - Start runs sequentially in registration order and must not block; it is
+//     the place to bind listeners or validate resources so failures surface
+//     before anything serves traffic.
+//   - Run runs concurrently for every component and may block until ctx is
+//     done or the component fails. A non-nil error triggers shutdown.
+//   - Stop runs sequentially in reverse registration order with a context
+//     bounded by the shutdown timeout.
+type Component struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Run   func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+type Manager struct {
+	logger          logger.Logger
+	shutdownTimeout time.Duration
+	components      []Component
+}
+
+func New(logger logger.Logger, shutdownTimeout time.Duration) *Manager {
+	return &Manager{
+		logger:          logger,
+		shutdownTimeout: shutdownTimeout,
+	}
+}
+
+func (m *Manager) Add(components ...Component) {
+	m.components = append(m.components, components...)
+}
+
+// Run starts every component, blocks until ctx is cancelled or a component
+// fails, then stops the started components in reverse order.
+func (m *Manager) Run(ctx context.Context) error {
+	const layer = "lifecycle"
+	const method = "Run"
+
+	started := 0
+	for _, c := range m.components {
+		if c.Start != nil {
+			m.logger.Info(layer, method, "starting component", "component", c.Name)
+			if err := c.Start(ctx); err != nil {
+				m.logger.Error(layer, method, "failed to start component", err, "component", c.Name)
+				return errors.Join(
+					fmt.Errorf("start %s: %w", c.Name, err),
+					m.stop(m.components[:started]),
+				)
+			}
+		}
+		started++
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	for _, c := range m.components {
+		if c.Run == nil {
+			continue
+		}
+		g.Go(func() error {
+			if err := c.Run(gctx); err != nil {
+				m.logger.Error(layer, method, "component failed", err, "component", c.Name)
+				return fmt.Errorf("run %s: %w", c.Name, err)
+			}
+			return nil
+		})
+	}
+
+	m.logger.Info(layer, method, "all components started")
+	<-gctx.Done()
+	m.logger.Info(layer, method, "shutting down components")
+
+	stopErr := m.stop(m.components)
+	return errors.Join(g.Wait(), stopErr)
+}
+
+// Abort stops the components added so far in reverse order without
+// starting them. It unwinds a setup that failed before Run, so components
+// are added as soon as what they stop exists.
+func (m *Manager) Abort() error {
+	return m.stop(m.components)
+}
+
+func (m *Manager) stop(components []Component) error {
+	const layer = "lifecycle"
+	const method = "stop"
+
+	ctx := context.Background()
+	if m.shutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.shutdownTimeout)
+		defer cancel()
+	}
+
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		c := components[i]
+		if c.Stop == nil {
+			continue
+		}
+
+		m.logger.Info(layer, method, "stopping component", "component", c.Name)
+		if err := c.Stop(ctx); err != nil {
+			m.logger.Error(layer, method, "failed to stop component", err, "component", c.Name)
+			errs = append(errs, fmt.Errorf("stop %s: %w", c.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,88 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/FlyKarlik/orderService/config"
+	"github.com/FlyKarlik/orderService/pkg/logger"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.OrderService.LogLevel = "error"
+	l, err := logger.New(cfg)
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+	return New(l, 0)
+}
+
+// recorder returns components that append their name to stopped when they
+// stop, failing with err when it is set.
+func recorder(stopped *[]string, err error, names ...string) []Component {
+	components := make([]Component, 0, len(names))
+	for _, name := range names {
+		components = append(components, Component{
+			Name: name,
+			Stop: func(context.Context) error {
+				*stopped = append(*stopped, name)
+				return err
+			},
+		})
+	}
+	return components
+}
+
+func TestAbortStopsInReverseOrder(t *testing.T) {
+	m := newTestManager(t)
+	var stopped []string
+	m.Add(recorder(&stopped, nil, "tracer", "grpc_clients", "redis")...)
+
+	if err := m.Abort(); err != nil {
+		t.Fatalf("Abort() error = %v", err)
+	}
+	if want := []string{"redis", "grpc_clients", "tracer"}; !reflect.DeepEqual(stopped, want) {
+		t.Fatalf("stopped = %v, want %v", stopped, want)
+	}
+}
+
+func TestAbortJoinsStopErrors(t *testing.T) {
+	m := newTestManager(t)
+	var stopped []string
+	failure := errors.New("close failed")
+	m.Add(recorder(&stopped, failure, "tracer", "redis")...)
+
+	if err := m.Abort(); !errors.Is(err, failure) {
+		t.Fatalf("Abort() error = %v, want %v", err, failure)
+	}
+	if len(stopped) != 2 {
+		t.Fatalf("stopped = %v, want every component stopped despite errors", stopped)
+	}
+}
+
+func TestRunStopsStartedComponentsWhenStartFails(t *testing.T) {
+	m := newTestManager(t)
+	var stopped []string
+	failure := errors.New("listen failed")
+	m.Add(recorder(&stopped, nil, "tracer", "redis")...)
+	m.Add(Component{
+		Name:  "grpc_server",
+		Start: func(context.Context) error { return failure },
+		Stop: func(context.Context) error {
+			stopped = append(stopped, "grpc_server")
+			return nil
+		},
+	})
+	m.Add(recorder(&stopped, nil, "health_checker")...)
+
+	if err := m.Run(context.Background()); !errors.Is(err, failure) {
+		t.Fatalf("Run() error = %v, want %v", err, failure)
+	}
+	if want := []string{"redis", "tracer"}; !reflect.DeepEqual(stopped, want) {
+		t.Fatalf("stopped = %v, want %v", stopped, want)
+	}
+}
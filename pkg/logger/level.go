@@ -0,0 +1,222 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Levels holds the service wide log level and optional per-layer overrides
+// keyed on the layer argument passed to every logger call. It is safe for
+// concurrent use and can be changed at runtime.
+type Levels struct {
+	global zap.AtomicLevel
+	base   zapcore.Level
+
+	mu     sync.RWMutex
+	layers map[string]zapcore.Level
+}
+
+// LevelsSnapshot is a point in time view of Levels.
+type LevelsSnapshot struct {
+	Level  string
+	Layers map[string]string
+}
+
+// layers are the layer names the service logs under. Overrides are only
+// accepted for these, so the override map cannot grow with arbitrary names.
+var layers = map[string]bool{
+	"app":              true,
+	"configwatch":      true,
+	"delivery":         true,
+	"driver":           true,
+	"grpc_interceptor": true,
+	"healthcheck":      true,
+	"http_gateway":     true,
+	"http_middleware":  true,
+	"lifecycle":        true,
+	"repo":             true,
+	"risk":             true,
+	"session":          true,
+	"tracer":           true,
+	"usecase":          true,
+}
+
+func checkLayer(layer string) error {
+	if !layers[layer] {
+		return fmt.Errorf("unknown log layer %q", layer)
+	}
+	return nil
+}
+
+func newLevels(logLevel string, layerLevels string) (*Levels, error) {
+	base, err := parseLevel(logLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	layers, err := ParseLayerLevels(layerLevels)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Levels{
+		global: zap.NewAtomicLevelAt(base),
+		base:   base,
+		layers: layers,
+	}, nil
+}
+
+// ParseLayerLevels parses overrides in the "layer=level,layer=level" form,
+// e.g. "repository=warn,usecase=debug".
+func ParseLayerLevels(s string) (map[string]zapcore.Level, error) {
+	layers := make(map[string]zapcore.Level)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		layer, level, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(layer) == "" {
+			return nil, fmt.Errorf("invalid layer level %q, expected layer=level", pair)
+		}
+		if err := checkLayer(strings.TrimSpace(layer)); err != nil {
+			return nil, err
+		}
+
+		lvl, err := parseLevel(strings.TrimSpace(level))
+		if err != nil {
+			return nil, err
+		}
+		layers[strings.TrimSpace(layer)] = lvl
+	}
+
+	return layers, nil
+}
+
+// Enabled reports whether an entry at lvl written by layer should be logged.
+func (lv *Levels) Enabled(layer string, lvl zapcore.Level) bool {
+	lv.mu.RLock()
+	override, ok := lv.layers[layer]
+	lv.mu.RUnlock()
+
+	if ok {
+		return override.Enabled(lvl)
+	}
+	return lv.global.Enabled(lvl)
+}
+
+// SetLevel changes the global level when layer is empty, otherwise the
+// override for layer. An empty level removes the override for layer. Layers
+// the service does not log under are rejected.
+func (lv *Levels) SetLevel(layer string, level string) error {
+	if layer == "" {
+		lvl, err := parseLevel(level)
+		if err != nil {
+			return err
+		}
+		lv.global.SetLevel(lvl)
+		return nil
+	}
+	if err := checkLayer(layer); err != nil {
+		return err
+	}
+
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+
+	if level == "" {
+		delete(lv.layers, layer)
+		return nil
+	}
+
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	lv.layers[layer] = lvl
+	return nil
+}
+
+// Reset replaces the global level and all layer overrides, e.g. after the
+// configuration was reloaded. Runtime changes made through SetLevel are
+// discarded.
+func (lv *Levels) Reset(logLevel string, layerLevels string) error {
+	base, err := parseLevel(logLevel)
+	if err != nil {
+		return err
+	}
+
+	layers, err := ParseLayerLevels(layerLevels)
+	if err != nil {
+		return err
+	}
+
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+
+	lv.base = base
+	lv.layers = layers
+	lv.global.SetLevel(base)
+	return nil
+}
+
+// ToggleDebug switches the global level between debug and the level the
+// service was started with. It is wired to SIGUSR1.
+func (lv *Levels) ToggleDebug() zapcore.Level {
+	lv.mu.RLock()
+	base := lv.base
+	lv.mu.RUnlock()
+
+	if lv.global.Level() == zapcore.DebugLevel && base != zapcore.DebugLevel {
+		lv.global.SetLevel(base)
+	} else {
+		lv.global.SetLevel(zapcore.DebugLevel)
+	}
+	return lv.global.Level()
+}
+
+func (lv *Levels) Snapshot() LevelsSnapshot {
+	lv.mu.RLock()
+	defer lv.mu.RUnlock()
+
+	layers := make(map[string]string, len(lv.layers))
+	for layer, lvl := range lv.layers {
+		layers[layer] = lvl.String()
+	}
+
+	return LevelsSnapshot{
+		Level:  lv.global.Level().String(),
+		Layers: layers,
+	}
+}
+
+// String renders the overrides in the same form ParseLayerLevels accepts.
+func (s LevelsSnapshot) String() string {
+	pairs := make([]string, 0, len(s.Layers))
+	for layer, lvl := range s.Layers {
+		pairs = append(pairs, layer+"="+lvl)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func parseLevel(level string) (zapcore.Level, error) {
+	switch level {
+	case "debug":
+		return zapcore.DebugLevel, nil
+	case "info":
+		return zapcore.InfoLevel, nil
+	case "warn":
+		return zapcore.WarnLevel, nil
+	case "error":
+		return zapcore.ErrorLevel, nil
+	default:
+		return zapcore.InfoLevel, fmt.Errorf("unknown log level %q", level)
+	}
+}
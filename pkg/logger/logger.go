@@ -1,12 +1,21 @@
 package logger
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/FlyKarlik/orderService/config"
+
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+// Logger writes structured entries. The details of Debug, Info, Warn and
+// Error are key/value pairs redacted by the Redactor, and so is the error
+// message when the "error" key is configured. The *f variants format their
+// details into one string that has no keys to match, so they are never
+// redacted and must not be given sensitive values.
 type Logger interface {
 	Debug(layer string, method string, msg string, args ...interface{})
 	Debugf(layer string, method string, msg string, format string, args ...interface{})
@@ -16,15 +25,43 @@ type Logger interface {
 	Errorf(layer string, method string, msg string, err error, format string, args ...interface{})
 	Warn(layer string, method string, msg string, err error, args ...interface{})
 	Warnf(layer string, method string, msg string, err error, format string, args ...interface{})
+	WithContext(ctx context.Context) Logger
+	Levels() *Levels
+	Redactor() *Redactor
 }
 
 type logger struct {
-	logger *zap.Logger
+	logger   *zap.Logger
+	levels   *Levels
+	redactor *Redactor
 }
 
-func New(logLevel string) (Logger, error) {
+// New builds the service logger. Entries are filtered by Levels, which can be
+// changed at runtime, so the zap core itself is opened up to debug. When
+// sampling is configured, repeated entries with the same level and message
+// are thinned out per second after the first LogSamplingInitial ones.
+func New(cfg *config.Config) (Logger, error) {
+	levels, err := newLevels(cfg.OrderService.LogLevel, cfg.OrderService.LogLayerLevels)
+	if err != nil {
+		return nil, err
+	}
+
+	redactor, err := NewRedactor(cfg.OrderService.LogRedactKeys, cfg.OrderService.LogRedactSalt)
+	if err != nil {
+		return nil, err
+	}
+
+	var sampling *zap.SamplingConfig
+	if cfg.OrderService.LogSamplingInitial > 0 {
+		sampling = &zap.SamplingConfig{
+			Initial:    cfg.OrderService.LogSamplingInitial,
+			Thereafter: cfg.OrderService.LogSamplingThereafter,
+		}
+	}
+
 	config := zap.Config{
-		Level:            getZapLevel(logLevel),
+		Level:            zap.NewAtomicLevelAt(zapcore.DebugLevel),
+		Sampling:         sampling,
 		Encoding:         "json",
 		OutputPaths:      []string{"stdout"},
 		ErrorOutputPaths: []string{"stderr"},
@@ -44,35 +81,56 @@ func New(logLevel string) (Logger, error) {
 	}
 
 	return &logger{
-		logger: zapLogger,
+		logger:   zapLogger,
+		levels:   levels,
+		redactor: redactor,
 	}, nil
 }
 
-func getZapLevel(logLevel string) zap.AtomicLevel {
-	switch logLevel {
-	case "debug":
-		return zap.NewAtomicLevelAt(zap.DebugLevel)
-	case "info":
-		return zap.NewAtomicLevelAt(zap.InfoLevel)
-	case "warn":
-		return zap.NewAtomicLevelAt(zap.WarnLevel)
-	case "error":
-		return zap.NewAtomicLevelAt(zap.ErrorLevel)
-	default:
-		return zap.NewAtomicLevelAt(zap.InfoLevel)
+func (l *logger) Levels() *Levels {
+	return l.levels
+}
+
+func (l *logger) Redactor() *Redactor {
+	return l.redactor
+}
+
+// WithContext returns a logger that adds trace_id and span_id of the span
+// active in ctx to every entry, so log lines can be joined with traces.
+func (l *logger) WithContext(ctx context.Context) Logger {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.IsValid() {
+		return l
+	}
+
+	return &logger{
+		logger: l.logger.With(
+			zap.String("trace_id", spanCtx.TraceID().String()),
+			zap.String("span_id", spanCtx.SpanID().String()),
+		),
+		levels:   l.levels,
+		redactor: l.redactor,
 	}
 }
 
 func (l *logger) Debug(layer string, method string, msg string, args ...interface{}) {
+	if !l.levels.Enabled(layer, zapcore.DebugLevel) {
+		return
+	}
+
 	l.logger.Debug(
 		msg,
 		zap.String("layer", layer),
 		zap.String("method", method),
-		zap.Any("details", args),
+		zap.Any("details", l.redactor.RedactArgs(args)),
 	)
 }
 
 func (l *logger) Debugf(layer string, method string, msg string, format string, args ...interface{}) {
+	if !l.levels.Enabled(layer, zapcore.DebugLevel) {
+		return
+	}
+
 	l.logger.Debug(
 		msg,
 		zap.String("layer", layer),
@@ -82,15 +140,23 @@ func (l *logger) Debugf(layer string, method string, msg string, format string,
 }
 
 func (l *logger) Info(layer string, method string, msg string, args ...interface{}) {
+	if !l.levels.Enabled(layer, zapcore.InfoLevel) {
+		return
+	}
+
 	l.logger.Info(
 		msg,
 		zap.String("layer", layer),
 		zap.String("method", method),
-		zap.Any("details", args),
+		zap.Any("details", l.redactor.RedactArgs(args)),
 	)
 }
 
 func (l *logger) Infof(layer string, method string, msg string, format string, args ...interface{}) {
+	if !l.levels.Enabled(layer, zapcore.InfoLevel) {
+		return
+	}
+
 	l.logger.Info(
 		msg,
 		zap.String("layer", layer),
@@ -100,51 +166,67 @@ func (l *logger) Infof(layer string, method string, msg string, format string, a
 }
 
 func (l *logger) Error(layer string, method string, msg string, err error, args ...interface{}) {
+	if !l.levels.Enabled(layer, zapcore.ErrorLevel) {
+		return
+	}
+
 	l.logger.Error(
 		msg,
 		zap.String("layer", layer),
 		zap.String("method", method),
-		zap.Error(err),
-		zap.Any("details", args),
+		l.errorField(err),
+		zap.Any("details", l.redactor.RedactArgs(args)),
 	)
 }
 
 func (l *logger) Errorf(layer string, method string, msg string, err error, format string, args ...interface{}) {
+	if !l.levels.Enabled(layer, zapcore.ErrorLevel) {
+		return
+	}
+
 	l.logger.Error(
 		msg,
 		zap.String("layer", layer),
 		zap.String("method", method),
-		zap.Error(err),
+		l.errorField(err),
 		zap.Any("details", fmt.Sprintf(format, args...)),
 	)
 }
 
 func (l *logger) Warn(layer string, method string, msg string, err error, args ...interface{}) {
+	if !l.levels.Enabled(layer, zapcore.WarnLevel) {
+		return
+	}
+
 	if err != nil {
 		l.logger.Warn(
 			msg,
 			zap.String("layer", layer),
 			zap.String("method", method),
-			zap.Error(err),
-			zap.Any("details", args),
+			l.errorField(err),
+			zap.Any("details", l.redactor.RedactArgs(args)),
 		)
 	} else {
 		l.logger.Warn(
 			msg,
 			zap.String("layer", layer),
 			zap.String("method", method),
-			zap.Any("details", args),
+			zap.Any("details", l.redactor.RedactArgs(args)),
 		)
 	}
 }
 
 func (l *logger) Warnf(layer string, method string, msg string, err error, format string, args ...interface{}) {
+	if !l.levels.Enabled(layer, zapcore.WarnLevel) {
+		return
+	}
+
 	if err != nil {
 		l.logger.Warn(
 			msg,
 			zap.String("layer", layer),
 			zap.String("method", method),
-			zap.String("error", err.Error()),
+			l.errorField(err),
 			zap.Any("details", fmt.Sprintf(format, args...)),
 		)
 	} else {
@@ -156,3 +238,14 @@ func (l *logger) Warnf(layer string, method string, msg string, err error, forma
 		)
 	}
 }
+
+// errorField logs err under "error", redacted when that key is configured.
+func (l *logger) errorField(err error) zap.Field {
+	if err == nil {
+		return zap.Error(nil)
+	}
+	if value, redacted := l.redactor.Redact(ErrorKey, err.Error()); redacted {
+		return zap.Any(ErrorKey, value)
+	}
+	return zap.Error(err)
+}
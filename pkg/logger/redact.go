@@ -0,0 +1,161 @@
+package logger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	RedactModeMask = "mask"
+	RedactModeHash = "hash"
+)
+
+const redactedMask = "***"
+
+// ErrorKey is the key error messages are redacted under, in the error field
+// of log entries and in the exception.message of span events.
+const ErrorKey = "error"
+
+// Redactor masks or hashes the values of sensitive keys before they leave the
+// process. The same instance is used for log details and span attributes, so
+// a hashed user_id in a log line matches the one on the span.
+//
+// A configured key matches a field whose name, with dots read as
+// underscores, equals the key or ends with "_"+key: "user_id" covers
+// "user_id", "expected_user_id", "user.id" and "order.user_id". Error
+// messages are free text, which may embed any value, and are redacted as a
+// whole under ErrorKey.
+type Redactor struct {
+	modes map[string]string
+	salt  []byte
+}
+
+// NewRedactor parses a "key=mode,key=mode" policy, e.g.
+// "user_id=hash,price=mask,quantity=mask". Hashes are keyed with salt so
+// they cannot be reversed by hashing candidate identifiers.
+func NewRedactor(policy string, salt string) (*Redactor, error) {
+	modes := make(map[string]string)
+	for _, pair := range strings.Split(policy, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, mode, ok := strings.Cut(pair, "=")
+		key = normalizeKey(strings.TrimSpace(key))
+		mode = strings.TrimSpace(mode)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid redaction rule %q, expected key=mode", pair)
+		}
+		if mode != RedactModeMask && mode != RedactModeHash {
+			return nil, fmt.Errorf("unknown redaction mode %q for key %q", mode, key)
+		}
+		modes[key] = mode
+	}
+
+	return &Redactor{
+		modes: modes,
+		salt:  []byte(salt),
+	}, nil
+}
+
+// Enabled reports whether any key is configured for redaction.
+func (r *Redactor) Enabled() bool {
+	return r != nil && len(r.modes) > 0
+}
+
+// Redact returns the value to emit for key: value itself, a fixed mask, or a
+// salted hash of the value.
+func (r *Redactor) Redact(key string, value interface{}) (interface{}, bool) {
+	mode, ok := r.mode(key)
+	if !ok {
+		return value, false
+	}
+
+	switch mode {
+	case RedactModeHash:
+		return r.hash(stringify(value)), true
+	default:
+		return redactedMask, true
+	}
+}
+
+// RedactArgs applies Redact to the values of key/value pairs in args. args is
+// copied before it is changed.
+func (r *Redactor) RedactArgs(args []interface{}) []interface{} {
+	if !r.Enabled() {
+		return args
+	}
+
+	var out []interface{}
+	for i := 0; i+1 < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			continue
+		}
+
+		value, redacted := r.Redact(key, args[i+1])
+		if !redacted {
+			continue
+		}
+		if out == nil {
+			out = append([]interface{}(nil), args...)
+		}
+		out[i+1] = value
+	}
+
+	if out == nil {
+		return args
+	}
+	return out
+}
+
+func (r *Redactor) mode(key string) (string, bool) {
+	if !r.Enabled() {
+		return "", false
+	}
+
+	key = normalizeKey(key)
+	if mode, ok := r.modes[key]; ok {
+		return mode, true
+	}
+	for configured, mode := range r.modes {
+		if strings.HasSuffix(key, "_"+configured) {
+			return mode, true
+		}
+	}
+	return "", false
+}
+
+func (r *Redactor) hash(value string) string {
+	mac := hmac.New(sha256.New, r.salt)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, ".", "_"))
+}
+
+// stringify renders value the way it is logged, dereferencing pointers so
+// equal values hash equally regardless of how they are held.
+func stringify(value interface{}) string {
+	v := reflect.ValueOf(value)
+	for v.IsValid() && v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		if s, ok := v.Interface().(fmt.Stringer); ok {
+			return s.String()
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return ""
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,159 @@
+package logger
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewRedactor(t *testing.T) {
+	cases := []struct {
+		name    string
+		policy  string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "empty", policy: "", want: map[string]string{}},
+		{
+			name:   "modes and spacing",
+			policy: " user_id=hash , price=mask,,Order.ID=mask",
+			want:   map[string]string{"user_id": "hash", "price": "mask", "order_id": "mask"},
+		},
+		{name: "missing mode", policy: "user_id", wantErr: true},
+		{name: "missing key", policy: "=mask", wantErr: true},
+		{name: "unknown mode", policy: "user_id=encrypt", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewRedactor(tc.policy, "salt")
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("NewRedactor(%q) error = nil, want an error", tc.policy)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewRedactor(%q) error = %v", tc.policy, err)
+			}
+			if !reflect.DeepEqual(r.modes, tc.want) {
+				t.Fatalf("NewRedactor(%q) modes = %v, want %v", tc.policy, r.modes, tc.want)
+			}
+			if r.Enabled() != (len(tc.want) > 0) {
+				t.Fatalf("Enabled() = %v with modes %v", r.Enabled(), tc.want)
+			}
+		})
+	}
+}
+
+func TestRedactMatchesKeySuffixes(t *testing.T) {
+	r, err := NewRedactor("user_id=mask,error=mask", "")
+	if err != nil {
+		t.Fatalf("NewRedactor() error = %v", err)
+	}
+
+	cases := []struct {
+		key  string
+		want bool
+	}{
+		{key: "user_id", want: true},
+		{key: "USER_ID", want: true},
+		{key: "user.id", want: true},
+		{key: "order.user_id", want: true},
+		{key: "expected_user_id", want: true},
+		{key: "error", want: true},
+		{key: "userid"},
+		{key: "user_ids"},
+		{key: "superuser_id"},
+		{key: "market_id"},
+	}
+	for _, tc := range cases {
+		value, redacted := r.Redact(tc.key, "42")
+		if redacted != tc.want {
+			t.Fatalf("Redact(%q) redacted = %v, want %v", tc.key, redacted, tc.want)
+		}
+		if redacted && value != redactedMask {
+			t.Fatalf("Redact(%q) = %v, want %q", tc.key, value, redactedMask)
+		}
+		if !redacted && value != "42" {
+			t.Fatalf("Redact(%q) = %v, want the value unchanged", tc.key, value)
+		}
+	}
+}
+
+func TestRedactHash(t *testing.T) {
+	r, err := NewRedactor("user_id=hash", "salt-a")
+	if err != nil {
+		t.Fatalf("NewRedactor() error = %v", err)
+	}
+	other, err := NewRedactor("user_id=hash", "salt-b")
+	if err != nil {
+		t.Fatalf("NewRedactor() error = %v", err)
+	}
+
+	id := "7f1c7a52-5c1e-4b5e-9a8e-2f0c1d9d3a11"
+	first, _ := r.Redact("user_id", id)
+	again, _ := r.Redact("order.user_id", &id)
+	if first != again {
+		t.Fatalf("hash of the same value = %v and %v, want equal", first, again)
+	}
+	if first == id || len(first.(string)) != 16 {
+		t.Fatalf("hash = %v, want 16 hex characters", first)
+	}
+	if salted, _ := other.Redact("user_id", id); salted == first {
+		t.Fatalf("hash with another salt = %v, want it to differ", salted)
+	}
+	if different, _ := r.Redact("user_id", id+"0"); different == first {
+		t.Fatalf("hash of another value = %v, want it to differ", different)
+	}
+}
+
+func TestRedactArgsCopies(t *testing.T) {
+	r, err := NewRedactor("price=mask", "")
+	if err != nil {
+		t.Fatalf("NewRedactor() error = %v", err)
+	}
+
+	args := []interface{}{"price", "100.5", "quantity", 3, "dangling"}
+	got := r.RedactArgs(args)
+	if want := []interface{}{"price", redactedMask, "quantity", 3, "dangling"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("RedactArgs() = %v, want %v", got, want)
+	}
+	if args[1] != "100.5" {
+		t.Fatalf("RedactArgs() changed its input to %v", args)
+	}
+}
+
+func TestLoggerRedaction(t *testing.T) {
+	r, err := NewRedactor("user_id=mask,error=mask", "")
+	if err != nil {
+		t.Fatalf("NewRedactor() error = %v", err)
+	}
+	levels, err := newLevels("debug", "")
+	if err != nil {
+		t.Fatalf("newLevels() error = %v", err)
+	}
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := &logger{logger: zap.New(core), levels: levels, redactor: r}
+
+	l.Error("repo", "test", "failed", errors.New("user 42 not found"), "user_id", "42")
+	l.Infof("repo", "test", "formatted", "user_id=%s", "42")
+
+	entries := logs.AllUntimed()
+	if len(entries) != 2 {
+		t.Fatalf("logged %d entries, want 2", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["error"] != redactedMask {
+		t.Fatalf("error = %v, want it redacted", fields["error"])
+	}
+	if details := fields["details"]; !reflect.DeepEqual(details, []interface{}{"user_id", redactedMask}) {
+		t.Fatalf("details = %v, want user_id redacted", details)
+	}
+	if details := entries[1].ContextMap()["details"]; details != "user_id=42" {
+		t.Fatalf("Infof details = %v, want them unredacted", details)
+	}
+}
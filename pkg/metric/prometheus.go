@@ -7,7 +7,13 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-func StartPrometheus(cfg *config.Config) error {
-	http.Handle("/metrics", promhttp.Handler())
-	return http.ListenAndServe(cfg.Infrastructure.Prometheus.Address, nil)
+// NewPrometheusServer serves /metrics on the internal metrics address.
+func NewPrometheusServer(cfg *config.Config) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	return &http.Server{
+		Addr:    cfg.Infrastructure.Prometheus.Address,
+		Handler: mux,
+	}
 }
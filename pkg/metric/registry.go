@@ -0,0 +1,166 @@
+package metric
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "order_service"
+
+// Rejection reasons used as the "reason" label. Keep this set small and
+// closed: it is exported as a label value.
+const (
+	RejectReasonMarketNotFound      = "market_not_found"
+	RejectReasonUpstreamFailure     = "upstream_failure"
+	RejectReasonStorageFailure      = "storage_failure"
+	RejectReasonExecution           = "execution"
+	RejectReasonRisk                = "risk"
+	RejectReasonFunds               = "funds"
+	RejectReasonMarketNotOpen       = "market_not_open"
+	RejectReasonMarketAssetsUnknown = "market_assets_unknown"
+	RejectReasonSelfTrade           = "self_trade"
+)
+
+// Cancellation reasons used as the "reason" label of orders_cancelled_total.
+const (
+	CancelReasonUser       = "user"
+	CancelReasonAdmin      = "admin"
+	CancelReasonDisconnect = "disconnect"
+	CancelReasonHalt       = "halt"
+)
+
+const (
+	CacheResultHit   = "hit"
+	CacheResultMiss  = "miss"
+	CacheResultError = "error"
+	// CacheResultVersionMismatch counts entries that were written with another
+	// envelope format, codec or schema and were treated as misses.
+	CacheResultVersionMismatch = "version_mismatch"
+)
+
+// Registry holds the business level collectors. Labels are limited to values
+// from closed sets (order type, reason, cache name) plus market_id, which is
+// bounded by the markets published by SpotInstrumentService. User and order
+// identifiers are never used as labels.
+type Registry struct {
+	ordersCreated   *prometheus.CounterVec
+	ordersRejected  *prometheus.CounterVec
+	timeToFill      *prometheus.HistogramVec
+	ordersExpired   *prometheus.CounterVec
+	ordersCancelled *prometheus.CounterVec
+	riskRejections  *prometheus.CounterVec
+	cacheRequests   *prometheus.CounterVec
+	upstreamLatency *prometheus.HistogramVec
+	activeStreams   prometheus.Gauge
+}
+
+func NewRegistry(registerer prometheus.Registerer) *Registry {
+	r := &Registry{
+		ordersCreated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_created_total",
+			Help:      "Orders accepted and stored, by market and order type.",
+		}, []string{"market_id", "order_type"}),
+		ordersRejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_rejected_total",
+			Help:      "Orders rejected, by reason.",
+		}, []string{"reason"}),
+		timeToFill: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "order_time_to_fill_seconds",
+			Help:      "Time between order creation and the order being filled.",
+			Buckets:   []float64{0.1, 0.5, 1, 5, 15, 30, 60, 120, 300, 900, 3600},
+		}, []string{"order_type"}),
+		ordersExpired: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_expired_total",
+			Help:      "Orders expired by their time in force, by time in force.",
+		}, []string{"time_in_force"}),
+		ordersCancelled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_cancelled_total",
+			Help:      "Orders cancelled, by reason.",
+		}, []string{"reason"}),
+		riskRejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_risk_rejected_total",
+			Help:      "Orders rejected by pre-trade risk checks, by check.",
+		}, []string{"check"}),
+		cacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_requests_total",
+			Help:      "Cache lookups, by cache and result (hit, miss, error, version_mismatch).",
+		}, []string{"cache", "result"}),
+		upstreamLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "upstream_request_duration_seconds",
+			Help:      "Latency of calls to upstream services.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"service", "method", "success"}),
+		activeStreams: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "order_streams_active",
+			Help:      "Order status subscriptions currently open.",
+		}),
+	}
+
+	registerer.MustRegister(
+		r.ordersCreated,
+		r.ordersRejected,
+		r.timeToFill,
+		r.ordersExpired,
+		r.ordersCancelled,
+		r.riskRejections,
+		r.cacheRequests,
+		r.upstreamLatency,
+		r.activeStreams,
+	)
+
+	return r
+}
+
+func (r *Registry) OrderCreated(marketID string, orderType string) {
+	r.ordersCreated.WithLabelValues(marketID, orderType).Inc()
+}
+
+func (r *Registry) OrderRejected(reason string) {
+	r.ordersRejected.WithLabelValues(reason).Inc()
+}
+
+func (r *Registry) OrderFilled(orderType string, timeToFill time.Duration) {
+	r.timeToFill.WithLabelValues(orderType).Observe(timeToFill.Seconds())
+}
+
+func (r *Registry) OrderExpired(timeInForce string) {
+	r.ordersExpired.WithLabelValues(timeInForce).Inc()
+}
+
+func (r *Registry) OrderCancelled(reason string, count int) {
+	r.ordersCancelled.WithLabelValues(reason).Add(float64(count))
+}
+
+func (r *Registry) RiskRejected(check string) {
+	r.riskRejections.WithLabelValues(check).Inc()
+}
+
+func (r *Registry) CacheRequest(cache string, result string) {
+	r.cacheRequests.WithLabelValues(cache, result).Inc()
+}
+
+func (r *Registry) UpstreamRequest(service string, method string, duration time.Duration, err error) {
+	success := "true"
+	if err != nil {
+		success = "false"
+	}
+	r.upstreamLatency.WithLabelValues(service, method, success).Observe(duration.Seconds())
+}
+
+func (r *Registry) StreamOpened() {
+	r.activeStreams.Inc()
+}
+
+func (r *Registry) StreamClosed() {
+	r.activeStreams.Dec()
+}
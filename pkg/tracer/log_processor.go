@@ -0,0 +1,42 @@
+package tracer
+
+import (
+	"context"
+
+	"github.com/FlyKarlik/orderService/pkg/logger"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// logSpanProcessor writes every finished span to the service log at debug
+// level. It is enabled with OPENTELEMETRY_LOG_SPANS.
+type logSpanProcessor struct {
+	logger logger.Logger
+}
+
+func newLogSpanProcessor(l logger.Logger) *logSpanProcessor {
+	return &logSpanProcessor{logger: l}
+}
+
+func (p *logSpanProcessor) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+func (p *logSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	const layer = "tracer"
+	const method = "OnEnd"
+
+	p.logger.Debug(layer, method, "span ended",
+		"name", s.Name(),
+		"trace_id", s.SpanContext().TraceID().String(),
+		"span_id", s.SpanContext().SpanID().String(),
+		"parent_span_id", s.Parent().SpanID().String(),
+		"duration", s.EndTime().Sub(s.StartTime()),
+		"status", s.Status().Code.String(),
+	)
+}
+
+func (p *logSpanProcessor) Shutdown(context.Context) error {
+	return nil
+}
+
+func (p *logSpanProcessor) ForceFlush(context.Context) error {
+	return nil
+}
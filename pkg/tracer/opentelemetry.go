@@ -3,51 +3,122 @@ package tracer
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/FlyKarlik/orderService/config"
+	"github.com/FlyKarlik/orderService/pkg/logger"
 	"go.opentelemetry.io/otel"
 
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 )
 
-func New(ctx context.Context, config *config.Config) (func(context.Context) error, error) {
+const (
+	ExporterOTLPGRPC = "otlp_grpc"
+	ExporterOTLPHTTP = "otlp_http"
+	ExporterStdout   = "stdout"
+)
+
+const (
+	SamplerAlways           = "always"
+	SamplerNever            = "never"
+	SamplerRatio            = "ratio"
+	SamplerParentAlways     = "parentbased_always"
+	SamplerParentBasedRatio = "parentbased_ratio"
+)
+
+// New installs the global tracer provider and propagator. When tracing is
+// disabled only the propagator is installed, so trace context still flows
+// through the service, and the returned shutdown function is a no-op.
+func New(ctx context.Context, config *config.Config, l logger.Logger) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	otelCfg := config.Infrastructure.Opentelemetry
+	if !otelCfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
 	res, err := resource.New(ctx,
 		resource.WithAttributes(
-			semconv.ServiceName(config.Infrastructure.Opentelemetry.ServiceName),
+			semconv.ServiceName(otelCfg.ServiceName),
 		),
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	exporter, err := otlptracehttp.New(
-		ctx,
-		otlptracehttp.WithEndpoint(fmt.Sprintf(
-			"%s:%s",
-			config.Infrastructure.Opentelemetry.Host,
-			config.Infrastructure.Opentelemetry.Port),
-		),
-		otlptracehttp.WithInsecure(),
-	)
+	exporter, err := newExporter(ctx, otelCfg)
 	if err != nil {
 		return nil, err
 	}
 
-	tp := sdktrace.NewTracerProvider(
+	exporter = newRedactingExporter(exporter, l.Redactor())
+
+	sampler, err := newSampler(otelCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []sdktrace.TracerProviderOption{
 		sdktrace.WithBatcher(exporter),
 		sdktrace.WithResource(res),
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
-	)
+		sdktrace.WithSampler(sampler),
+	}
+	if otelCfg.LogSpans {
+		opts = append(opts, sdktrace.WithSpanProcessor(newLogSpanProcessor(l)))
+	}
+
+	tp := sdktrace.NewTracerProvider(opts...)
 
 	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
-		propagation.TraceContext{},
-		propagation.Baggage{},
-	))
 
 	return tp.Shutdown, nil
 }
+
+func newExporter(ctx context.Context, otelCfg config.OpentelemetryConfig) (sdktrace.SpanExporter, error) {
+	endpoint := fmt.Sprintf("%s:%s", otelCfg.Host, otelCfg.Port)
+
+	switch otelCfg.Exporter {
+	case ExporterOTLPGRPC:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint)}
+		if otelCfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case ExporterOTLPHTTP, "":
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+		if otelCfg.Insecure || otelCfg.Exporter == "" {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unknown opentelemetry exporter %q", otelCfg.Exporter)
+	}
+}
+
+func newSampler(otelCfg config.OpentelemetryConfig) (sdktrace.Sampler, error) {
+	switch otelCfg.Sampler {
+	case SamplerAlways, "":
+		return sdktrace.AlwaysSample(), nil
+	case SamplerNever:
+		return sdktrace.NeverSample(), nil
+	case SamplerRatio:
+		return sdktrace.TraceIDRatioBased(otelCfg.SampleRatio), nil
+	case SamplerParentAlways:
+		return sdktrace.ParentBased(sdktrace.AlwaysSample()), nil
+	case SamplerParentBasedRatio:
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(otelCfg.SampleRatio)), nil
+	default:
+		return nil, fmt.Errorf("unknown opentelemetry sampler %q", otelCfg.Sampler)
+	}
+}
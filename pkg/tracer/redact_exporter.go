@@ -0,0 +1,72 @@
+package tracer
+
+import (
+	"context"
+
+	"github.com/FlyKarlik/orderService/pkg/logger"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// redactingExporter applies the logger redaction policy to span and event
+// attributes before spans are handed to the real exporter.
+type redactingExporter struct {
+	sdktrace.SpanExporter
+	redactor *logger.Redactor
+}
+
+func newRedactingExporter(exporter sdktrace.SpanExporter, redactor *logger.Redactor) sdktrace.SpanExporter {
+	if !redactor.Enabled() {
+		return exporter
+	}
+	return &redactingExporter{SpanExporter: exporter, redactor: redactor}
+}
+
+func (e *redactingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	redacted := make([]sdktrace.ReadOnlySpan, len(spans))
+	for i, s := range spans {
+		redacted[i] = &redactedSpan{ReadOnlySpan: s, redactor: e.redactor}
+	}
+	return e.SpanExporter.ExportSpans(ctx, redacted)
+}
+
+type redactedSpan struct {
+	sdktrace.ReadOnlySpan
+	redactor *logger.Redactor
+}
+
+func (s *redactedSpan) Attributes() []attribute.KeyValue {
+	return redactAttributes(s.redactor, s.ReadOnlySpan.Attributes())
+}
+
+func (s *redactedSpan) Events() []sdktrace.Event {
+	events := s.ReadOnlySpan.Events()
+	out := make([]sdktrace.Event, len(events))
+	for i, event := range events {
+		event.Attributes = redactAttributes(s.redactor, event.Attributes)
+		out[i] = event
+	}
+	return out
+}
+
+func redactAttributes(redactor *logger.Redactor, attrs []attribute.KeyValue) []attribute.KeyValue {
+	out := make([]attribute.KeyValue, len(attrs))
+	for i, kv := range attrs {
+		value, redacted := redactor.Redact(redactionKey(kv.Key), kv.Value.Emit())
+		if redacted {
+			kv = attribute.String(string(kv.Key), value.(string))
+		}
+		out[i] = kv
+	}
+	return out
+}
+
+// redactionKey returns the key attr is redacted under. The message of an
+// error recorded on a span follows the error field of log entries.
+func redactionKey(key attribute.Key) string {
+	if key == semconv.ExceptionMessageKey {
+		return logger.ErrorKey
+	}
+	return string(key)
+}
@@ -0,0 +1,107 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/FlyKarlik/orderService/pkg/logger"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func exportSpan(t *testing.T, policy string) tracetest.SpanStub {
+	t.Helper()
+	redactor, err := logger.NewRedactor(policy, "salt")
+	if err != nil {
+		t.Fatalf("NewRedactor() error = %v", err)
+	}
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(newRedactingExporter(exporter, redactor)))
+	defer provider.Shutdown(context.Background())
+
+	_, span := provider.Tracer("test").Start(context.Background(), "CreateOrder")
+	span.SetAttributes(
+		attribute.String("user.id", "42"),
+		attribute.String("order.price", "100.5"),
+		attribute.String("market.id", "btc-usd"),
+	)
+	span.AddEvent("fill", trace.WithAttributes(attribute.String("order.user_id", "42")))
+	span.RecordError(errors.New("order of user 42 not found"))
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("exported %d spans, want 1", len(spans))
+	}
+	return spans[0]
+}
+
+func attributeValues(attrs []attribute.KeyValue) map[string]string {
+	values := make(map[string]string, len(attrs))
+	for _, kv := range attrs {
+		values[string(kv.Key)] = kv.Value.Emit()
+	}
+	return values
+}
+
+func TestRedactingExporter(t *testing.T) {
+	cases := []struct {
+		name           string
+		policy         string
+		wantAttributes map[string]string
+		wantFill       string
+		wantException  string
+	}{
+		{
+			name:           "disabled",
+			policy:         "",
+			wantAttributes: map[string]string{"user.id": "42", "order.price": "100.5", "market.id": "btc-usd"},
+			wantFill:       "42",
+			wantException:  "order of user 42 not found",
+		},
+		{
+			name:           "attributes and events",
+			policy:         "user_id=mask,price=mask",
+			wantAttributes: map[string]string{"user.id": "***", "order.price": "***", "market.id": "btc-usd"},
+			wantFill:       "***",
+			wantException:  "order of user 42 not found",
+		},
+		{
+			name:           "error messages",
+			policy:         "error=mask",
+			wantAttributes: map[string]string{"user.id": "42", "order.price": "100.5", "market.id": "btc-usd"},
+			wantFill:       "42",
+			wantException:  "***",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			span := exportSpan(t, tc.policy)
+
+			got := attributeValues(span.Attributes)
+			for key, want := range tc.wantAttributes {
+				if got[key] != want {
+					t.Fatalf("attribute %s = %q, want %q", key, got[key], want)
+				}
+			}
+
+			events := make(map[string]map[string]string, len(span.Events))
+			for _, event := range span.Events {
+				events[event.Name] = attributeValues(event.Attributes)
+			}
+			if got := events["fill"]["order.user_id"]; got != tc.wantFill {
+				t.Fatalf("fill event order.user_id = %q, want %q", got, tc.wantFill)
+			}
+			if got := events["exception"]["exception.message"]; got != tc.wantException {
+				t.Fatalf("exception.message = %q, want %q", got, tc.wantException)
+			}
+			if got := events["exception"]["exception.type"]; got == "" || got == "***" {
+				t.Fatalf("exception.type = %q, want it kept", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package wal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const snapshotFile = "snapshot"
+
+// WriteSnapshot atomically replaces the snapshot in dir. seq is the last
+// segment whose records are included in payload; replay continues after it.
+func WriteSnapshot(dir string, seq uint64, payload []byte) error {
+	body := make([]byte, 8+len(payload))
+	binary.BigEndian.PutUint64(body[:8], seq)
+	copy(body[8:], payload)
+
+	record := make([]byte, recordHeader+len(body))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(body)))
+	binary.BigEndian.PutUint32(record[4:8], crc32Checksum(body))
+	copy(record[recordHeader:], body)
+
+	path := filepath.Join(dir, snapshotFile)
+	tmp := path + ".tmp"
+	if err := writeFileSync(tmp, record); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// ReadSnapshot returns the snapshot payload and the segment it covers. A
+// missing snapshot yields seq 0 and a nil payload. A damaged snapshot is an
+// error wrapping ErrCorrupt: segments it covered may already be deleted, so
+// it cannot be skipped safely.
+func ReadSnapshot(dir string) (uint64, []byte, error) {
+	data, err := os.ReadFile(filepath.Join(dir, snapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil, nil
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	body, size, err := readRecord(data)
+	if err != nil {
+		return 0, nil, fmt.Errorf("snapshot: %w", err)
+	}
+	if size != len(data) {
+		return 0, nil, fmt.Errorf("snapshot: %w: trailing data", ErrCorrupt)
+	}
+	if len(body) < 8 {
+		return 0, nil, fmt.Errorf("snapshot: %w: missing sequence", ErrCorrupt)
+	}
+
+	return binary.BigEndian.Uint64(body[:8]), body[8:], nil
+}
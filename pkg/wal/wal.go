@@ -0,0 +1,439 @@
+package wal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SyncAlways   = "always"
+	SyncInterval = "interval"
+	SyncNone     = "none"
+)
+
+const (
+	segmentPrefix = "wal-"
+	segmentSuffix = ".log"
+	recordHeader  = 8
+	maxRecordSize = 64 << 20
+)
+
+// ErrCorrupt reports a record whose length or checksum does not match, or a
+// record cut short by a crash.
+var ErrCorrupt = errors.New("wal: corrupt record")
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Log is an append-only log split into numbered segments. Every record is
+// framed as [length uint32][crc32c uint32][payload], so torn writes and bit
+// rot are detected on replay.
+//
+// Rotate seals the current segment and starts the next one; a snapshot that
+// covers every sealed segment lets Remove delete them.
+type Log struct {
+	dir      string
+	policy   string
+	interval time.Duration
+
+	mu    sync.Mutex
+	seq   uint64
+	file  *os.File
+	dirty bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Open opens the newest segment in dir for appending, creating dir and the
+// first segment when needed. With SyncInterval a background goroutine fsyncs
+// every interval until Close.
+func Open(dir string, policy string, interval time.Duration) (*Log, error) {
+	switch policy {
+	case SyncAlways, SyncNone:
+	case SyncInterval:
+		if interval <= 0 {
+			return nil, fmt.Errorf("wal: sync interval must be positive, got %s", interval)
+		}
+	default:
+		return nil, fmt.Errorf("wal: unknown sync policy %q", policy)
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	seq := uint64(1)
+	if len(segments) > 0 {
+		seq = segments[len(segments)-1]
+	}
+
+	l := &Log{
+		dir:      dir,
+		policy:   policy,
+		interval: interval,
+	}
+	if err := l.openSegment(seq); err != nil {
+		return nil, err
+	}
+
+	if policy == SyncInterval {
+		l.stop = make(chan struct{})
+		l.done = make(chan struct{})
+		go l.syncLoop()
+	}
+
+	return l, nil
+}
+
+// Append writes one record. With SyncAlways it returns only after the record
+// is on stable storage.
+func (l *Log) Append(payload []byte) error {
+	if err := l.Write(payload); err != nil {
+		return err
+	}
+	return l.Commit()
+}
+
+// Write appends one record without waiting for stable storage. Records are
+// replayed in the order Write was called; Commit makes them durable.
+func (l *Log) Write(payload []byte) error {
+	if len(payload) > maxRecordSize {
+		return fmt.Errorf("wal: record of %d bytes exceeds limit", len(payload))
+	}
+
+	buf := make([]byte, recordHeader+len(payload))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32Checksum(payload))
+	copy(buf[recordHeader:], payload)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return os.ErrClosed
+	}
+	if _, err := l.file.Write(buf); err != nil {
+		return err
+	}
+	l.dirty = true
+	return nil
+}
+
+// Commit returns once every record written so far is as durable as the
+// sync policy asks for. With SyncAlways it syncs the segment, unless a
+// concurrent Commit already synced it; the other policies leave syncing to
+// the background loop or to the OS.
+func (l *Log) Commit() error {
+	if l.policy != SyncAlways {
+		return nil
+	}
+	return l.Sync()
+}
+
+// Rotate syncs and seals the current segment, starts a new one and returns
+// the sequence number of the sealed segment.
+func (l *Log) Rotate() (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	sealed := l.seq
+	if err := l.closeSegment(); err != nil {
+		return 0, err
+	}
+	if err := l.openSegment(sealed + 1); err != nil {
+		return 0, err
+	}
+	return sealed, nil
+}
+
+// Remove deletes sealed segments up to and including seq.
+func (l *Log) Remove(seq uint64) error {
+	l.mu.Lock()
+	current := l.seq
+	l.mu.Unlock()
+
+	segments, err := listSegments(l.dir)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, s := range segments {
+		if s > seq || s >= current {
+			continue
+		}
+		if err := os.Remove(segmentPath(l.dir, s)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (l *Log) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.syncLocked()
+}
+
+func (l *Log) Close() error {
+	if l.stop != nil {
+		close(l.stop)
+		<-l.done
+		l.stop = nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	return l.closeSegment()
+}
+
+func (l *Log) syncLoop() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			_ = l.Sync()
+		}
+	}
+}
+
+func (l *Log) syncLocked() error {
+	if l.file == nil || !l.dirty {
+		return nil
+	}
+	if err := l.file.Sync(); err != nil {
+		return err
+	}
+	l.dirty = false
+	return nil
+}
+
+func (l *Log) openSegment(seq uint64) error {
+	file, err := os.OpenFile(segmentPath(l.dir, seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	if err := syncDir(l.dir); err != nil {
+		_ = file.Close()
+		return err
+	}
+	l.file = file
+	l.seq = seq
+	return nil
+}
+
+func (l *Log) closeSegment() error {
+	l.dirty = true
+	syncErr := l.syncLocked()
+	closeErr := l.file.Close()
+	l.file = nil
+	return errors.Join(syncErr, closeErr)
+}
+
+// Corruption describes a segment whose tail could not be read. The bytes from
+// Offset on are moved to QuarantinePath and the segment is truncated, so the
+// log stays appendable while the damaged data is kept for inspection.
+type Corruption struct {
+	Segment        uint64
+	Offset         int64
+	QuarantinePath string
+	Err            error
+}
+
+type ReplayResult struct {
+	Records     int
+	Segments    int
+	Corruptions []Corruption
+}
+
+// Replay calls fn for every record in segments newer than after, oldest
+// first. Only the newest segment, the one a crash can leave half written,
+// may end in a damaged tail: it is quarantined and reported in the result
+// rather than failing the replay. Damage in a sealed segment, which was
+// synced whole before the next one started, fails the replay with
+// ErrCorrupt, as does an error from fn.
+func Replay(dir string, after uint64, fn func(payload []byte) error) (ReplayResult, error) {
+	var result ReplayResult
+
+	segments, err := listSegments(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return result, nil
+		}
+		return result, err
+	}
+
+	for i, seq := range segments {
+		if seq <= after {
+			continue
+		}
+		result.Segments++
+
+		n, corruption, err := replaySegment(dir, seq, i == len(segments)-1, fn)
+		result.Records += n
+		if err != nil {
+			return result, err
+		}
+		if corruption != nil {
+			result.Corruptions = append(result.Corruptions, *corruption)
+		}
+	}
+
+	return result, nil
+}
+
+func replaySegment(dir string, seq uint64, active bool, fn func([]byte) error) (int, *Corruption, error) {
+	path := segmentPath(dir, seq)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var records int
+	offset := 0
+	for offset < len(data) {
+		payload, size, err := readRecord(data[offset:])
+		if err != nil {
+			if !active {
+				return records, nil, fmt.Errorf("wal: sealed segment %d at offset %d: %w", seq, offset, err)
+			}
+			corruption, qerr := quarantine(path, seq, data, offset, err)
+			return records, corruption, qerr
+		}
+		if err := fn(payload); err != nil {
+			return records, nil, err
+		}
+		records++
+		offset += size
+	}
+
+	return records, nil, nil
+}
+
+func readRecord(data []byte) ([]byte, int, error) {
+	if len(data) < recordHeader {
+		return nil, 0, fmt.Errorf("%w: truncated header", ErrCorrupt)
+	}
+
+	length := binary.BigEndian.Uint32(data[0:4])
+	checksum := binary.BigEndian.Uint32(data[4:8])
+	if length > maxRecordSize {
+		return nil, 0, fmt.Errorf("%w: length %d exceeds limit", ErrCorrupt, length)
+	}
+	if len(data) < recordHeader+int(length) {
+		return nil, 0, fmt.Errorf("%w: truncated payload", ErrCorrupt)
+	}
+
+	payload := data[recordHeader : recordHeader+int(length)]
+	if crc32Checksum(payload) != checksum {
+		return nil, 0, fmt.Errorf("%w: checksum mismatch", ErrCorrupt)
+	}
+	return payload, recordHeader + int(length), nil
+}
+
+func quarantine(path string, seq uint64, data []byte, offset int, cause error) (*Corruption, error) {
+	quarantinePath := fmt.Sprintf("%s.corrupt-%d", path, time.Now().UnixNano())
+	if err := writeFileSync(quarantinePath, data[offset:]); err != nil {
+		return nil, err
+	}
+	if err := os.Truncate(path, int64(offset)); err != nil {
+		return nil, err
+	}
+
+	return &Corruption{
+		Segment:        seq,
+		Offset:         int64(offset),
+		QuarantinePath: quarantinePath,
+		Err:            cause,
+	}, nil
+}
+
+func crc32Checksum(data []byte) uint32 {
+	return crc32.Checksum(data, crcTable)
+}
+
+func listSegments(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, seq)
+	}
+
+	sort.Slice(segments, func(i, j int) bool { return segments[i] < segments[j] })
+	return segments, nil
+}
+
+func segmentPath(dir string, seq uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%s%020d%s", segmentPrefix, seq, segmentSuffix))
+}
+
+func writeFileSync(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	if err := d.Sync(); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
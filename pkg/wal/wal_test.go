@@ -0,0 +1,280 @@
+package wal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func frame(payload string) []byte {
+	buf := make([]byte, recordHeader+len(payload))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32Checksum([]byte(payload)))
+	copy(buf[recordHeader:], payload)
+	return buf
+}
+
+func frames(payloads ...string) []byte {
+	var buf []byte
+	for _, payload := range payloads {
+		buf = append(buf, frame(payload)...)
+	}
+	return buf
+}
+
+func badChecksum(payload string) []byte {
+	buf := frame(payload)
+	buf[4] ^= 0xff
+	return buf
+}
+
+func writeSegments(t *testing.T, dir string, segments [][]byte) {
+	t.Helper()
+	for i, data := range segments {
+		if err := os.WriteFile(segmentPath(dir, uint64(i+1)), data, 0o640); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+}
+
+func replayAll(t *testing.T, dir string, after uint64) ([]string, ReplayResult, error) {
+	t.Helper()
+	var got []string
+	result, err := Replay(dir, after, func(payload []byte) error {
+		got = append(got, string(payload))
+		return nil
+	})
+	return got, result, err
+}
+
+func TestReplay(t *testing.T) {
+	cases := []struct {
+		name        string
+		segments    [][]byte
+		after       uint64
+		want        []string
+		wantOffset  int64
+		wantCorrupt bool
+		wantErr     bool
+	}{
+		{
+			name:     "clean segments",
+			segments: [][]byte{frames("a", "b"), frames("c")},
+			want:     []string{"a", "b", "c"},
+		},
+		{
+			name:     "after skips covered segments",
+			segments: [][]byte{frames("a", "b"), frames("c")},
+			after:    1,
+			want:     []string{"c"},
+		},
+		{
+			name:        "checksum mismatch in the active segment",
+			segments:    [][]byte{frames("a"), append(frames("b"), badChecksum("c")...)},
+			want:        []string{"a", "b"},
+			wantOffset:  int64(len(frame("b"))),
+			wantCorrupt: true,
+		},
+		{
+			name:        "torn final record",
+			segments:    [][]byte{append(frames("a", "b"), frame("torn")[:recordHeader+2]...)},
+			want:        []string{"a", "b"},
+			wantOffset:  int64(len(frames("a", "b"))),
+			wantCorrupt: true,
+		},
+		{
+			name:        "torn header",
+			segments:    [][]byte{append(frames("a"), frame("torn")[:3]...)},
+			want:        []string{"a"},
+			wantOffset:  int64(len(frame("a"))),
+			wantCorrupt: true,
+		},
+		{
+			name:     "checksum mismatch in a sealed segment",
+			segments: [][]byte{append(frames("a"), badChecksum("b")...), frames("c")},
+			want:     []string{"a"},
+			wantErr:  true,
+		},
+		{
+			name:     "torn record in a sealed segment",
+			segments: [][]byte{append(frames("a"), frame("torn")[:recordHeader+1]...), frames("c")},
+			want:     []string{"a"},
+			wantErr:  true,
+		},
+		{
+			name:     "damaged sealed segment covered by a snapshot",
+			segments: [][]byte{badChecksum("a"), frames("b")},
+			after:    1,
+			want:     []string{"b"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeSegments(t, dir, tc.segments)
+
+			got, result, err := replayAll(t, dir, tc.after)
+			if tc.wantErr {
+				if !errors.Is(err, ErrCorrupt) {
+					t.Fatalf("Replay() error = %v, want %v", err, ErrCorrupt)
+				}
+			} else if err != nil {
+				t.Fatalf("Replay() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("Replay() records = %q, want %q", got, tc.want)
+			}
+			if result.Records != len(tc.want) {
+				t.Fatalf("Replay() result.Records = %d, want %d", result.Records, len(tc.want))
+			}
+
+			if !tc.wantCorrupt {
+				if len(result.Corruptions) != 0 {
+					t.Fatalf("Replay() corruptions = %+v, want none", result.Corruptions)
+				}
+				return
+			}
+			if len(result.Corruptions) != 1 {
+				t.Fatalf("Replay() corruptions = %+v, want one", result.Corruptions)
+			}
+			corruption := result.Corruptions[0]
+			if corruption.Segment != uint64(len(tc.segments)) || corruption.Offset != tc.wantOffset {
+				t.Fatalf("Replay() corruption at segment %d offset %d, want segment %d offset %d",
+					corruption.Segment, corruption.Offset, len(tc.segments), tc.wantOffset)
+			}
+			if !errors.Is(corruption.Err, ErrCorrupt) {
+				t.Fatalf("Replay() corruption error = %v, want %v", corruption.Err, ErrCorrupt)
+			}
+		})
+	}
+}
+
+func TestReplayQuarantinesTail(t *testing.T) {
+	dir := t.TempDir()
+	tail := append(badChecksum("bad"), frames("after")...)
+	writeSegments(t, dir, [][]byte{append(frames("a"), tail...)})
+
+	_, result, err := replayAll(t, dir, 0)
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if len(result.Corruptions) != 1 {
+		t.Fatalf("Replay() corruptions = %+v, want one", result.Corruptions)
+	}
+
+	quarantined, err := os.ReadFile(result.Corruptions[0].QuarantinePath)
+	if err != nil {
+		t.Fatalf("ReadFile(quarantine) error = %v", err)
+	}
+	if !bytes.Equal(quarantined, tail) {
+		t.Fatalf("quarantine = %x, want %x", quarantined, tail)
+	}
+	segment, err := os.ReadFile(segmentPath(dir, 1))
+	if err != nil {
+		t.Fatalf("ReadFile(segment) error = %v", err)
+	}
+	if !bytes.Equal(segment, frames("a")) {
+		t.Fatalf("segment = %x, want it truncated to %x", segment, frames("a"))
+	}
+
+	// The truncated segment replays cleanly and stays appendable.
+	log, err := Open(dir, SyncAlways, 0)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if err := log.Append([]byte("b")); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	got, result, err := replayAll(t, dir, 0)
+	if err != nil || len(result.Corruptions) != 0 {
+		t.Fatalf("Replay() after quarantine = %+v, %v, want no corruption", result, err)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Replay() records = %q, want %q", got, want)
+	}
+}
+
+func TestRotateAndSnapshotReplay(t *testing.T) {
+	for _, policy := range []string{SyncAlways, SyncNone} {
+		t.Run(policy, func(t *testing.T) {
+			dir := t.TempDir()
+			log, err := Open(dir, policy, 0)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			defer log.Close()
+
+			appendAll := func(payloads ...string) {
+				t.Helper()
+				for _, payload := range payloads {
+					if err := log.Append([]byte(payload)); err != nil {
+						t.Fatalf("Append() error = %v", err)
+					}
+				}
+			}
+
+			appendAll("a", "b")
+			sealed, err := log.Rotate()
+			if err != nil {
+				t.Fatalf("Rotate() error = %v", err)
+			}
+			if sealed != 1 {
+				t.Fatalf("Rotate() sealed = %d, want 1", sealed)
+			}
+			appendAll("c")
+
+			segments, err := listSegments(dir)
+			if err != nil {
+				t.Fatalf("listSegments() error = %v", err)
+			}
+			if want := []uint64{1, 2}; !reflect.DeepEqual(segments, want) {
+				t.Fatalf("segments = %v, want %v", segments, want)
+			}
+			if got, _, err := replayAll(t, dir, 0); err != nil || !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+				t.Fatalf("Replay() = %q, %v, want all records", got, err)
+			}
+
+			// A snapshot covering the sealed segment replays only what
+			// came after it, before and after the segment is removed.
+			if got, result, err := replayAll(t, dir, sealed); err != nil || !reflect.DeepEqual(got, []string{"c"}) || result.Segments != 1 {
+				t.Fatalf("Replay(after=%d) = %q, %+v, %v, want [c] from one segment", sealed, got, result, err)
+			}
+			if err := log.Remove(sealed); err != nil {
+				t.Fatalf("Remove() error = %v", err)
+			}
+			if _, err := os.Stat(segmentPath(dir, sealed)); !errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("sealed segment still exists after Remove(): %v", err)
+			}
+			appendAll("d")
+			if got, _, err := replayAll(t, dir, sealed); err != nil || !reflect.DeepEqual(got, []string{"c", "d"}) {
+				t.Fatalf("Replay(after=%d) after Remove() = %q, %v, want [c d]", sealed, got, err)
+			}
+
+			// Remove never deletes the active segment.
+			if err := log.Remove(10); err != nil {
+				t.Fatalf("Remove() error = %v", err)
+			}
+			if _, err := os.Stat(segmentPath(dir, 2)); err != nil {
+				t.Fatalf("active segment removed: %v", err)
+			}
+		})
+	}
+}
+
+func TestWriteRejectsOversizedRecord(t *testing.T) {
+	log, err := Open(t.TempDir(), SyncNone, 0)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer log.Close()
+
+	if err := log.Write(make([]byte, maxRecordSize+1)); err == nil {
+		t.Fatal("Write() of an oversized record error = nil, want an error")
+	}
+}